@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"net/http"
 	"time"
 
 	data "traceability/data"
@@ -8,18 +9,85 @@ import (
 	"github.com/dgrijalva/jwt-go"
 )
 
-// CreateToken creates token with email
-func CreateToken(email string) (string, error) {
-	user, err := data.FindUserByEmail(email)
+// AccessTokenTTL is how long an access token is valid, clients get a new one
+// with the refresh token of their session
+var AccessTokenTTL = 15 * time.Minute
 
+const (
+	// accessTokenAudience is the audience of access tokens, user tokens have
+	// another one so that neither is taken for the other
+	accessTokenAudience = "traceability-access"
+	// userTokenAudience is the audience of emailed user tokens
+	userTokenAudience = "traceability-user"
+)
+
+// RefreshTokenCookie is the cookie carrying the refresh token, the access
+// token is only returned in the body and sent in the Authorization header
+const RefreshTokenCookie = "refreshtoken"
+
+// CreateToken creates an access token of the session of the user
+func CreateToken(userID string, sessionID string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userid": user.ID,
-		"exp":    time.Now().Add(time.Hour * time.Duration(1000)).Unix(),
+		"aud":    accessTokenAudience,
+		"userid": userID,
+		"sid":    sessionID,
+		"exp":    time.Now().Add(AccessTokenTTL).Unix(),
 		"iat":    time.Now().Unix(),
 	})
-	tokenString, err := token.SignedString([]byte(AppKey))
+	return token.SignedString([]byte(AppKey))
+}
+
+// Login starts a new session of the user, sets the refresh token cookie and
+// the AccessToken and RefreshToken of the user
+func Login(rw http.ResponseWriter, r *http.Request, user *data.User) error {
+	session, refreshToken, err := data.AddSession(user.ID, r.UserAgent())
+	if err != nil {
+		return err
+	}
+	return setTokens(rw, user, session, refreshToken)
+}
+
+// Refresh rotates the refresh token of its session and returns the user
+// with a new access token like Login
+func Refresh(rw http.ResponseWriter, refreshToken string) (data.User, error) {
+	session, refreshToken, err := data.RotateSession(refreshToken)
+	if err != nil {
+		return data.User{}, err
+	}
+	user, err := data.FindUserByID(session.UserID)
+	if err != nil {
+		return user, err
+	}
+	return user, setTokens(rw, &user, session, refreshToken)
+}
+
+func setTokens(rw http.ResponseWriter, user *data.User, session data.Session, refreshToken string) error {
+	accessToken, err := CreateToken(user.ID, session.ID)
 	if err != nil {
-		return "nil", err
+		return err
 	}
-	return tokenString, err
+	user.AccessToken, user.RefreshToken = accessToken, refreshToken
+
+	http.SetCookie(rw, &http.Cookie{
+		Name:     RefreshTokenCookie,
+		Value:    refreshToken,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return nil
+}
+
+// ClearCookies removes the refresh token cookie on logout
+func ClearCookies(rw http.ResponseWriter) {
+	http.SetCookie(rw, &http.Cookie{
+		Name:     RefreshTokenCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
 }
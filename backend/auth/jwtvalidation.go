@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,9 +15,13 @@ import (
 	"github.com/gorilla/mux"
 )
 
-const (
-	// AppKey is used as app key for token creation
-	AppKey = "gucluler.com"
+var (
+	// AppKey is used as app key for token creation, main reads it from APP_KEY
+	AppKey string
+
+	// AllowedOrigins are the origins of the web apps allowed to call the api
+	// with the cookies of their users
+	AllowedOrigins []string
 )
 
 // Middleware is our middleware to check our token is valid. Returning
@@ -26,16 +32,80 @@ func Middleware(next http.Handler) http.Handler {
 	}
 
 	jwtMiddleware := jwtmiddleware.New(jwtmiddleware.Options{
-		ValidationKeyGetter: func(token *jwt.Token) (interface{}, error) {
-			return []byte(AppKey), nil
-		},
-		SigningMethod: jwt.SigningMethodHS256,
+		ValidationKeyGetter: accessTokenKey,
+		SigningMethod:       jwt.SigningMethodHS256,
 	})
 
-	return jwtMiddleware.Handler(next)
+	return withAccessTokens(jwtmiddleware.FromAuthHeader, jwtMiddleware.Handler(checkSession(next)), next)
 }
 
-// ProjectAuthMiddleware authenticate user to project
+// accessTokenKey returns the key of access tokens, tokens meant for another
+// audience, e.g. emailed user tokens, are rejected
+func accessTokenKey(token *jwt.Token) (interface{}, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !claims.VerifyAudience(accessTokenAudience, true) {
+		return nil, errors.New("token is not an access token")
+	}
+	return []byte(AppKey), nil
+}
+
+// withAccessTokens serves requests authenticated with a personal access token
+// with next and all others with the jwt handler. The token acts as its user
+// like a jwt with the user's id; read-only tokens only read.
+func withAccessTokens(extractor jwtmiddleware.TokenExtractor, jwtHandler http.Handler, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		token, err := extractor(r)
+		if err != nil || !data.IsAccessToken(token) {
+			jwtHandler.ServeHTTP(rw, r)
+			return
+		}
+
+		pat, err := data.UseAccessToken(token)
+		if err != nil {
+			http.Error(rw, `{{"error": "401 token revoked"}}`, http.StatusUnauthorized)
+			return
+		}
+		if pat.ReadOnly && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(rw, `{{"error": "403 read-only token"}}`, http.StatusForbidden)
+			return
+		}
+
+		user := &jwt.Token{Claims: jwt.MapClaims{"userid": pat.UserID}, Valid: true}
+		ctx := context.WithValue(r.Context(), "user", user)
+		ctx = context.WithValue(ctx, data.KeyAccessToken{}, pat)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// checkSession rejects access tokens whose session was logged out or expired
+func checkSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := data.GetUserIDFromContext(r.Context())
+		sessionID := data.GetSessionIDFromContext(r.Context())
+		if sessionID == "" || !data.FindActiveSession(sessionID, userID) {
+			http.Error(rw, `{{"error": "401 token revoked"}}`, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// StreamMiddleware checks the token like Middleware and also accepts it in
+// the access_token query parameter, browsers can't set headers when they
+// open websockets and event streams
+func StreamMiddleware(next http.Handler) http.Handler {
+	extractor := jwtmiddleware.FromFirst(jwtmiddleware.FromAuthHeader, jwtmiddleware.FromParameter("access_token"))
+	jwtMiddleware := jwtmiddleware.New(jwtmiddleware.Options{
+		ValidationKeyGetter: accessTokenKey,
+		SigningMethod:       jwt.SigningMethodHS256,
+		Extractor:           extractor,
+	})
+
+	return withAccessTokens(extractor, jwtMiddleware.Handler(checkSession(next)), next)
+}
+
+// ProjectAuthMiddleware authenticate user to project and adds the role of the
+// user in the project to the context
 func ProjectAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 
@@ -48,25 +118,111 @@ func ProjectAuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		userID := data.GetUserIDFromContext(r.Context())
-		isMember := data.UserHasPermission(projectID, userID, "member")
-		isOwner := data.UserHasPermission(projectID, userID, "owner")
-
-		if userID == "" || (!isOwner && !isMember) {
+		if userID == "" {
+			http.Error(rw, `{{"error": "401 user not authenticated"}}`, http.StatusUnauthorized)
+			return
+		}
+		role, err := data.FindProjectRole(projectID, userID)
+		if err != nil {
 			http.Error(rw, `{{"error": "401 user not authenticated"}}`, http.StatusUnauthorized)
 			return
 		}
+
+		if pat, ok := data.GetAccessTokenFromContext(r.Context()); ok {
+			if !pat.AllowsProject(projectID) {
+				http.Error(rw, `{{"error": "403 token not valid for the project"}}`, http.StatusForbidden)
+				return
+			}
+			role = pat.Restrict(role)
+		}
+		if !data.IsEmailVerified(userID) {
+			role = role.ReadOnly()
+		}
+
+		ctx := context.WithValue(r.Context(), data.KeyRole{}, role)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// Require returns a middleware which calls next only if the role added by
+// ProjectAuthMiddleware allows the action, it answers 403 otherwise
+func Require(permission data.Permission) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			role, ok := data.GetRoleFromContext(r.Context())
+			if ok && !role.Can(permission) && !data.IsEmailVerified(data.GetUserIDFromContext(r.Context())) {
+				http.Error(rw, `{{"error": "403 email not verified"}}`, http.StatusForbidden)
+				return
+			}
+			if !ok || !role.Can(permission) {
+				http.Error(rw, `{{"error": "403 permission denied"}}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// RejectAccessTokens answers 403 to requests authenticated with a personal
+// access token, they can't manage tokens and sessions
+func RejectAccessTokens(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, ok := data.GetAccessTokenFromContext(r.Context()); ok {
+			http.Error(rw, `{{"error": "403 access tokens can't be used here"}}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// RejectProjectAccessTokens answers 403 to requests authenticated with a
+// personal access token limited to projects, routes without a project can't
+// keep them to their projects
+func RejectProjectAccessTokens(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if pat, ok := data.GetAccessTokenFromContext(r.Context()); ok && len(pat.ProjectIDs) > 0 {
+			http.Error(rw, `{{"error": "403 token is limited to projects"}}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// RequireVerified calls next only for users who confirmed their email, it
+// answers 403 otherwise
+func RequireVerified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !data.IsEmailVerified(data.GetUserIDFromContext(r.Context())) {
+			http.Error(rw, `{{"error": "403 email not verified"}}`, http.StatusForbidden)
+			return
+		}
 		next.ServeHTTP(rw, r)
 	})
 }
 
-// CORS Middleware
+// IsAllowedOrigin reports whether the origin is one of the AllowedOrigins
+func IsAllowedOrigin(origin string) bool {
+	for _, allowed := range AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS Middleware, only the AllowedOrigins may read the answers and send
+// the cookies along
 func CORS(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); origin != "" && IsAllowedOrigin(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			// the refresh token cookie is sent along by browsers of other origins
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
 		w.Header().Add("Content-Type", "application/json")
 		if r.Method == "OPTIONS" {
-			w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PATCH")
+			w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PATCH,DELETE")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-CSRF-Token, Authorization")
 		} else {
 			h.ServeHTTP(w, r)
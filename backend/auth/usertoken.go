@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"errors"
+
+	data "traceability/data"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SignUserToken returns the signed token of an emailed user token
+func SignUserToken(t data.UserToken) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"aud":     userTokenAudience,
+		"jti":     t.ID,
+		"userid":  t.UserID,
+		"purpose": string(t.Purpose),
+		"exp":     t.ExpiresAt.Unix(),
+		"iat":     t.CreatedAt.Unix(),
+	})
+	return token.SignedString([]byte(AppKey))
+}
+
+// ParseUserToken checks the signature, audience, expiry and purpose of a signed user
+// token and returns the id of the token, it is data.ErrUnknownUserToken if
+// the token isn't valid
+func ParseUserToken(signed string, purpose data.UserTokenPurpose) (string, error) {
+	token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(AppKey), nil
+	})
+	if err != nil || !token.Valid {
+		return "", data.ErrUnknownUserToken
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !claims.VerifyAudience(userTokenAudience, true) || claims["purpose"] != string(purpose) {
+		return "", data.ErrUnknownUserToken
+	}
+	id, ok := claims["jti"].(string)
+	if !ok || id == "" {
+		return "", data.ErrUnknownUserToken
+	}
+	return id, nil
+}
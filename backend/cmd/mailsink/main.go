@@ -0,0 +1,82 @@
+// Command mailsink is a local stand-in SMTP server for trying out the emails
+// of the server, e.g. invitations. It accepts every message and prints it
+// instead of delivering it. Point the server at it with MAIL_SMTP_ADDR.
+//
+//	mailsink [-addr :2525]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("mailsink: ")
+
+	addr := flag.String("addr", ":2525", "address to listen on")
+	flag.Parse()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("listening on", *addr)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go serve(conn)
+	}
+}
+
+// serve speaks just enough SMTP for net/smtp clients
+func serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(format string, args ...interface{}) {
+		tp.PrintfLine(format, args...)
+	}
+
+	reply("220 mailsink ready")
+	var from string
+	var to []string
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 mailsink")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			from, to = strings.TrimSpace(line[len("MAIL FROM:"):]), nil
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			to = append(to, strings.TrimSpace(line[len("RCPT TO:"):]))
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			body, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			fmt.Printf("--- from %s to %s\n%s\n", from, strings.Join(to, ", "), body)
+			reply("250 ok")
+		case cmd == "RSET", cmd == "NOOP":
+			reply("250 ok")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Command mockidp is a local OpenID Connect provider for trying out the
+// login with providers. It logs everyone in as the given user without asking,
+// a login_hint parameter on the authorization request overrides the email.
+// It checks the PKCE code verifier like a real provider.
+//
+//	mockidp [-addr :9999] [-email dev@example.com] [-name Dev] [-verified=true]
+//
+// Configure it as provider in oauth.json:
+//
+//	[{"name": "mock", "label": "Mock", "kind": "oidc", "issuer": "http://localhost:9999",
+//	  "clientID": "traceability", "clientSecret": "secret"}]
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type user struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+type grant struct {
+	user        user
+	challenge   string
+	redirectURI string
+}
+
+var (
+	mu     sync.Mutex
+	codes  = map[string]grant{}
+	tokens = map[string]user{}
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("mockidp: ")
+
+	addr := flag.String("addr", ":9999", "address to listen on")
+	email := flag.String("email", "dev@example.com", "email of the logged in user")
+	name := flag.String("name", "Dev", "name of the logged in user")
+	verified := flag.Bool("verified", true, "whether the email is verified")
+	flag.Parse()
+
+	http.HandleFunc("/.well-known/openid-configuration", func(rw http.ResponseWriter, r *http.Request) {
+		issuer := "http://" + r.Host
+		writeJSON(rw, map[string]interface{}{
+			"issuer":                           issuer,
+			"authorization_endpoint":           issuer + "/authorize",
+			"token_endpoint":                   issuer + "/token",
+			"userinfo_endpoint":                issuer + "/userinfo",
+			"response_types_supported":         []string{"code"},
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+
+	http.HandleFunc("/authorize", func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		redirectURI := q.Get("redirect_uri")
+		if q.Get("response_type") != "code" || redirectURI == "" {
+			http.Error(rw, "response_type=code and redirect_uri are required", http.StatusBadRequest)
+			return
+		}
+		if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+			http.Error(rw, "an S256 code_challenge is required", http.StatusBadRequest)
+			return
+		}
+
+		u := user{Subject: *email, Email: *email, EmailVerified: *verified, Name: *name}
+		if hint := q.Get("login_hint"); hint != "" {
+			u.Subject, u.Email, u.Name = hint, hint, strings.Split(hint, "@")[0]
+		}
+		code := random()
+		mu.Lock()
+		codes[code] = grant{user: u, challenge: q.Get("code_challenge"), redirectURI: redirectURI}
+		mu.Unlock()
+
+		log.Printf("logging in %s", u.Email)
+		back := url.Values{"code": {code}, "state": {q.Get("state")}}
+		http.Redirect(rw, r, redirectURI+"?"+back.Encode(), http.StatusFound)
+	})
+
+	http.HandleFunc("/token", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		code := r.PostFormValue("code")
+		mu.Lock()
+		g, ok := codes[code]
+		delete(codes, code)
+		mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		switch {
+		case !ok, g.redirectURI != r.PostFormValue("redirect_uri"):
+			rw.WriteHeader(http.StatusBadRequest)
+			writeJSON(rw, map[string]string{"error": "invalid_grant", "error_description": "unknown code"})
+			return
+		case base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge:
+			rw.WriteHeader(http.StatusBadRequest)
+			writeJSON(rw, map[string]string{"error": "invalid_grant", "error_description": "code verifier doesn't match"})
+			return
+		}
+
+		token := random()
+		mu.Lock()
+		tokens[token] = g.user
+		mu.Unlock()
+		writeJSON(rw, map[string]interface{}{"access_token": token, "token_type": "Bearer", "expires_in": 3600})
+	})
+
+	http.HandleFunc("/userinfo", func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		u, ok := tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		mu.Unlock()
+		if !ok {
+			http.Error(rw, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		writeJSON(rw, u)
+	})
+
+	log.Println("listening on", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func random() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(v)
+}
@@ -0,0 +1,168 @@
+// Command tracescan scans source code on the local disk and updates the
+// traceability project in the database with what it finds.
+//
+//	tracescan go -project <projectID> [module directory]
+//	tracescan trace -project <projectID> [module directory]
+//	tracescan git -project <projectID> [-full] [repository directory]
+//	tracescan drift -project <projectID> [-apply] [module directory]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"traceability/codescan"
+	"traceability/data"
+	"traceability/database"
+)
+
+// notificationTimeout is how long the command waits for the watchers to be notified before it exits
+const notificationTimeout = time.Minute
+
+var commands = map[string]func(args []string) (interface{}, error){
+	"go":    scanGo,
+	"trace": scanTrace,
+	"git":   scanGit,
+	"drift": scanDrift,
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: tracescan <command> -project <projectID> [flags] [directory]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  go     populate the development view from the packages of a Go module")
+	fmt.Fprintln(os.Stderr, "  trace  link components to the code annotated with // trace: <ref> comments")
+	fmt.Fprintln(os.Stderr, "  git    record commits whose messages reference components")
+	fmt.Fprintln(os.Stderr, "  drift  report differences between a Go module and the development view")
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("tracescan: ")
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	report, err := command(os.Args[2:])
+	// the watchers are notified in the background, also about the changes made before an error
+	if !data.FlushNotifications(notificationTimeout) {
+		log.Println("timed out notifying the watchers of the changes")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+}
+
+// commonFlags are the flags shared by all commands
+type commonFlags struct {
+	fs        *flag.FlagSet
+	dbURI     *string
+	dbName    *string
+	projectID *string
+}
+
+// newFlagSet returns the flag set of a command with the common flags defined
+func newFlagSet(name string) *commonFlags {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	return &commonFlags{
+		fs:        fs,
+		dbURI:     fs.String("db", "mongodb://localhost:27017", "MongoDB connection uri"),
+		dbName:    fs.String("dbname", "traceability", "database name"),
+		projectID: fs.String("project", "", "id of the project to update"),
+	}
+}
+
+// parse parses args, connects to the database and returns the directory argument
+func (c *commonFlags) parse(args []string) (string, error) {
+	c.fs.Parse(args)
+	if *c.projectID == "" {
+		return "", fmt.Errorf("-project is required")
+	}
+	if err := database.Connect(*c.dbURI, *c.dbName); err != nil {
+		return "", err
+	}
+	dir := c.fs.Arg(0)
+	if dir == "" {
+		dir = "."
+	}
+	return dir, nil
+}
+
+func scanGo(args []string) (interface{}, error) {
+	flags := newFlagSet("go")
+	dir, err := flags.parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs, err := codescan.ScanGoModule(codescan.FindModuleRoot(dir))
+	if err != nil {
+		return nil, err
+	}
+	return codescan.SyncDevelopmentView(*flags.projectID, pkgs)
+}
+
+func scanTrace(args []string) (interface{}, error) {
+	flags := newFlagSet("trace")
+	dir, err := flags.parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations, err := codescan.ScanTraceAnnotations(codescan.FindModuleRoot(dir))
+	if err != nil {
+		return nil, err
+	}
+	return codescan.SyncTraceAnnotations(*flags.projectID, annotations)
+}
+
+func scanGit(args []string) (interface{}, error) {
+	flags := newFlagSet("git")
+	full := flags.fs.Bool("full", false, "scan the whole history instead of continuing after the last scan")
+	dir, err := flags.parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// the repository is named by its directory
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return codescan.SyncGitHistory(*flags.projectID, filepath.Dir(dir), filepath.Base(dir), *full)
+}
+
+func scanDrift(args []string) (interface{}, error) {
+	flags := newFlagSet("drift")
+	apply := flags.fs.Bool("apply", false, "apply all changes of the report right away")
+	dir, err := flags.parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	root := codescan.FindModuleRoot(dir)
+	pkgs, err := codescan.ScanGoModule(root)
+	if err != nil {
+		return nil, err
+	}
+	report, err := codescan.DetectDrift(*flags.projectID, codescan.ModulePath(root), pkgs)
+	if err != nil || !*apply {
+		return report, err
+	}
+	return codescan.ApplyDrift(*flags.projectID, report.ID, nil)
+}
@@ -0,0 +1,52 @@
+// Command webhookrecv is a local webhook receiver for trying out the webhooks
+// of a project. It checks the signature of every delivery, prints it and
+// answers with the given status code, e.g. 500 to watch the retries. The
+// server only posts to it with WEBHOOK_ALLOWED_NETWORKS=127.0.0.1/32 set.
+//
+//	webhookrecv -secret <secret> [-addr :9090] [-status 204]
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"traceability/webhook"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("webhookrecv: ")
+
+	addr := flag.String("addr", ":9090", "address to listen on")
+	secret := flag.String("secret", "", "secret of the webhook")
+	status := flag.Int("status", http.StatusNoContent, "status code to answer with")
+	flag.Parse()
+
+	http.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !webhook.Verify(*secret, body, r.Header.Get(webhook.SignatureHeader)) {
+			log.Printf("delivery %s: invalid signature", r.Header.Get(webhook.DeliveryHeader))
+			http.Error(rw, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		var indented bytes.Buffer
+		if json.Indent(&indented, body, "", "  ") != nil {
+			indented.Write(body)
+		}
+		log.Printf("delivery %s: %s\n%s", r.Header.Get(webhook.DeliveryHeader), r.Header.Get(webhook.EventHeader), indented.String())
+		rw.WriteHeader(*status)
+	})
+
+	fmt.Println("receiving webhooks at", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,160 @@
+package codescan
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Annotation is a "// trace: <ref>" comment found in the source code
+type Annotation struct {
+	// Ref is the referenced component id or external id, e.g. a story key
+	Ref string `json:"ref"`
+
+	// File is the path of the file relative to the module root
+	File string `json:"file"`
+
+	// Line is the line of the comment
+	Line int `json:"line"`
+
+	// Function is the enclosing function, methods as Type.Method
+	Function string `json:"function,omitempty"`
+
+	// Package is the import path of the package of the file
+	Package string `json:"package"`
+}
+
+// tracePattern matches the text of a trace comment, several refs may be
+// separated by commas or spaces: "trace: US-12, US-13"
+var tracePattern = regexp.MustCompile(`^\s*trace:\s*(.+?)\s*$`)
+
+// refPattern matches a ref, an id or key with at least one digit like
+// "US-12" or a component id, so that words of a sentence aren't taken for refs
+var refPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/#-]*$`)
+
+// ParseTraceComment returns the refs of a comment text without the comment
+// markers. The refs end at the first word which isn't a ref, the rest of the
+// comment may explain them: "trace: US-12 stores the order".
+func ParseTraceComment(text string) []string {
+	m := tracePattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	var refs []string
+	for _, field := range strings.FieldsFunc(m[1], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}) {
+		if !refPattern.MatchString(field) || !strings.ContainsAny(field, "0123456789") {
+			break
+		}
+		refs = append(refs, field)
+	}
+	return refs
+}
+
+// commentLines returns the lines of a comment without the comment markers,
+// the leading "*" of the lines of block comments is removed too
+func commentLines(text string) []string {
+	if strings.HasPrefix(text, "//") {
+		return []string{strings.TrimPrefix(text, "//")}
+	}
+	lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimSpace(line), "*")
+	}
+	return lines
+}
+
+// ScanTraceAnnotations returns all trace annotations of the Go files below
+// root, test files included, sorted by file and line. Block comments may have
+// a trace on every line. Files which fail to parse are logged and skipped.
+func ScanTraceAnnotations(root string) ([]Annotation, error) {
+	modulePath := ModulePath(root)
+	fset := token.NewFileSet()
+	var result []Annotation
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != root && skipDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			log.Printf("[ERROR] skipping %s: %v", path, err)
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		pkg := importPath(modulePath, filepath.ToSlash(filepath.Dir(rel)))
+
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				line := fset.Position(c.Pos()).Line
+				for i, text := range commentLines(c.Text) {
+					for _, ref := range ParseTraceComment(text) {
+						result = append(result, Annotation{
+							Ref:      ref,
+							File:     rel,
+							Line:     line + i,
+							Function: enclosingFunction(file, c.Pos()),
+							Package:  pkg,
+						})
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].File != result[j].File {
+			return result[i].File < result[j].File
+		}
+		return result[i].Line < result[j].Line
+	})
+	return result, nil
+}
+
+// enclosingFunction returns the name of the function whose body or doc
+// comment contains pos, or an empty string for package level comments
+func enclosingFunction(file *ast.File, pos token.Pos) string {
+	for _, decl := range file.Decls {
+		d, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		start := d.Pos()
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+		if pos < start || pos > d.End() {
+			continue
+		}
+		if recv := receiverType(d.Recv); recv != "" {
+			return recv + "." + d.Name.Name
+		}
+		return d.Name.Name
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package codescan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTraceComment(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{" trace: US-12", []string{"US-12"}},
+		{"trace:US-12,US-13", []string{"US-12", "US-13"}},
+		{" trace: US-12, US-13\tUS-14 ", []string{"US-12", "US-13", "US-14"}},
+		{" trace: US-12 stores the order", []string{"US-12"}},
+		{" trace: stores US-12", nil},
+		{" trace: 5f2b6c1e-8f0a-4c9b-9d3e-2a7c1b4d6e8f", []string{"5f2b6c1e-8f0a-4c9b-9d3e-2a7c1b4d6e8f"}},
+		{" trace: gherkin:features/pay.feature#2", []string{"gherkin:features/pay.feature#2"}},
+		{" trace: US-12 (see US-13)", []string{"US-12"}},
+		{" trace: -12", nil},
+		{" trace:", nil},
+		{" see trace: US-12", nil},
+		{" Trace: US-12", nil},
+		{" the code below", nil},
+	}
+	for _, tt := range tests {
+		if got := ParseTraceComment(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTraceComment(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCommentLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"// trace: US-1", []string{" trace: US-1"}},
+		{"/* trace: US-1 */", []string{"trace: US-1"}},
+		{"/*\n * trace: US-1\n   trace: US-2\n */", []string{"", " trace: US-1", "trace: US-2", ""}},
+	}
+	for _, tt := range tests {
+		if got := commentLines(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("commentLines(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestScanTraceAnnotations(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/shop\n",
+		"main.go": `package main
+
+func main() {
+	// trace: US-7
+}
+`,
+		"order/order.go": `package order
+
+// trace: US-1
+type Order struct{}
+
+// Place stores the order
+// trace: US-2, US-3 stores it
+func (o *Order) Place() {
+	/*
+	 * trace: US-4
+	 * not a trace: US-5
+	 */
+}
+`,
+		"order/broken.go":      "package order\n// trace: US-8\nfunc {\n",
+		"vendor/lib/lib.go":    "package lib\n// trace: US-9\n",
+		"testdata/data.go":     "package data\n// trace: US-10\n",
+		".hidden/hidden.go":    "package hidden\n// trace: US-11\n",
+		"order/order_test.go":  "package order\n\n// trace: US-12\nfunc TestPlace() {}\n",
+		"order/notes/notes.md": "trace: US-13\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	annotations, err := ScanTraceAnnotations(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Annotation{
+		{Ref: "US-7", File: "main.go", Line: 4, Function: "main", Package: "example.com/shop"},
+		{Ref: "US-1", File: "order/order.go", Line: 3, Package: "example.com/shop/order"},
+		{Ref: "US-2", File: "order/order.go", Line: 7, Function: "Order.Place", Package: "example.com/shop/order"},
+		{Ref: "US-3", File: "order/order.go", Line: 7, Function: "Order.Place", Package: "example.com/shop/order"},
+		{Ref: "US-4", File: "order/order.go", Line: 10, Function: "Order.Place", Package: "example.com/shop/order"},
+		{Ref: "US-12", File: "order/order_test.go", Line: 3, Function: "TestPlace", Package: "example.com/shop/order"},
+	}
+	if !reflect.DeepEqual(annotations, want) {
+		t.Errorf("annotations = %+v, want %+v", annotations, want)
+	}
+}
@@ -0,0 +1,103 @@
+package codescan
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxArchiveSize limits the unpacked size of uploaded source archives
+const MaxArchiveSize = 200 << 20
+
+// ErrArchiveTooLarge is returned when an archive unpacks to more than MaxArchiveSize bytes
+var ErrArchiveTooLarge = errors.New("archive is too large")
+
+// ErrInvalidArchivePath is returned for archive entries pointing outside of the target directory
+var ErrInvalidArchivePath = errors.New("archive entry has an invalid path")
+
+// ExtractZip unpacks the zip archive read from r into a new temporary
+// directory and returns its path, the caller has to remove it
+func ExtractZip(r io.Reader) (string, error) {
+	// zip needs random access, so the upload is spooled to disk first
+	tmp, err := ioutil.TempFile("", "traceability-upload-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, io.LimitReader(r, MaxArchiveSize+1))
+	if err != nil {
+		return "", err
+	}
+	if size > MaxArchiveSize {
+		return "", ErrArchiveTooLarge
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := ioutil.TempDir("", "traceability-scan-")
+	if err != nil {
+		return "", err
+	}
+	if err := extractFiles(zr, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+func extractFiles(zr *zip.Reader, dir string) error {
+	var total uint64
+	for _, f := range zr.File {
+		target := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return ErrInvalidArchivePath
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			// symlinks could point outside of the directory
+			continue
+		}
+		total += f.UncompressedSize64
+		if total > MaxArchiveSize {
+			return ErrArchiveTooLarge
+		}
+		if err := extractFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, io.LimitReader(rc, int64(f.UncompressedSize64)))
+	return err
+}
@@ -0,0 +1,361 @@
+package codescan
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	data "traceability/data"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrDriftApplied is returned when all changes of a drift report were applied already
+var ErrDriftApplied = errors.New("drift report was applied already")
+
+// packagePath returns the import path a development component stands for.
+// Scanned components carry it in their external id, components typed in by
+// hand are matched by a description equal to an import path of the module.
+func packagePath(c data.ArchViewComponent, modulePath string) (string, bool) {
+	if strings.HasPrefix(c.ExternalID, goExternalIDPrefix) {
+		return strings.TrimPrefix(c.ExternalID, goExternalIDPrefix), true
+	}
+	description := strings.TrimSpace(c.Desctription)
+	if modulePath != "" && (description == modulePath || strings.HasPrefix(description, modulePath+"/")) {
+		return description, true
+	}
+	return "", false
+}
+
+// diffNames returns the names only in code and the names only in stored
+func diffNames(code []string, stored []string) (added []string, removed []string) {
+	inCode := map[string]bool{}
+	for _, n := range code {
+		inCode[n] = true
+	}
+	inStored := map[string]bool{}
+	for _, n := range stored {
+		inStored[n] = true
+		if !inCode[n] {
+			removed = append(removed, n)
+		}
+	}
+	for _, n := range code {
+		if !inStored[n] {
+			added = append(added, n)
+		}
+	}
+	return added, removed
+}
+
+// DetectDrift compares the scanned packages of the module with the
+// development view of the project and stores the differences as a pending
+// drift report, nothing in the view is changed until the report is applied.
+func DetectDrift(projectID string, modulePath string, pkgs []Package) (data.DriftReport, error) {
+	report := data.DriftReport{ProjectID: projectID}
+
+	project, err := data.FindProjectByID(projectID)
+	if err != nil {
+		return report, err
+	}
+	stored, err := data.FindArchViewComponentsByViewID(project.DevelopmentViewID)
+	if err != nil {
+		return report, err
+	}
+
+	byPath := map[string]data.ArchViewComponent{}
+	for _, c := range stored {
+		if c.Stale {
+			continue
+		}
+		if path, ok := packagePath(c, modulePath); ok {
+			byPath[path] = c
+		}
+	}
+
+	add := func(change data.DriftChange) {
+		change.ID = strconv.Itoa(len(report.Changes) + 1)
+		report.Changes = append(report.Changes, change)
+	}
+	members := []struct {
+		added, removed data.DriftChangeKind
+		lists          func(p Package, c data.ArchViewComponent) (code []string, stored []string)
+	}{
+		{data.DriftFunctionAdded, data.DriftFunctionRemoved, func(p Package, c data.ArchViewComponent) ([]string, []string) {
+			return p.Functions, c.FunctionList
+		}},
+		{data.DriftVariableAdded, data.DriftVariableRemoved, func(p Package, c data.ArchViewComponent) ([]string, []string) {
+			return p.Variables, c.VarList
+		}},
+		{data.DriftTypeAdded, data.DriftTypeRemoved, func(p Package, c data.ArchViewComponent) ([]string, []string) {
+			return p.Types, c.TypeList
+		}},
+	}
+
+	scanned := map[string]bool{}
+	for _, p := range pkgs {
+		scanned[p.ImportPath] = true
+		c, ok := byPath[p.ImportPath]
+		if !ok {
+			add(data.DriftChange{
+				Kind:        data.DriftPackageAdded,
+				Package:     p.ImportPath,
+				PackageName: p.Name,
+				Dir:         p.Dir,
+				Functions:   p.Functions,
+				Variables:   p.Variables,
+				Types:       p.Types,
+			})
+			continue
+		}
+		for _, m := range members {
+			added, removed := diffNames(m.lists(p, c))
+			for _, name := range added {
+				add(data.DriftChange{Kind: m.added, Package: p.ImportPath, ComponentID: c.ID, Name: name})
+			}
+			for _, name := range removed {
+				add(data.DriftChange{Kind: m.removed, Package: p.ImportPath, ComponentID: c.ID, Name: name})
+			}
+		}
+	}
+
+	for path, c := range byPath {
+		if !scanned[path] {
+			add(data.DriftChange{Kind: data.DriftPackageRemoved, Package: path, ComponentID: c.ID})
+		}
+	}
+
+	for _, p := range pkgs {
+		for _, imp := range p.Imports {
+			if !scanned[imp] {
+				continue
+			}
+			from, fromOK := byPath[p.ImportPath]
+			to, toOK := byPath[imp]
+			if fromOK && toOK {
+				linked, err := data.HasLink(from.ID, to.ID)
+				if err != nil {
+					return report, err
+				}
+				if linked {
+					continue
+				}
+			}
+			add(data.DriftChange{
+				Kind:              data.DriftDependencyMissing,
+				Package:           p.ImportPath,
+				ComponentID:       from.ID,
+				Target:            imp,
+				TargetComponentID: to.ID,
+			})
+		}
+	}
+
+	return data.AddDriftReport(report)
+}
+
+// ApplyDrift applies the changes of the drift report with the given ids, or
+// all pending changes if ids is empty, to the development view. Changes which
+// depend on an added package that is not applied are left pending. The
+// report is stored with the applied changes even if applying fails, and
+// changes found in the view already aren't made twice, so a failed apply can
+// be repeated.
+func ApplyDrift(projectID string, reportID string, ids []string) (data.DriftReport, error) {
+	report, err := data.FindDriftReportByID(projectID, reportID)
+	if err != nil {
+		return report, err
+	}
+	if report.Status == data.DriftApplied {
+		return report, ErrDriftApplied
+	}
+	project, err := data.FindProjectByID(projectID)
+	if err != nil {
+		return report, err
+	}
+
+	selected := map[string]bool{}
+	for _, id := range ids {
+		selected[id] = true
+	}
+	var pending []*data.DriftChange
+	for i := range report.Changes {
+		c := &report.Changes[i]
+		if !c.Applied && (len(ids) == 0 || selected[c.ID]) {
+			pending = append(pending, c)
+		}
+	}
+
+	components := map[string]*data.ArchViewComponent{}
+	// fail stores what was applied before the error, changes of components
+	// which can't be stored stay pending
+	fail := func(err error) (data.DriftReport, error) {
+		for id, target := range components {
+			if data.UpdateArchViewComponent(*target) == nil {
+				continue
+			}
+			for _, c := range pending {
+				if c.ComponentID == id && c.Kind != data.DriftPackageAdded && c.Kind != data.DriftDependencyMissing {
+					c.Applied = false
+				}
+			}
+		}
+		if uerr := finishDrift(&report); uerr != nil {
+			return report, uerr
+		}
+		return report, err
+	}
+
+	// added packages first, the other changes may refer to their components
+	added := map[string]string{}
+	for _, c := range pending {
+		if c.Kind != data.DriftPackageAdded {
+			continue
+		}
+		// a package added by an earlier, failed apply is taken as it is
+		component, err := data.FindArchViewComponentByExternalID(projectID, PackageExternalID(c.Package))
+		if err == mongo.ErrNoDocuments {
+			component, err = data.AddArchViewComponent(packageComponent(projectID, project.DevelopmentViewID, Package{
+				ImportPath: c.Package,
+				Name:       c.PackageName,
+				Dir:        c.Dir,
+				Functions:  c.Functions,
+				Variables:  c.Variables,
+				Types:      c.Types,
+			}))
+		}
+		if err != nil {
+			return fail(err)
+		}
+		c.ComponentID = component.ID
+		c.Applied = true
+		added[c.Package] = component.ID
+	}
+
+	component := func(id string) (*data.ArchViewComponent, error) {
+		if c, ok := components[id]; ok {
+			return c, nil
+		}
+		c, err := data.FindArchViewComponentByID(id)
+		if err != nil {
+			return nil, err
+		}
+		components[id] = &c
+		return &c, nil
+	}
+
+	for _, c := range pending {
+		switch c.Kind {
+		case data.DriftFunctionAdded, data.DriftFunctionRemoved,
+			data.DriftVariableAdded, data.DriftVariableRemoved,
+			data.DriftTypeAdded, data.DriftTypeRemoved,
+			data.DriftPackageRemoved:
+			target, err := component(c.ComponentID)
+			if err == mongo.ErrNoDocuments {
+				// the component was deleted since the scan
+				continue
+			}
+			if err != nil {
+				return fail(err)
+			}
+			applyMemberChange(target, c)
+			c.Applied = true
+		case data.DriftDependencyMissing:
+			from, to := c.ComponentID, c.TargetComponentID
+			if from == "" {
+				from = added[c.Package]
+			}
+			if to == "" {
+				to = added[c.Target]
+			}
+			if from == "" || to == "" {
+				continue
+			}
+			externalID := importExternalID(c.Package, c.Target)
+			_, err := data.FindLinkByExternalID(projectID, externalID)
+			if err == mongo.ErrNoDocuments {
+				_, err = data.AddLink(data.Link{
+					From:       from,
+					To:         to,
+					Kind:       importsLinkKind,
+					ProjectID:  projectID,
+					ExternalID: externalID,
+				})
+			}
+			if err != nil {
+				return fail(err)
+			}
+			c.ComponentID, c.TargetComponentID = from, to
+			c.Applied = true
+		}
+	}
+
+	for id, c := range components {
+		if err := data.UpdateArchViewComponent(*c); err != nil {
+			return fail(err)
+		}
+		delete(components, id)
+	}
+
+	return report, finishDrift(&report)
+}
+
+// finishDrift sets the status of the report from its applied changes and stores it
+func finishDrift(report *data.DriftReport) error {
+	report.Status = data.DriftApplied
+	applied := false
+	for _, c := range report.Changes {
+		if c.Applied {
+			applied = true
+		} else {
+			report.Status = data.DriftPartial
+		}
+	}
+	if !applied {
+		report.Status = data.DriftPending
+	} else {
+		now := time.Now()
+		report.AppliedAt = &now
+	}
+	return data.UpdateDriftReport(*report)
+}
+
+// applyMemberChange adds or removes the name of c to the lists of the component
+func applyMemberChange(target *data.ArchViewComponent, c *data.DriftChange) {
+	switch c.Kind {
+	case data.DriftFunctionAdded:
+		target.FunctionList = addName(target.FunctionList, c.Name)
+	case data.DriftFunctionRemoved:
+		target.FunctionList = removeName(target.FunctionList, c.Name)
+	case data.DriftVariableAdded:
+		target.VarList = addName(target.VarList, c.Name)
+	case data.DriftVariableRemoved:
+		target.VarList = removeName(target.VarList, c.Name)
+	case data.DriftTypeAdded:
+		target.TypeList = addName(target.TypeList, c.Name)
+	case data.DriftTypeRemoved:
+		target.TypeList = removeName(target.TypeList, c.Name)
+	case data.DriftPackageRemoved:
+		target.Stale = true
+	}
+}
+
+// addName appends the name to the list unless it is in it already
+func addName(l []string, name string) []string {
+	for _, n := range l {
+		if n == name {
+			return l
+		}
+	}
+	return append(l, name)
+}
+
+func removeName(l []string, name string) []string {
+	result := l[:0]
+	for _, n := range l {
+		if n != name {
+			result = append(result, n)
+		}
+	}
+	return result
+}
@@ -0,0 +1,254 @@
+package codescan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	data "traceability/data"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RepositoryRoot is the directory the repositories scanned through the api
+// have to be in, main reads it from GIT_REPOSITORY_ROOT. The api scans no
+// repositories if it is empty.
+var RepositoryRoot string
+
+// ErrRepositoryOutsideRoot is returned for repositories which are not in the root directory
+var ErrRepositoryOutsideRoot = errors.New("repository is not in the repository root")
+
+// GitCommit is a commit read from the log of a repository
+type GitCommit struct {
+	Hash    string
+	Author  string
+	Email   string
+	Date    time.Time
+	Message string
+	Files   []string
+}
+
+// separators of the git log format, they never appear in commit messages
+const (
+	gitRecordSeparator = "\x1e"
+	gitFieldSeparator  = "\x1f"
+)
+
+var gitLogFormat = "--format=" + gitRecordSeparator + strings.Join([]string{"%H", "%an", "%ae", "%aI", "%B", ""}, gitFieldSeparator)
+
+// git runs a git command in the repository and returns its standard output
+func git(repo string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return out, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// isAncestor reports whether commit is still part of the history of HEAD,
+// it is not after a force push rewrote the history
+func isAncestor(repo string, commit string) bool {
+	cmd := exec.Command("git", "-C", repo, "merge-base", "--is-ancestor", commit, "HEAD")
+	return cmd.Run() == nil
+}
+
+// ReadGitLog returns the commits reachable from HEAD of the repository at
+// path, oldest first. If since is not empty only the commits after it are read.
+func ReadGitLog(repo string, since string) ([]GitCommit, error) {
+	revision := "HEAD"
+	if since != "" {
+		revision = since + "..HEAD"
+	}
+	out, err := git(repo, "log", "--reverse", "--no-color", "--name-only", gitLogFormat, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []GitCommit
+	for _, record := range strings.Split(string(out), gitRecordSeparator) {
+		fields := strings.Split(record, gitFieldSeparator)
+		if len(fields) < 6 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, err
+		}
+		c := GitCommit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    date,
+			Message: strings.TrimSpace(fields[4]),
+		}
+		for _, file := range strings.Split(fields[5], "\n") {
+			if file = strings.TrimSpace(file); file != "" {
+				c.Files = append(c.Files, file)
+			}
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// containsRef reports whether ref appears in message as a whole word, so
+// that the story key US-1 is not found in US-12
+func containsRef(message string, ref string) bool {
+	isWord := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+	}
+	for offset := 0; ; {
+		i := strings.Index(message[offset:], ref)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(ref)
+		before, after := ' ', ' '
+		if start > 0 {
+			before = rune(message[start-1])
+		}
+		if end < len(message) {
+			after = rune(message[end])
+		}
+		if !isWord(before) && !isWord(after) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// GitReport lists what a scan of a repository found
+// swagger:model
+type GitReport struct {
+	// path of the scanned repository relative to the repository root
+	Repository string `json:"repository"`
+
+	// commit the scan started after, empty for a full scan
+	Since string `json:"since,omitempty"`
+
+	// newest commit scanned, the next scan starts after it
+	LastCommit string `json:"lastCommit"`
+
+	// number of commits read
+	Scanned int `json:"scanned"`
+
+	// commits referencing components
+	Matched []GitMatch `json:"matched"`
+}
+
+// GitMatch is a commit referencing components
+type GitMatch struct {
+	Hash         string   `json:"hash"`
+	Subject      string   `json:"subject"`
+	ComponentIDs []string `json:"componentIDs"`
+}
+
+// ResolveRepository returns the absolute path of the repository at repo, a
+// path relative to root, and its path relative to root with symbolic links
+// resolved. Repositories outside of root are rejected with ErrRepositoryOutsideRoot.
+func ResolveRepository(root string, repo string) (string, string, error) {
+	if root == "" {
+		return "", "", ErrRepositoryOutsideRoot
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", "", err
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(root, repo))
+	if err != nil {
+		return "", "", ErrRepositoryOutsideRoot
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", ErrRepositoryOutsideRoot
+	}
+	return path, filepath.ToSlash(rel), nil
+}
+
+// SyncGitHistory reads the commits of the local repository at repo, a path
+// relative to root, and stores those whose message references a component id
+// or external id of the project. Scans continue after the last commit of the
+// previous scan unless full is set or the history was rewritten since. The
+// report and the commits name the repository by its path relative to root.
+func SyncGitHistory(projectID string, root string, repo string, full bool) (*GitReport, error) {
+	path, repo, err := ResolveRepository(root, repo)
+	if err != nil {
+		return nil, err
+	}
+	report := &GitReport{Repository: repo, Matched: []GitMatch{}}
+
+	previous, err := data.FindGitScan(projectID, repo)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if !full && previous.LastCommit != "" && isAncestor(path, previous.LastCommit) {
+		report.Since = previous.LastCommit
+	}
+	report.LastCommit = report.Since
+
+	commits, err := ReadGitLog(path, report.Since)
+	if err != nil {
+		return nil, err
+	}
+
+	components, err := data.FindArchViewComponentsByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, gc := range commits {
+		report.Scanned++
+		report.LastCommit = gc.Hash
+
+		var ids []string
+		for _, c := range components {
+			if containsRef(gc.Message, c.ID) || (c.ExternalID != "" && containsRef(gc.Message, c.ExternalID)) {
+				ids = append(ids, c.ID)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		err := data.AddCommit(data.Commit{
+			Hash:         gc.Hash,
+			ProjectID:    projectID,
+			Repository:   repo,
+			Author:       gc.Author,
+			Email:        gc.Email,
+			Date:         gc.Date,
+			Message:      gc.Message,
+			Files:        gc.Files,
+			ComponentIDs: ids,
+		})
+		if err != nil {
+			return report, err
+		}
+		report.Matched = append(report.Matched, GitMatch{
+			Hash:         gc.Hash,
+			Subject:      strings.SplitN(gc.Message, "\n", 2)[0],
+			ComponentIDs: ids,
+		})
+	}
+
+	if report.LastCommit != "" {
+		err = data.UpdateGitScan(data.GitScan{
+			ProjectID:  projectID,
+			Repository: repo,
+			LastCommit: report.LastCommit,
+			ScannedAt:  time.Now(),
+		})
+	}
+	return report, err
+}
@@ -0,0 +1,245 @@
+// Package codescan reads source code and keeps the development view of a
+// project in sync with it
+package codescan
+
+import (
+	"bufio"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package is the exported API of one Go package of a scanned module
+type Package struct {
+	// ImportPath is the module path joined with the directory of the package
+	ImportPath string `json:"importPath"`
+
+	// Name is the package clause name
+	Name string `json:"name"`
+
+	// Dir is the directory of the package relative to the module root
+	Dir string `json:"dir"`
+
+	// Functions are exported functions and methods, methods as Type.Method
+	Functions []string `json:"functions"`
+
+	// Types are exported type names
+	Types []string `json:"types"`
+
+	// Variables are exported package level variables
+	Variables []string `json:"variables"`
+
+	// Imports are the import paths used by the files of the package
+	Imports []string `json:"imports"`
+}
+
+// skipDir reports directories the go tool ignores as well
+func skipDir(name string) bool {
+	return name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// ModulePath returns the module path declared in the go.mod file of root
+// or an empty string if there is none
+func ModulePath(root string) string {
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// FindModuleRoot returns the directory closest to dir which has a go.mod file,
+// uploaded archives often wrap the module in a directory of its own
+func FindModuleRoot(dir string) string {
+	root := ""
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() && path != dir && skipDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && info.Name() == "go.mod" {
+			candidate := filepath.Dir(path)
+			if root == "" || len(candidate) < len(root) {
+				root = candidate
+			}
+		}
+		return nil
+	})
+	if root == "" {
+		return dir
+	}
+	return root
+}
+
+// ScanGoModule parses all non test Go files below root and returns one
+// Package per directory, sorted by import path. Files which fail to parse
+// are logged and skipped.
+func ScanGoModule(root string) ([]Package, error) {
+	modulePath := ModulePath(root)
+	fset := token.NewFileSet()
+	byDir := map[string]*Package{}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != root && skipDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			// a broken file shouldn't hide the rest of the module
+			log.Printf("[ERROR] skipping %s: %v", path, err)
+			return nil
+		}
+
+		dir, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		dir = filepath.ToSlash(dir)
+		p, ok := byDir[dir]
+		if !ok {
+			p = &Package{
+				ImportPath: importPath(modulePath, dir),
+				Name:       file.Name.Name,
+				Dir:        dir,
+			}
+			byDir[dir] = p
+		}
+		addFile(p, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Package
+	for _, p := range byDir {
+		p.Functions = uniqueSorted(p.Functions)
+		p.Types = uniqueSorted(p.Types)
+		p.Variables = uniqueSorted(p.Variables)
+		p.Imports = uniqueSorted(p.Imports)
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ImportPath < result[j].ImportPath
+	})
+	return result, nil
+}
+
+func importPath(modulePath string, dir string) string {
+	switch {
+	case dir == ".":
+		if modulePath == "" {
+			return "."
+		}
+		return modulePath
+	case modulePath == "":
+		return dir
+	default:
+		return modulePath + "/" + dir
+	}
+}
+
+// addFile collects the exported declarations and imports of file into p
+func addFile(p *Package, file *ast.File) {
+	for _, imp := range file.Imports {
+		p.Imports = append(p.Imports, strings.Trim(imp.Path.Value, `"`))
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv == nil {
+				p.Functions = append(p.Functions, d.Name.Name)
+				continue
+			}
+			if recv := receiverType(d.Recv); ast.IsExported(recv) {
+				p.Functions = append(p.Functions, recv+"."+d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						p.Types = append(p.Types, s.Name.Name)
+					}
+				case *ast.ValueSpec:
+					if d.Tok != token.VAR {
+						continue
+					}
+					for _, name := range s.Names {
+						if name.IsExported() {
+							p.Variables = append(p.Variables, name.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// receiverType returns the type name of a method receiver, without pointer and type parameters
+func receiverType(recv *ast.FieldList) string {
+	if recv == nil || len(recv.List) == 0 {
+		return ""
+	}
+	expr := recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func uniqueSorted(l []string) []string {
+	sort.Strings(l)
+	result := l[:0]
+	for _, s := range l {
+		if len(result) == 0 || s != result[len(result)-1] {
+			result = append(result, s)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
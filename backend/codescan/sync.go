@@ -0,0 +1,332 @@
+package codescan
+
+import (
+	"reflect"
+	"strings"
+
+	data "traceability/data"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// goExternalIDPrefix marks components and links created from Go packages
+const goExternalIDPrefix = "go:"
+
+// importsLinkKind is the link kind from a package to a package it imports
+const importsLinkKind = "imports"
+
+// SyncReport lists what a scan changed in the development view
+// swagger:model
+type SyncReport struct {
+	// ids of the components created for new packages
+	Created []string `json:"created"`
+
+	// ids of the components updated for known packages
+	Updated []string `json:"updated"`
+
+	// ids of the components whose package no longer exists
+	Stale []string `json:"stale"`
+
+	// ids of the links created for new imports between packages
+	LinksCreated []string `json:"linksCreated"`
+
+	// imports which could not be linked as a package component was deleted meanwhile
+	LinksSkipped []string `json:"linksSkipped"`
+}
+
+// PackageExternalID is the external id of the component of a Go package
+func PackageExternalID(importPath string) string {
+	return goExternalIDPrefix + importPath
+}
+
+// importExternalID is the external id of the link between two Go packages
+func importExternalID(from string, to string) string {
+	return goExternalIDPrefix + from + "->" + to
+}
+
+// packageComponent returns the development component describing p
+func packageComponent(projectID string, viewID string, p Package) data.ArchViewComponent {
+	return data.ArchViewComponent{
+		Kind:         data.Development,
+		Desctription: p.ImportPath,
+		ViewID:       viewID,
+		ProjectID:    projectID,
+		Level:        packageLevel(p.Dir),
+		FunctionList: p.Functions,
+		VarList:      p.Variables,
+		TypeList:     p.Types,
+		ExternalID:   PackageExternalID(p.ImportPath),
+		Metadata: map[string]string{
+			"package": p.Name,
+			"dir":     p.Dir,
+		},
+	}
+}
+
+// packageLevel draws the module root at level 0 and every directory one level deeper
+func packageLevel(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// SyncDevelopmentView creates or updates one development component per
+// package and links packages to the packages of the module they import.
+// Components of packages which were not scanned again are marked stale.
+func SyncDevelopmentView(projectID string, pkgs []Package) (*SyncReport, error) {
+	project, err := data.FindProjectByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{
+		Created:      []string{},
+		Updated:      []string{},
+		Stale:        []string{},
+		LinksCreated: []string{},
+		LinksSkipped: []string{},
+	}
+	componentIDs := map[string]string{}
+
+	for _, p := range pkgs {
+		c := packageComponent(projectID, project.DevelopmentViewID, p)
+		existing, err := data.FindArchViewComponentByExternalID(projectID, c.ExternalID)
+		switch err {
+		case nil:
+			existing.Desctription = c.Desctription
+			existing.FunctionList = c.FunctionList
+			existing.VarList = c.VarList
+			existing.TypeList = c.TypeList
+			existing.Metadata = c.Metadata
+			existing.Stale = false
+			if err := data.UpdateArchViewComponent(existing); err != nil {
+				return report, err
+			}
+			report.Updated = append(report.Updated, existing.ID)
+			componentIDs[p.ImportPath] = existing.ID
+		case mongo.ErrNoDocuments:
+			created, err := data.AddArchViewComponent(c)
+			if err != nil {
+				return report, err
+			}
+			report.Created = append(report.Created, created.ID)
+			componentIDs[p.ImportPath] = created.ID
+		default:
+			return report, err
+		}
+	}
+
+	for _, p := range pkgs {
+		for _, imp := range p.Imports {
+			to, ok := componentIDs[imp]
+			if !ok {
+				// standard library and third party packages have no component
+				continue
+			}
+			externalID := importExternalID(p.ImportPath, imp)
+			_, err := data.FindLinkByExternalID(projectID, externalID)
+			if err == nil {
+				continue
+			}
+			if err != mongo.ErrNoDocuments {
+				return report, err
+			}
+			l, err := data.AddLink(data.Link{
+				From:       componentIDs[p.ImportPath],
+				To:         to,
+				Kind:       importsLinkKind,
+				ProjectID:  projectID,
+				ExternalID: externalID,
+			})
+			if err == data.ErrLinkComponent {
+				report.LinksSkipped = append(report.LinksSkipped, externalID)
+				continue
+			}
+			if err != nil {
+				return report, err
+			}
+			report.LinksCreated = append(report.LinksCreated, l.ID)
+		}
+	}
+
+	previous, err := data.FindArchViewComponentsByExternalIDPrefix(projectID, goExternalIDPrefix)
+	if err != nil {
+		return report, err
+	}
+	for _, c := range previous {
+		if c.Stale || componentIDs[strings.TrimPrefix(c.ExternalID, goExternalIDPrefix)] != "" {
+			continue
+		}
+		c.Stale = true
+		if err := data.UpdateArchViewComponent(c); err != nil {
+			return report, err
+		}
+		report.Stale = append(report.Stale, c.ID)
+	}
+
+	return report, nil
+}
+
+// implementedByLinkKind is the link kind from a traced component to the package implementing it
+const implementedByLinkKind = "implemented-by"
+
+// traceExternalIDPrefix marks the implemented-by links created from trace annotations
+const traceExternalIDPrefix = "trace:"
+
+// TraceReport lists what a trace annotation scan changed in the project
+// swagger:model
+type TraceReport struct {
+	// ids of the components whose source locations were replaced
+	Traced []string `json:"traced"`
+
+	// ids of the components which are no longer annotated anywhere
+	Cleared []string `json:"cleared"`
+
+	// annotations whose ref matches no component of the project
+	Unresolved []Annotation `json:"unresolved"`
+
+	// ids of the implemented-by links created
+	LinksCreated []string `json:"linksCreated"`
+
+	// implemented-by links which could not be created as a component was deleted meanwhile
+	LinksSkipped []string `json:"linksSkipped"`
+
+	// ids of the implemented-by links removed as their annotation is gone
+	LinksRemoved []string `json:"linksRemoved"`
+}
+
+// SyncTraceAnnotations stores the annotations as source locations on the
+// referenced components and links them to the development components of the
+// annotated packages. A scan replaces the source locations and the
+// implemented-by links of the previous one.
+func SyncTraceAnnotations(projectID string, annotations []Annotation) (*TraceReport, error) {
+	report := &TraceReport{
+		Traced:       []string{},
+		Cleared:      []string{},
+		Unresolved:   []Annotation{},
+		LinksCreated: []string{},
+		LinksSkipped: []string{},
+		LinksRemoved: []string{},
+	}
+
+	components := map[string]*data.ArchViewComponent{}
+	// source locations of the components before the scan
+	stored := map[string][]data.SourceLocation{}
+	// refs resolve to the same component whether they use its id or external id
+	resolved := map[string]string{}
+	var order []string
+	packages := map[string]map[string]bool{}
+	// external ids of the implemented-by links of this scan
+	traced := map[string]bool{}
+
+	for _, a := range annotations {
+		id, ok := resolved[a.Ref]
+		if !ok {
+			c, err := data.FindArchViewComponentByRef(projectID, a.Ref)
+			switch err {
+			case nil:
+				id = c.ID
+				if _, seen := components[id]; !seen {
+					stored[id] = c.SourceLocations
+					c.SourceLocations = nil
+					components[id] = &c
+					order = append(order, id)
+					packages[id] = map[string]bool{}
+				}
+			case mongo.ErrNoDocuments:
+			default:
+				return report, err
+			}
+			resolved[a.Ref] = id
+		}
+		if id == "" {
+			report.Unresolved = append(report.Unresolved, a)
+			continue
+		}
+		c := components[id]
+		c.SourceLocations = append(c.SourceLocations, data.SourceLocation{
+			File:     a.File,
+			Line:     a.Line,
+			Function: a.Function,
+			Package:  a.Package,
+		})
+		packages[id][a.Package] = true
+	}
+
+	for _, id := range order {
+		// components annotated like before are neither written nor reported
+		if !reflect.DeepEqual(stored[id], components[id].SourceLocations) {
+			if err := data.UpdateArchViewComponent(*components[id]); err != nil {
+				return report, err
+			}
+			report.Traced = append(report.Traced, id)
+		}
+
+		for pkg := range packages[id] {
+			dev, err := data.FindArchViewComponentByExternalID(projectID, PackageExternalID(pkg))
+			if err == mongo.ErrNoDocuments || (err == nil && dev.ID == id) {
+				// the package was not scanned into the development view
+				continue
+			}
+			if err != nil {
+				return report, err
+			}
+			externalID := traceExternalIDPrefix + id + "->" + PackageExternalID(pkg)
+			traced[externalID] = true
+			_, err = data.FindLinkByExternalID(projectID, externalID)
+			if err == nil {
+				continue
+			}
+			if err != mongo.ErrNoDocuments {
+				return report, err
+			}
+			l, err := data.AddLink(data.Link{
+				From:       id,
+				To:         dev.ID,
+				Kind:       implementedByLinkKind,
+				ProjectID:  projectID,
+				ExternalID: externalID,
+			})
+			if err == data.ErrLinkComponent {
+				report.LinksSkipped = append(report.LinksSkipped, externalID)
+				continue
+			}
+			if err != nil {
+				return report, err
+			}
+			report.LinksCreated = append(report.LinksCreated, l.ID)
+		}
+	}
+
+	previous, err := data.FindArchViewComponentsWithSourceLocations(projectID)
+	if err != nil {
+		return report, err
+	}
+	for _, c := range previous {
+		if _, ok := components[c.ID]; ok {
+			continue
+		}
+		c.SourceLocations = nil
+		if err := data.UpdateArchViewComponent(c); err != nil {
+			return report, err
+		}
+		report.Cleared = append(report.Cleared, c.ID)
+	}
+
+	links, err := data.FindProjectLinksWithExternalIDPrefix(projectID, traceExternalIDPrefix)
+	if err != nil {
+		return report, err
+	}
+	for _, l := range links {
+		if traced[l.ExternalID] {
+			continue
+		}
+		if _, err := data.DeleteLink(projectID, l.ID, ""); err != nil {
+			return report, err
+		}
+		report.LinksRemoved = append(report.LinksRemoved, l.ID)
+	}
+
+	return report, nil
+}
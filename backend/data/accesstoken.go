@@ -0,0 +1,256 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	db "traceability/database"
+
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AccessTokenPrefix starts every personal access token, it tells them apart from jwts
+const AccessTokenPrefix = "trc_"
+
+// accessTokenUseInterval is how often the last use of a token is stored
+const accessTokenUseInterval = time.Minute
+
+var (
+	// ErrUnknownAccessToken is returned when a personal access token is not found
+	ErrUnknownAccessToken = errors.New("access token not found")
+	// ErrAccessTokenEnded is returned when a revoked or expired token is used
+	ErrAccessTokenEnded = errors.New("access token was revoked or has expired")
+	// ErrExpiryInPast is returned when a token would be expired on creation
+	ErrExpiryInPast = errors.New("expiry must be in the future")
+)
+
+// PersonalAccessToken lets scripts and CI jobs act as their user with a limited scope
+// swagger:model
+type PersonalAccessToken struct {
+	// the id of the token
+	//
+	// required: false
+	ID string `json:"id"`
+
+	// id of the user the token acts as
+	//
+	// required: false
+	UserID string `json:"userID" bson:"userid"`
+
+	// name telling what the token is used for
+	//
+	// required: true
+	// max length: 50
+	Name string `json:"name" validate:"required,max=50"`
+
+	// the token, only returned when it is created
+	//
+	// required: false
+	Token string `json:"token,omitempty" bson:"-"`
+
+	// sha256 of the token, the token itself isn't stored
+	TokenHash string `json:"-" bson:"tokenhash"`
+
+	// beginning of the token to recognize it
+	//
+	// required: false
+	Prefix string `json:"prefix"`
+
+	// read-only tokens can only read projects
+	//
+	// required: false
+	ReadOnly bool `json:"readOnly" bson:"readonly"`
+
+	// ids of the projects the token is limited to, all projects of the user if
+	// empty. Limited tokens are rejected on routes outside of a project.
+	//
+	// required: false
+	ProjectIDs []string `json:"projectIDs" bson:"projectids"`
+
+	// time the token stops working, never if empty
+	//
+	// required: false
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" bson:"expiresat,omitempty"`
+
+	// time the token was created
+	//
+	// required: false
+	CreatedAt time.Time `json:"createdAt" bson:"createdat"`
+
+	// time the token was last used, to the minute
+	//
+	// required: false
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" bson:"lastusedat,omitempty"`
+
+	// time the token was revoked
+	//
+	// required: false
+	RevokedAt *time.Time `json:"revokedAt,omitempty" bson:"revokedat,omitempty"`
+}
+
+// KeyAccessToken is the context key of the personal access token of a request
+type KeyAccessToken struct{}
+
+// GetAccessTokenFromContext returns the personal access token the request is authenticated with
+func GetAccessTokenFromContext(ctx context.Context) (PersonalAccessToken, bool) {
+	t, ok := ctx.Value(KeyAccessToken{}).(PersonalAccessToken)
+	return t, ok
+}
+
+// Active reports whether the token is neither revoked nor expired
+func (t PersonalAccessToken) Active() bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || time.Now().Before(*t.ExpiresAt))
+}
+
+// AllowsProject reports whether the token may be used on the project
+func (t PersonalAccessToken) AllowsProject(projectID string) bool {
+	if len(t.ProjectIDs) == 0 {
+		return true
+	}
+	for _, id := range t.ProjectIDs {
+		if id == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+// Restrict returns the role of the user limited to what the token allows
+func (t PersonalAccessToken) Restrict(r Role) Role {
+	if !t.ReadOnly {
+		return r
+	}
+	return r.ReadOnly()
+}
+
+// IsAccessToken reports whether the bearer token is a personal access token
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// AddAccessToken creates a personal access token of the user, it may only be
+// limited to projects the user is a member of
+func AddAccessToken(t PersonalAccessToken) (PersonalAccessToken, error) {
+	if t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()) {
+		return t, ErrExpiryInPast
+	}
+	if t.ProjectIDs == nil {
+		t.ProjectIDs = []string{}
+	}
+	for _, projectID := range t.ProjectIDs {
+		project, err := FindProjectByID(projectID)
+		if err == mongo.ErrNoDocuments || (err == nil && !project.HasMember(t.UserID)) {
+			return t, ErrNotProjectMember
+		}
+		if err != nil {
+			return t, err
+		}
+	}
+
+	secret, err := newToken()
+	if err != nil {
+		return t, err
+	}
+	t.ID = guuid.New().String()
+	t.Token = AccessTokenPrefix + secret
+	t.TokenHash = hashToken(t.Token)
+	t.Prefix = t.Token[:len(AccessTokenPrefix)+8]
+	t.CreatedAt = time.Now()
+	t.LastUsedAt, t.RevokedAt = nil, nil
+
+	collection := db.DB.Collection(db.AccessTokenCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), t)
+	if err != nil {
+		return t, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return t, nil
+}
+
+// FindAccessTokensOfUser returns the personal access tokens of the user, newest first
+func FindAccessTokensOfUser(userID string) ([]PersonalAccessToken, error) {
+	opts := options.Find().SetSort(bson.M{"createdat": -1})
+	cur, err := db.DB.Collection(db.AccessTokenCollectionName).Find(context.TODO(), bson.M{"userid": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []PersonalAccessToken{}
+	for cur.Next(context.TODO()) {
+		var elem PersonalAccessToken
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
+func findAccessToken(filter bson.M) (PersonalAccessToken, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.AccessTokenCollectionName)
+
+	var result PersonalAccessToken
+	err := collection.FindOne(ctx, filter).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, ErrUnknownAccessToken
+	}
+	return result, err
+}
+
+// FindActiveAccessToken reports whether the personal access token of the user is neither revoked nor expired
+func FindActiveAccessToken(id string, userID string) bool {
+	t, err := findAccessToken(bson.M{"id": id, "userid": userID})
+	return err == nil && t.Active()
+}
+
+// UseAccessToken returns the active personal access token and records its use
+func UseAccessToken(token string) (PersonalAccessToken, error) {
+	t, err := findAccessToken(bson.M{"tokenhash": hashToken(token)})
+	if err != nil {
+		return t, err
+	}
+	if !t.Active() {
+		return t, ErrAccessTokenEnded
+	}
+
+	now := time.Now()
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= accessTokenUseInterval {
+		t.LastUsedAt = &now
+		collection := db.DB.Collection(db.AccessTokenCollectionName)
+		_, err = collection.UpdateOne(context.TODO(), bson.M{"id": t.ID}, bson.M{"$set": bson.M{"lastusedat": now}})
+	}
+	return t, err
+}
+
+// RevokeAccessToken stops the personal access token of the user from working
+func RevokeAccessToken(userID string, id string) (PersonalAccessToken, error) {
+	t, err := findAccessToken(bson.M{"userid": userID, "id": id})
+	if err != nil {
+		return t, err
+	}
+	if t.RevokedAt != nil {
+		return t, nil
+	}
+
+	now := time.Now()
+	t.RevokedAt = &now
+	collection := db.DB.Collection(db.AccessTokenCollectionName)
+	updateResult, err := collection.UpdateOne(context.TODO(), bson.M{"id": t.ID}, bson.M{"$set": bson.M{"revokedat": now}})
+	if err != nil {
+		return t, err
+	}
+	fmt.Println("Updated a single document:", updateResult)
+	return t, nil
+}
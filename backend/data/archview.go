@@ -2,10 +2,9 @@ package data
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
+	"regexp"
 	"time"
 
 	db "traceability/database"
@@ -29,6 +28,8 @@ const (
 	None ViewKind = "none"
 )
 
+// Further view kinds like "deployment" are defined per project, see ViewKindDefinition
+
 // ArchViewComponent is the component of a view
 // swagger:model
 type ArchViewComponent struct {
@@ -42,10 +43,11 @@ type ArchViewComponent struct {
 	// required: false
 	UserKind string `json:"userKind"`
 
-	// Kind, "userStory", "functional", "development"
+	// Kind, "userStory", "functional", "development" or a view kind defined
+	// in the project, taken from the view if empty
 	//
 	// required: false
-	Kind ViewKind `json:"kind" validate:"oneof=userStory functional development"`
+	Kind ViewKind `json:"kind" validate:"omitempty,viewkind"`
 
 	LinksList []string `json:"links,omitempty"`
 	// Description
@@ -68,28 +70,67 @@ type ArchViewComponent struct {
 	//VarList is used for development view to show variables of a component
 	VarList []string `json:"variables,omitempty" bson:"omitempty"`
 
+	//TypeList is used for development view to show types of a component
+	TypeList []string `json:"types,omitempty" bson:"typelist,omitempty"`
+
 	// Drawing level of the component
 	Level int `json:"level" bson:"level,omitmepty"`
+
+	// ExternalID is the identifier of the component in the tool it was imported from
+	//
+	// required: false
+	ExternalID string `json:"externalID,omitempty" bson:"externalid,omitempty"`
+
+	// Metadata keeps attributes of imported components which have no field of their own
+	//
+	// required: false
+	Metadata map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+
+	// ParentID is the id of the component this one details, e.g. the
+	// user story of an acceptance criterion
+	//
+	// required: false
+	ParentID string `json:"parentID,omitempty" bson:"parentid,omitempty"`
+
+	// Stale is set when the source the component was imported from no longer has it
+	//
+	// required: false
+	Stale bool `json:"stale,omitempty" bson:"stale,omitempty"`
+
+	// SourceLocations are the places in the code annotated with a trace to the component
+	//
+	// required: false
+	SourceLocations []SourceLocation `json:"sourceLocations,omitempty" bson:"sourcelocations,omitempty"`
+
+	// CustomAttributes are the values of the attributes the view kind defines, see AttributeSchema
+	//
+	// required: false
+	CustomAttributes map[string]interface{} `json:"customAttributes,omitempty" bson:"customattributes,omitempty"`
+
+	// Labels are the ids of the labels attached
+	//
+	// required: false
+	Labels []string `json:"labels,omitempty" bson:"labels,omitempty"`
+
+	// Actor is the id of the user making the change, it is not stored and
+	// only names the actor of the emitted event
+	Actor string `json:"-" bson:"-"`
 }
 
-// UnmarshalJSON parses from json
-func (ac *ArchViewComponent) UnmarshalJSON(data []byte) error {
-	// Define a secondary tycursive cape so that we don't end up with a rell to json.Unmarshal
-	type Aux ArchViewComponent
-	var a *Aux = (*Aux)(ac)
-	err := json.Unmarshal(data, &a)
-	if err != nil {
-		return err
-	}
+// SourceLocation is a place in the source code
+// swagger:model
+type SourceLocation struct {
+	// path of the file relative to the module root
+	File string `json:"file"`
 
-	// Validate the valid enum values
-	switch ac.Kind {
-	case UserStory, Functional, Development, None:
-		return nil
-	default:
-		ac.Kind = ""
-		return errors.New("invalid value for Key")
-	}
+	// line in the file
+	Line int `json:"line"`
+
+	// enclosing function, methods as Type.Method
+	Function string `json:"function,omitempty"`
+
+	// import path of the package of the file
+	Package string `json:"package,omitempty"`
 }
 
 // ArchView general purpose architecture view
@@ -111,10 +152,10 @@ type ArchView struct {
 	// required: true
 	ProjectID string `json:"projectID" bson:"projectid,omitempty"`
 
-	// Kind, "userStory", "functional", "development"
+	// Kind, "userStory", "functional", "development" or a view kind defined in the project
 	//
-	// required: false
-	Kind string `json:"kind" validate:"oneof=userStory functional development"`
+	// required: true
+	Kind string `json:"kind" validate:"required,viewkind"`
 
 	// description
 	//
@@ -130,10 +171,22 @@ type ArchView struct {
 	//
 	// required: false
 	UserKinds []string `json:"userKinds,omitempty" bson:"userkinds,omitempty"`
+
+	// Labels are the ids of the labels attached
+	//
+	// required: false
+	Labels []string `json:"labels,omitempty" bson:"labels,omitempty"`
+
+	// Actor is the id of the user making the change, it is not stored and
+	// only names the actor of the emitted event
+	Actor string `json:"-" bson:"-"`
 }
 
-// AddArchView adds a new project to the database
+// AddArchView adds a new project to the database, the kind of the view has to be defined in the project
 func AddArchView(v ArchView) (*ArchView, error) {
+	if _, err := FindViewKind(v.ProjectID, v.Kind); err != nil {
+		return &v, err
+	}
 	v.ID = guuid.New().String()
 
 	collection := db.DB.Collection(db.ArchViewCollectionName)
@@ -144,6 +197,7 @@ func AddArchView(v ArchView) (*ArchView, error) {
 		return &v, err
 	}
 	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	emit(Event{Type: EventViewCreated, ProjectID: v.ProjectID, TargetKind: "view", TargetID: v.ID, Actor: v.Actor, After: v})
 	return &v, nil
 }
 
@@ -191,8 +245,23 @@ func FindArchViewByID(id string) (ArchView, error) {
 	return resultArchView, err
 }
 
-// AddArchViewComponent adds component to the ArchView
-func AddArchViewComponent(c ArchViewComponent) {
+// FindProjectArchView returns the view of the project or mongo.ErrNoDocuments
+func FindProjectArchView(projectID string, id string) (ArchView, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+	collection := db.DB.Collection(db.ArchViewCollectionName)
+
+	var resultArchView ArchView
+	err := collection.FindOne(ctx, bson.M{"projectid": projectID, "id": id}).Decode(&resultArchView)
+	return resultArchView, err
+}
+
+// AddArchViewComponent adds component to the ArchView after checking it against the kind of the view
+func AddArchViewComponent(c ArchViewComponent) (ArchViewComponent, error) {
+	if err := CheckArchViewComponent(&c); err != nil {
+		return c, err
+	}
 	c.ID = guuid.New().String()
 	archViewID := c.ViewID
 	archViewCollection := db.DB.Collection(db.ArchViewCollectionName)
@@ -202,33 +271,47 @@ func AddArchViewComponent(c ArchViewComponent) {
 	update := bson.M{"$push": bson.M{"components": c.ID}}
 
 	updateResult, err := archViewCollection.UpdateOne(context.TODO(), query, update)
+	if err != nil {
+		return c, err
+	}
 	insertResult, err := componentCollection.InsertOne(context.TODO(), c)
-
 	if err != nil {
-		panic(err)
+		return c, err
 	}
 
 	fmt.Println("Upserted a single document:", updateResult, "\n Inserted a single document: ", insertResult)
+	emit(Event{Type: EventComponentCreated, ProjectID: c.ProjectID, TargetKind: "component", TargetID: c.ID, Related: []string{c.ViewID}, Actor: c.Actor, After: c})
+	return c, nil
 }
 
 // UpdateArchView replaces archview with new one
 func UpdateArchView(a ArchView) error {
+	before, _ := FindArchViewByID(a.ID)
 	archViewCollection := db.DB.Collection(db.ArchViewCollectionName)
 	query := bson.M{"id": a.ID}
 
 	replaceResult, err := archViewCollection.ReplaceOne(context.TODO(), query, a)
 	fmt.Println("Replaced a single document:", replaceResult)
-	return err
+	if err != nil {
+		return err
+	}
+	emit(Event{Type: EventViewUpdated, ProjectID: a.ProjectID, TargetKind: "view", TargetID: a.ID, Actor: a.Actor, Before: before, After: a})
+	return nil
 }
 
 // UpdateArchViewComponent replaces component with new one
 func UpdateArchViewComponent(ac ArchViewComponent) error {
+	before, _ := FindArchViewComponentByID(ac.ID)
 	archViewComponentCollection := db.DB.Collection(db.ArchViewComponentCollectionName)
 	query := bson.M{"id": ac.ID}
 
 	replaceResult, err := archViewComponentCollection.ReplaceOne(context.TODO(), query, ac)
 	fmt.Println("Replaced a single document:", replaceResult)
-	return err
+	if err != nil {
+		return err
+	}
+	emit(Event{Type: EventComponentUpdated, ProjectID: ac.ProjectID, TargetKind: "component", TargetID: ac.ID, Related: []string{ac.ViewID}, Actor: ac.Actor, Before: before, After: ac})
+	return nil
 }
 
 // FindArchViewComponentByID returns an ArchView or error
@@ -246,6 +329,102 @@ func FindArchViewComponentByID(id string) (ArchViewComponent, error) {
 	return resultComponent, err
 }
 
+// FindProjectArchViewComponent returns the component of the project or mongo.ErrNoDocuments
+func FindProjectArchViewComponent(projectID string, id string) (ArchViewComponent, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+	collection := db.DB.Collection(db.ArchViewComponentCollectionName)
+
+	var resultComponent ArchViewComponent
+	err := collection.FindOne(ctx, bson.M{"projectid": projectID, "id": id}).Decode(&resultComponent)
+	return resultComponent, err
+}
+
+// FindArchViewComponentByExternalID returns the component of the project imported with the external id
+func FindArchViewComponentByExternalID(projectID string, externalID string) (ArchViewComponent, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+	collection := db.DB.Collection(db.ArchViewComponentCollectionName)
+
+	var resultComponent ArchViewComponent
+
+	filter := bson.M{"projectid": projectID, "externalid": externalID}
+	err := collection.FindOne(ctx, filter).Decode(&resultComponent)
+	return resultComponent, err
+}
+
+// FindArchViewComponentsByExternalIDPrefix returns the components of the project whose external id starts with prefix
+func FindArchViewComponentsByExternalIDPrefix(projectID string, prefix string) ([]ArchViewComponent, error) {
+	collection := db.DB.Collection(db.ArchViewComponentCollectionName)
+	filter := bson.M{
+		"projectid":  projectID,
+		"externalid": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)},
+	}
+	cur, err := collection.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	var result []ArchViewComponent
+	for cur.Next(context.TODO()) {
+		var elem ArchViewComponent
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
+// FindArchViewComponentByRef returns the component of the project with ref as id or external id
+func FindArchViewComponentByRef(projectID string, ref string) (ArchViewComponent, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+	collection := db.DB.Collection(db.ArchViewComponentCollectionName)
+
+	var resultComponent ArchViewComponent
+
+	filter := bson.M{
+		"projectid": projectID,
+		"$or": []interface{}{
+			bson.M{"id": ref},
+			bson.M{"externalid": ref},
+		},
+	}
+	err := collection.FindOne(ctx, filter).Decode(&resultComponent)
+	return resultComponent, err
+}
+
+// FindArchViewComponentsWithSourceLocations returns the components of the project annotated in the code
+func FindArchViewComponentsWithSourceLocations(projectID string) ([]ArchViewComponent, error) {
+	collection := db.DB.Collection(db.ArchViewComponentCollectionName)
+	filter := bson.M{
+		"projectid":       projectID,
+		"sourcelocations": bson.M{"$exists": true, "$ne": bson.A{}},
+	}
+	cur, err := collection.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	var result []ArchViewComponent
+	for cur.Next(context.TODO()) {
+		var elem ArchViewComponent
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
 // FindArchViewComponentsByViewID returns an ArchView or error
 func FindArchViewComponentsByViewID(id string) ([]ArchViewComponent, error) {
 	collection := db.DB.Collection(db.ArchViewComponentCollectionName)
@@ -305,3 +484,49 @@ func FindArchViewComponentsByProjectID(id string) ([]ArchViewComponent, error) {
 
 	return result, nil
 }
+
+// WalkArchViewsOfProject calls fn for every archview of the project without loading them all at once
+func WalkArchViewsOfProject(projectID string, fn func(ArchView) error) error {
+	collection := db.DB.Collection(db.ArchViewCollectionName)
+	filter := bson.D{primitive.E{Key: "projectid", Value: projectID}}
+	cur, err := collection.Find(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var elem ArchView
+		if err := cur.Decode(&elem); err != nil {
+			return err
+		}
+		if err := fn(elem); err != nil {
+			return err
+		}
+	}
+
+	return cur.Err()
+}
+
+// WalkArchViewComponentsOfView calls fn for every component of the view without loading them all at once
+func WalkArchViewComponentsOfView(viewID string, fn func(ArchViewComponent) error) error {
+	collection := db.DB.Collection(db.ArchViewComponentCollectionName)
+	filter := bson.D{primitive.E{Key: "viewid", Value: viewID}}
+	cur, err := collection.Find(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var elem ArchViewComponent
+		if err := cur.Decode(&elem); err != nil {
+			return err
+		}
+		if err := fn(elem); err != nil {
+			return err
+		}
+	}
+
+	return cur.Err()
+}
@@ -0,0 +1,361 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	db "traceability/database"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AttributeType is the type of a custom component attribute
+type AttributeType string
+
+const (
+	// AttributeString is free text
+	AttributeString AttributeType = "string"
+	// AttributeNumber is a json number
+	AttributeNumber AttributeType = "number"
+	// AttributeEnum is one of the values of the schema
+	AttributeEnum AttributeType = "enum"
+	// AttributeDate is a date as 2006-01-02
+	AttributeDate AttributeType = "date"
+	// AttributeUser is the id of a user
+	AttributeUser AttributeType = "user"
+	// AttributeURL is an absolute url
+	AttributeURL AttributeType = "url"
+)
+
+// attributeDateLayout is the layout of date attributes, dates in this layout sort as strings
+const attributeDateLayout = "2006-01-02"
+
+// AttributeSchema defines a custom attribute of the components of a view kind
+// swagger:model
+type AttributeSchema struct {
+	// key of the attribute in the attributes of components
+	//
+	// required: true
+	Name string `json:"name" validate:"required,viewkind"`
+
+	// name shown to users
+	//
+	// required: false
+	Label string `json:"label,omitempty" bson:"label,omitempty"`
+
+	// "string", "number", "enum", "date", "user" or "url"
+	//
+	// required: true
+	Type AttributeType `json:"type" validate:"required,oneof=string number enum date user url"`
+
+	// allowed values of enum attributes
+	//
+	// required: false
+	Values []string `json:"values,omitempty" bson:"values,omitempty" validate:"dive,required"`
+
+	// components of the view kind must set the attribute
+	//
+	// required: false
+	Required bool `json:"required,omitempty" bson:"required,omitempty"`
+}
+
+// validateAttributeSchema requires the values of enum attributes, they end
+// up in a oneof tag and can't contain its separators
+func validateAttributeSchema(sl validator.StructLevel) {
+	s := sl.Current().Interface().(AttributeSchema)
+	if s.Type == AttributeEnum && len(s.Values) == 0 {
+		sl.ReportError(s.Values, "Values", "values", "required", "")
+	}
+	for _, v := range s.Values {
+		if strings.ContainsAny(v, ",|'") {
+			sl.ReportError(s.Values, "Values", "values", "excludesall", ",|'")
+		}
+	}
+}
+
+// tag returns the validator tag checking values of the attribute
+func (s AttributeSchema) tag() string {
+	tag := "attribute=" + string(s.Type)
+	switch s.Type {
+	case AttributeEnum:
+		quoted := make([]string, len(s.Values))
+		for i, v := range s.Values {
+			quoted[i] = "'" + v + "'"
+		}
+		tag += ",oneof=" + strings.Join(quoted, " ")
+	case AttributeDate:
+		tag += ",datetime=" + attributeDateLayout
+	case AttributeURL:
+		tag += ",url"
+	case AttributeUser:
+		tag += ",user"
+	}
+	return tag
+}
+
+// validateAttributeType checks the json type of an attribute value before
+// the checks of its type run, they panic on values of another type
+func validateAttributeType(fl validator.FieldLevel) bool {
+	switch fl.Field().Interface().(type) {
+	case float64:
+		return AttributeType(fl.Param()) == AttributeNumber
+	case string:
+		return AttributeType(fl.Param()) != AttributeNumber
+	}
+	return false
+}
+
+// validateUser checks that the value is the id of a user
+func validateUser(fl validator.FieldLevel) bool {
+	_, err := FindUserByID(fl.Field().String())
+	return err == nil
+}
+
+// validateSchema fails for attributes which are not in the schema of the view kind
+func validateSchema(fl validator.FieldLevel) bool {
+	return false
+}
+
+// ValidateAttributes validates the custom attributes of a component against
+// the schemas of its view kind
+func (v *Validation) ValidateAttributes(attributes map[string]interface{}, schemas []AttributeSchema) ValidationErrors {
+	var returnErrs []ValidationError
+	collect := func(name string, err error) {
+		if err == nil {
+			return
+		}
+		for _, err := range err.(validator.ValidationErrors) {
+			returnErrs = append(returnErrs, ValidationError{FieldError: err.(validator.FieldError), attribute: name})
+		}
+	}
+
+	known := map[string]bool{}
+	for _, s := range schemas {
+		known[s.Name] = true
+		value, ok := attributes[s.Name]
+		if !ok || value == nil {
+			if s.Required {
+				collect(s.Name, v.validate.Var(nil, "required"))
+			}
+			continue
+		}
+		collect(s.Name, v.validate.Var(value, s.tag()))
+	}
+	for name, value := range attributes {
+		if !known[name] {
+			collect(name, v.validate.Var(value, "schema"))
+		}
+	}
+
+	return returnErrs
+}
+
+// kindAttributeSchemas are the attribute schemas of a view kind in a project
+type kindAttributeSchemas struct {
+	ProjectID string            `bson:"projectid"`
+	Kind      string            `bson:"kind"`
+	Schemas   []AttributeSchema `bson:"schemas"`
+}
+
+// findProjectAttributeSchemas returns the attribute schemas of the project by view kind
+func findProjectAttributeSchemas(projectID string) (map[string][]AttributeSchema, error) {
+	collection := db.DB.Collection(db.AttributeSchemaCollectionName)
+	cur, err := collection.Find(context.TODO(), bson.M{"projectid": projectID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := map[string][]AttributeSchema{}
+	for cur.Next(context.TODO()) {
+		var elem kindAttributeSchemas
+		if err := cur.Decode(&elem); err != nil {
+			return nil, err
+		}
+		result[elem.Kind] = elem.Schemas
+	}
+	return result, cur.Err()
+}
+
+// findKindAttributeSchemas returns the attribute schemas of the view kind in the project
+func findKindAttributeSchemas(projectID string, kind string) ([]AttributeSchema, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.AttributeSchemaCollectionName)
+
+	var result kindAttributeSchemas
+	err := collection.FindOne(ctx, bson.M{"projectid": projectID, "kind": kind}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return result.Schemas, err
+}
+
+// setAttributeSchemas replaces the attribute schemas of the view kind in the project
+func setAttributeSchemas(projectID string, kind string, schemas []AttributeSchema) error {
+	if len(schemas) == 0 {
+		return deleteAttributeSchemas(projectID, kind)
+	}
+	collection := db.DB.Collection(db.AttributeSchemaCollectionName)
+	query := bson.M{"projectid": projectID, "kind": kind}
+	s := kindAttributeSchemas{ProjectID: projectID, Kind: kind, Schemas: schemas}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, s, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	fmt.Println("Upserted a single document:", replaceResult)
+	return nil
+}
+
+// deleteAttributeSchemas removes the attribute schemas of the view kind in the project
+func deleteAttributeSchemas(projectID string, kind string) error {
+	collection := db.DB.Collection(db.AttributeSchemaCollectionName)
+	deleteResult, err := collection.DeleteOne(context.TODO(), bson.M{"projectid": projectID, "kind": kind})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Deleted a single document:", deleteResult)
+	return nil
+}
+
+// FindAttributeSchemas returns the attribute schemas of the view kind of the component's view
+func FindAttributeSchemas(c ArchViewComponent) ([]AttributeSchema, error) {
+	view, err := FindArchViewByID(c.ViewID)
+	if err != nil {
+		return nil, err
+	}
+	k, err := FindViewKind(view.ProjectID, view.Kind)
+	if err != nil {
+		return nil, err
+	}
+	return k.AttributeSchemas, nil
+}
+
+// attributeFilterOperators map the operator prefixes of filter values to mongo operators
+var attributeFilterOperators = []struct {
+	prefix   string
+	operator string
+}{
+	{">=", "$gte"},
+	{"<=", "$lte"},
+	{">", "$gt"},
+	{"<", "$lt"},
+	{"!", "$ne"},
+}
+
+// ComponentFilter selects components by their custom attributes
+type ComponentFilter bson.M
+
+// ParseComponentFilter returns the filter matching components by the custom
+// attributes in the query parameters "attr.<name>=<value>". Number and
+// date values can be prefixed with >=, <=, > or <, all values with ! to
+// negate, attributes which are not in a schema are rejected.
+func ParseComponentFilter(query url.Values, kinds []ViewKindDefinition) (ComponentFilter, error) {
+	types := map[string]AttributeType{}
+	for _, k := range kinds {
+		for _, s := range k.AttributeSchemas {
+			types[s.Name] = s.Type
+		}
+	}
+
+	filter := ComponentFilter{}
+	for key, values := range query {
+		if !strings.HasPrefix(key, "attr.") {
+			continue
+		}
+		name := strings.TrimPrefix(key, "attr.")
+		t, ok := types[name]
+		if !ok {
+			return nil, fmt.Errorf("attribute %s is not defined in the view kinds of the project", name)
+		}
+
+		conditions := bson.M{}
+		var in, notIn []interface{}
+		for _, raw := range values {
+			operator, value := "$eq", raw
+			for _, o := range attributeFilterOperators {
+				if strings.HasPrefix(raw, o.prefix) {
+					operator, value = o.operator, strings.TrimPrefix(raw, o.prefix)
+					break
+				}
+			}
+			ordered := operator != "$eq" && operator != "$ne"
+			if ordered && t != AttributeNumber && t != AttributeDate {
+				return nil, fmt.Errorf("attribute %s of type %s can't be compared with %s", name, t, operator)
+			}
+
+			var v interface{} = value
+			if t == AttributeNumber {
+				n, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("attribute %s needs a number: %v", name, err)
+				}
+				v = n
+			}
+			switch operator {
+			case "$eq":
+				in = append(in, v)
+			case "$ne":
+				notIn = append(notIn, v)
+			default:
+				conditions[operator] = v
+			}
+		}
+		if len(in) > 0 {
+			conditions["$in"] = in
+		}
+		if len(notIn) > 0 {
+			conditions["$nin"] = notIn
+		}
+		filter["customattributes."+name] = conditions
+	}
+	return filter, nil
+}
+
+// FindArchViewComponentsByViewIDAndFilter returns the components of the view matching the filter
+func FindArchViewComponentsByViewIDAndFilter(id string, f ComponentFilter) ([]ArchViewComponent, error) {
+	filter := bson.M{"viewid": id}
+	for k, v := range f {
+		filter[k] = v
+	}
+	return findArchViewComponents(filter)
+}
+
+// FindArchViewComponentsByProjectIDAndFilter returns the components of the project matching the filter
+func FindArchViewComponentsByProjectIDAndFilter(id string, f ComponentFilter) ([]ArchViewComponent, error) {
+	filter := bson.M{"projectid": id}
+	for k, v := range f {
+		filter[k] = v
+	}
+	return findArchViewComponents(filter)
+}
+
+func findArchViewComponents(filter bson.M) ([]ArchViewComponent, error) {
+	collection := db.DB.Collection(db.ArchViewComponentCollectionName)
+	cur, err := collection.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []ArchViewComponent{}
+	for cur.Next(context.TODO()) {
+		var elem ArchViewComponent
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
@@ -1,5 +1,48 @@
 package data
 
+// Signup is a new user with their password
+// swagger:model
+type Signup struct {
+	// the name of the user
+	//
+	// required: true
+	Name string `json:"name" validate:"required"`
+
+	// the email of the user
+	//
+	// required: true
+	Email string `json:"email" validate:"required,email"`
+
+	// the password of the user. New passwords have at least 10 characters,
+	// at most 72 bytes and 3 of lower case letters, upper case letters,
+	// digits and other characters.
+	//
+	// required: true
+	Password string `json:"password" validate:"required,password"`
+
+	// token of an invitation to accept on signup
+	//
+	// required: false
+	Invitation string `json:"invitation,omitempty"`
+}
+
+// LoginResponse is a user with the tokens of their new or refreshed session
+// swagger:model
+type LoginResponse struct {
+	User
+
+	// access token of the session, it is also set as cookie
+	AccessToken string `json:"accessToken"`
+
+	// token getting new access tokens at /refresh, it is also set as cookie
+	RefreshToken string `json:"refreshToken"`
+}
+
+// WithTokens returns the user with the tokens of their session
+func (u User) WithTokens() LoginResponse {
+	return LoginResponse{User: u, AccessToken: u.AccessToken, RefreshToken: u.RefreshToken}
+}
+
 // Auth is the auth model
 type Auth struct {
 	// the email of the user
@@ -15,6 +58,11 @@ type Auth struct {
 	// max length: 30
 	// min legnght: 6
 	Password string `json:"password" validate:"required"`
+
+	// token of an invitation to accept on login
+	//
+	// required: false
+	Invitation string `json:"invitation,omitempty"`
 }
 
 var authList = []*Auth{
@@ -0,0 +1,226 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	db "traceability/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidCursor is returned when a change feed cursor can't be parsed
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// changeKinds are the kinds of things recorded in the change feed
+var changeKinds = map[string]bool{
+	"project":   true,
+	"view":      true,
+	"component": true,
+	"link":      true,
+	"member":    true,
+}
+
+// FieldChange is a top-level field of a changed thing with its json values
+// before and after the change
+// swagger:model
+type FieldChange struct {
+	// json name of the field
+	Field string `json:"field"`
+
+	// value before the change, missing for new fields
+	Before interface{} `json:"before,omitempty" bson:"before,omitempty"`
+
+	// value after the change, missing for removed fields
+	After interface{} `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+// Change is an entry of the append-only change feed of a project
+// swagger:model
+type Change struct {
+	// position in the feed of the project, pass it as since to resume after the change
+	Cursor string `json:"cursor" bson:"-"`
+
+	// sequence number in the feed of the project
+	Seq int64 `json:"-"`
+
+	// id of the project
+	ProjectID string `json:"projectID" bson:"projectid"`
+
+	// what happened, e.g. "component.updated"
+	Type EventType `json:"type"`
+
+	// "project", "view", "component", "link" or "member"
+	TargetKind string `json:"targetKind" bson:"targetkind"`
+
+	// id of the changed thing
+	TargetID string `json:"targetID" bson:"targetid"`
+
+	// id of the user making the change, empty for imports and scans
+	Actor string `json:"actor,omitempty" bson:"actor,omitempty"`
+
+	// time of the change
+	Time time.Time `json:"time"`
+
+	// changed fields, all fields of created things
+	Diff []FieldChange `json:"diff"`
+}
+
+// ChangePage is a part of the change feed of a project
+// swagger:model
+type ChangePage struct {
+	// changes after the requested cursor, oldest first
+	Changes []Change `json:"changes"`
+
+	// cursor of the last change of the page, pass it as since to get the next page
+	Cursor string `json:"cursor"`
+
+	// set when there are further changes after the page
+	HasMore bool `json:"hasMore"`
+}
+
+// changeMu keeps the sequence numbers of the feed in the order of their
+// inserts, so that a reader never skips a change by resuming after a later one
+var changeMu sync.Mutex
+
+// jsonFields returns the json representation of v as a map of its fields
+func jsonFields(v interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if v == nil {
+		return fields, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return fields, json.Unmarshal(b, &fields)
+}
+
+// Diff returns the top-level json fields which differ between before and after, sorted by name
+func Diff(before interface{}, after interface{}) ([]FieldChange, error) {
+	b, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range b {
+		names[name] = true
+	}
+	for name := range a {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	result := []FieldChange{}
+	for _, name := range sorted {
+		if !reflect.DeepEqual(b[name], a[name]) {
+			result = append(result, FieldChange{Field: name, Before: b[name], After: a[name]})
+		}
+	}
+	return result, nil
+}
+
+// nextChangeSeq increments and returns the sequence counter of the project's feed
+func nextChangeSeq(projectID string) (int64, error) {
+	collection := db.DB.Collection(db.CounterCollectionName)
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	filter := bson.M{"name": "changes", "projectid": projectID}
+	err := collection.FindOneAndUpdate(context.TODO(), filter, bson.M{"$inc": bson.M{"seq": 1}}, opts).Decode(&counter)
+	return counter.Seq, err
+}
+
+// recordChange appends changes of projects, views, components, links and
+// members to the feed of their project
+func recordChange(e Event) error {
+	if !changeKinds[e.TargetKind] {
+		return nil
+	}
+	diff, err := Diff(e.Before, e.After)
+	if err != nil {
+		return err
+	}
+
+	changeMu.Lock()
+	defer changeMu.Unlock()
+
+	seq, err := nextChangeSeq(e.ProjectID)
+	if err != nil {
+		return err
+	}
+	c := Change{
+		Seq:        seq,
+		ProjectID:  e.ProjectID,
+		Type:       e.Type,
+		TargetKind: e.TargetKind,
+		TargetID:   e.TargetID,
+		Actor:      e.Actor,
+		Time:       e.Time,
+		Diff:       diff,
+	}
+	insertResult, err := db.DB.Collection(db.ChangeCollectionName).InsertOne(context.TODO(), c)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return nil
+}
+
+// FindChanges returns up to limit changes of the project after the cursor,
+// an empty cursor starts at the beginning of the feed
+func FindChanges(projectID string, since string, limit int64) (ChangePage, error) {
+	var after int64
+	if since != "" {
+		n, err := strconv.ParseInt(since, 10, 64)
+		if err != nil || n < 0 {
+			return ChangePage{}, ErrInvalidCursor
+		}
+		after = n
+	}
+
+	filter := bson.M{"projectid": projectID, "seq": bson.M{"$gt": after}}
+	// one more than asked for tells whether there are more
+	opts := options.Find().SetSort(bson.M{"seq": 1}).SetLimit(limit + 1)
+	cur, err := db.DB.Collection(db.ChangeCollectionName).Find(context.TODO(), filter, opts)
+	if err != nil {
+		return ChangePage{}, err
+	}
+	defer cur.Close(context.TODO())
+
+	page := ChangePage{Changes: []Change{}, Cursor: strconv.FormatInt(after, 10)}
+	for cur.Next(context.TODO()) {
+		if int64(len(page.Changes)) == limit {
+			page.HasMore = true
+			break
+		}
+		var elem Change
+		if err := cur.Decode(&elem); err != nil {
+			return page, err
+		}
+		elem.Cursor = strconv.FormatInt(elem.Seq, 10)
+		page.Changes = append(page.Changes, elem)
+		page.Cursor = elem.Cursor
+	}
+
+	return page, cur.Err()
+}
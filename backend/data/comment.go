@@ -0,0 +1,398 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	db "traceability/database"
+
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CommentTarget is the kind of thing a comment thread is attached to
+type CommentTarget string
+
+const (
+	// CommentOnComponent is a comment on an ArchViewComponent
+	CommentOnComponent CommentTarget = "component"
+	// CommentOnLink is a comment on a Link
+	CommentOnLink CommentTarget = "link"
+	// CommentOnView is a comment on an ArchView
+	CommentOnView CommentTarget = "view"
+)
+
+var (
+	// ErrUnknownComment is returned when a comment is not found on the target
+	ErrUnknownComment = errors.New("comment not found")
+	// ErrNotCommentAuthor is returned when someone else than the author edits a comment
+	ErrNotCommentAuthor = errors.New("only the author can edit a comment")
+	// ErrNotThreadRoot is returned when a reply is resolved or reopened instead of its thread
+	ErrNotThreadRoot = errors.New("only threads can be resolved and reopened")
+)
+
+// CommentRevision is a former body of an edited comment
+// swagger:model
+type CommentRevision struct {
+	// markdown body before the edit
+	Body string `json:"body"`
+
+	// time the body was replaced
+	EditedAt time.Time `json:"editedAt"`
+}
+
+// Comment is a markdown comment on a component, link or view. Comments
+// without ThreadID start a thread, replies carry the id of the thread.
+// swagger:model
+type Comment struct {
+	// the id of the comment
+	//
+	// required: false
+	ID string `json:"id"`
+
+	// belonging project's id
+	//
+	// required: false
+	ProjectID string `json:"projectID" bson:"projectid"`
+
+	// "component", "link" or "view"
+	//
+	// required: false
+	TargetKind CommentTarget `json:"targetKind" bson:"targetkind"`
+
+	// id of the component, link or view
+	//
+	// required: false
+	TargetID string `json:"targetID" bson:"targetid"`
+
+	// id of the first comment of the thread, empty for the first comment itself
+	//
+	// required: false
+	ThreadID string `json:"threadID,omitempty" bson:"threadid,omitempty"`
+
+	// id of the author
+	//
+	// required: false
+	Author string `json:"author"`
+
+	// markdown body
+	//
+	// required: true
+	Body string `json:"body" validate:"required,max=10000"`
+
+	// ids of the project members mentioned with @name in the body
+	//
+	// required: false
+	Mentions []string `json:"mentions,omitempty" bson:"mentions,omitempty"`
+
+	// time the comment was written
+	//
+	// required: false
+	CreatedAt time.Time `json:"createdAt" bson:"createdat"`
+
+	// time of the last edit
+	//
+	// required: false
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" bson:"updatedat,omitempty"`
+
+	// former bodies, oldest first
+	//
+	// required: false
+	History []CommentRevision `json:"history,omitempty" bson:"history,omitempty"`
+
+	// set when the thread is resolved
+	//
+	// required: false
+	Resolved bool `json:"resolved"`
+
+	// id of the user who resolved the thread
+	//
+	// required: false
+	ResolvedBy string `json:"resolvedBy,omitempty" bson:"resolvedby,omitempty"`
+
+	// time the thread was resolved
+	//
+	// required: false
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty" bson:"resolvedat,omitempty"`
+}
+
+// CommentThread is the first comment of a thread with its replies, oldest first
+// swagger:model
+type CommentThread struct {
+	Comment
+
+	// replies to the first comment
+	Replies []Comment `json:"replies"`
+}
+
+// mentionPattern matches @handles which don't follow a word character, so
+// that e-mail addresses in comments are no mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]*\w)`)
+
+// ParseMentions returns the handles mentioned in body without the @, in order and unique
+func ParseMentions(body string) []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(m[1])
+		if !seen[handle] {
+			seen[handle] = true
+			result = append(result, handle)
+		}
+	}
+	return result
+}
+
+// mentionHandles are the handles a user can be mentioned with: the local part
+// of the e-mail address and the name without spaces
+func mentionHandles(u User) []string {
+	handles := []string{strings.ToLower(strings.ReplaceAll(u.Name, " ", ""))}
+	if i := strings.Index(u.Email, "@"); i > 0 {
+		handles = append(handles, strings.ToLower(u.Email[:i]))
+	}
+	return handles
+}
+
+// ResolveMentions returns the ids of the project members mentioned in body
+func ResolveMentions(projectID string, body string) ([]string, error) {
+	handles := ParseMentions(body)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	project, err := FindProjectByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	byHandle := map[string]string{}
+	for _, m := range project.Members {
+		u, err := FindUserByID(m.ID)
+		if err != nil {
+			continue
+		}
+		for _, h := range mentionHandles(u) {
+			byHandle[h] = u.ID
+		}
+	}
+
+	var result []string
+	seen := map[string]bool{}
+	for _, h := range handles {
+		if id, ok := byHandle[h]; ok && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+// FindCommentTargetProject returns the project of the component, link or view
+// the comments are attached to, or mongo.ErrNoDocuments if it doesn't exist
+func FindCommentTargetProject(kind CommentTarget, id string) (string, error) {
+	switch kind {
+	case CommentOnComponent:
+		c, err := FindArchViewComponentByID(id)
+		return c.ProjectID, err
+	case CommentOnLink:
+		l, err := FindLinkByID(id)
+		return l.ProjectID, err
+	case CommentOnView:
+		v, err := FindArchViewByID(id)
+		return v.ProjectID, err
+	}
+	return "", mongo.ErrNoDocuments
+}
+
+// AddComment stores a new comment or reply written now, mentions are resolved from the body
+func AddComment(c Comment) (Comment, error) {
+	if c.ThreadID != "" {
+		thread, err := FindCommentByID(c.TargetKind, c.TargetID, c.ThreadID)
+		if err != nil {
+			return c, err
+		}
+		// replies to replies end up in the same thread
+		if thread.ThreadID != "" {
+			c.ThreadID = thread.ThreadID
+		}
+	}
+
+	mentions, err := ResolveMentions(c.ProjectID, c.Body)
+	if err != nil {
+		return c, err
+	}
+	c.ID = guuid.New().String()
+	c.Mentions = mentions
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = nil
+	c.History = nil
+	c.Resolved, c.ResolvedBy, c.ResolvedAt = false, "", nil
+
+	collection := db.DB.Collection(db.CommentCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), c)
+	if err != nil {
+		return c, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	emit(c.event(EventCommentCreated, c.Author, nil))
+	return c, nil
+}
+
+// FindCommentByID returns the comment on the target or ErrUnknownComment
+func FindCommentByID(kind CommentTarget, targetID string, id string) (Comment, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.CommentCollectionName)
+
+	var result Comment
+	filter := bson.M{"targetkind": kind, "targetid": targetID, "id": id}
+	err := collection.FindOne(ctx, filter).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, ErrUnknownComment
+	}
+	return result, err
+}
+
+// FindCommentThreads returns the threads on the target, oldest first. If
+// resolved is not nil only threads with that state are returned.
+func FindCommentThreads(kind CommentTarget, targetID string, resolved *bool) ([]CommentThread, error) {
+	collection := db.DB.Collection(db.CommentCollectionName)
+	cur, err := collection.Find(context.TODO(), bson.M{"targetkind": kind, "targetid": targetID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	var comments []Comment
+	for cur.Next(context.TODO()) {
+		var elem Comment
+		if err := cur.Decode(&elem); err != nil {
+			return nil, err
+		}
+		comments = append(comments, elem)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+
+	result := []CommentThread{}
+	index := map[string]int{}
+	for _, c := range comments {
+		if c.ThreadID == "" {
+			index[c.ID] = len(result)
+			result = append(result, CommentThread{Comment: c, Replies: []Comment{}})
+		}
+	}
+	for _, c := range comments {
+		if i, ok := index[c.ThreadID]; ok {
+			result[i].Replies = append(result[i].Replies, c)
+		}
+	}
+
+	if resolved == nil {
+		return result, nil
+	}
+	filtered := []CommentThread{}
+	for _, t := range result {
+		if t.Resolved == *resolved {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered, nil
+}
+
+// UpdateComment replaces the body of the comment by its author and keeps the former body in the history
+func UpdateComment(kind CommentTarget, targetID string, id string, author string, body string) (Comment, error) {
+	c, err := FindCommentByID(kind, targetID, id)
+	if err != nil {
+		return c, err
+	}
+	if c.Author != author {
+		return c, ErrNotCommentAuthor
+	}
+	if c.Body == body {
+		return c, nil
+	}
+	before := c
+
+	mentions, err := ResolveMentions(c.ProjectID, body)
+	if err != nil {
+		return c, err
+	}
+	now := time.Now()
+	c.History = append(c.History, CommentRevision{Body: c.Body, EditedAt: now})
+	c.Body = body
+	c.Mentions = mentions
+	c.UpdatedAt = &now
+
+	if err := replaceComment(c); err != nil {
+		return c, err
+	}
+	emit(c.event(EventCommentUpdated, author, before))
+	return c, nil
+}
+
+// ResolveCommentThread marks the thread resolved by user, or reopens it if resolved is false
+func ResolveCommentThread(kind CommentTarget, targetID string, id string, user string, resolved bool) (Comment, error) {
+	c, err := FindCommentByID(kind, targetID, id)
+	if err != nil {
+		return c, err
+	}
+	if c.ThreadID != "" {
+		return c, ErrNotThreadRoot
+	}
+	if c.Resolved == resolved {
+		return c, nil
+	}
+
+	before := c
+	c.Resolved = resolved
+	if resolved {
+		now := time.Now()
+		c.ResolvedBy, c.ResolvedAt = user, &now
+	} else {
+		c.ResolvedBy, c.ResolvedAt = "", nil
+	}
+	if err := replaceComment(c); err != nil {
+		return c, err
+	}
+	emit(c.event(EventCommentUpdated, user, before))
+	return c, nil
+}
+
+// event returns the event of a change of the comment by actor
+func (c Comment) event(t EventType, actor string, before interface{}) Event {
+	e := Event{
+		Type:       t,
+		ProjectID:  c.ProjectID,
+		TargetKind: "comment",
+		TargetID:   c.ID,
+		Related:    []string{c.TargetID},
+		Mentions:   c.Mentions,
+		Actor:      actor,
+		After:      c,
+	}
+	if before != nil {
+		e.Before = before
+	}
+	return e
+}
+
+func replaceComment(c Comment) error {
+	collection := db.DB.Collection(db.CommentCollectionName)
+	query := bson.M{"id": c.ID}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, c)
+	fmt.Println("Replaced a single document:", replaceResult)
+	return err
+}
@@ -0,0 +1,125 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+	db "traceability/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Commit is a git commit whose message references components of a project
+// swagger:model
+type Commit struct {
+	// hash of the commit
+	//
+	// required: true
+	Hash string `json:"hash"`
+
+	// id of the project
+	//
+	// required: true
+	ProjectID string `json:"projectID"`
+
+	// path of the repository the commit was read from
+	//
+	// required: false
+	Repository string `json:"repository"`
+
+	// name of the author
+	//
+	// required: false
+	Author string `json:"author"`
+
+	// email of the author
+	//
+	// required: false
+	Email string `json:"email"`
+
+	// author date
+	//
+	// required: false
+	Date time.Time `json:"date"`
+
+	// full commit message
+	//
+	// required: false
+	Message string `json:"message"`
+
+	// paths of the files touched by the commit
+	//
+	// required: false
+	Files []string `json:"files,omitempty"`
+
+	// ids of the components referenced in the message
+	//
+	// required: true
+	ComponentIDs []string `json:"componentIDs" bson:"componentids"`
+}
+
+// GitScan remembers the last commit scanned in a repository for a project
+type GitScan struct {
+	ProjectID  string    `json:"projectID"`
+	Repository string    `json:"repository"`
+	LastCommit string    `json:"lastCommit"`
+	ScannedAt  time.Time `json:"scannedAt"`
+}
+
+// AddCommit stores the commit, a commit scanned again replaces the stored one
+func AddCommit(c Commit) error {
+	collection := db.DB.Collection(db.CommitCollectionName)
+	query := bson.M{"projectid": c.ProjectID, "hash": c.Hash}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, c, options.Replace().SetUpsert(true))
+	fmt.Println("Upserted a single document:", replaceResult)
+	return err
+}
+
+// FindCommitsOfComponent returns the commits of the project referencing the component, newest first
+func FindCommitsOfComponent(projectID string, componentID string) ([]Commit, error) {
+	collection := db.DB.Collection(db.CommitCollectionName)
+	filter := bson.M{"projectid": projectID, "componentids": componentID}
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}})
+	cur, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []Commit{}
+	for cur.Next(context.TODO()) {
+		var elem Commit
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
+// FindGitScan returns the last scan of the repository for the project
+func FindGitScan(projectID string, repository string) (GitScan, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.GitScanCollectionName)
+
+	var result GitScan
+	filter := bson.M{"projectid": projectID, "repository": repository}
+	err := collection.FindOne(ctx, filter).Decode(&result)
+	return result, err
+}
+
+// UpdateGitScan stores the last scan of the repository for the project
+func UpdateGitScan(s GitScan) error {
+	collection := db.DB.Collection(db.GitScanCollectionName)
+	query := bson.M{"projectid": s.ProjectID, "repository": s.Repository}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, s, options.Replace().SetUpsert(true))
+	fmt.Println("Upserted a single document:", replaceResult)
+	return err
+}
@@ -0,0 +1,143 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+	db "traceability/database"
+
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DriftChangeKind is the kind of difference between code and development view
+type DriftChangeKind string
+
+const (
+	// DriftPackageAdded is a package in the code without component
+	DriftPackageAdded DriftChangeKind = "package.added"
+	// DriftPackageRemoved is a component whose package is no longer in the code
+	DriftPackageRemoved DriftChangeKind = "package.removed"
+	// DriftFunctionAdded is a function missing from FunctionList
+	DriftFunctionAdded DriftChangeKind = "function.added"
+	// DriftFunctionRemoved is a function in FunctionList which is no longer in the code
+	DriftFunctionRemoved DriftChangeKind = "function.removed"
+	// DriftVariableAdded is a variable missing from VarList
+	DriftVariableAdded DriftChangeKind = "variable.added"
+	// DriftVariableRemoved is a variable in VarList which is no longer in the code
+	DriftVariableRemoved DriftChangeKind = "variable.removed"
+	// DriftTypeAdded is a type missing from TypeList
+	DriftTypeAdded DriftChangeKind = "type.added"
+	// DriftTypeRemoved is a type in TypeList which is no longer in the code
+	DriftTypeRemoved DriftChangeKind = "type.removed"
+	// DriftDependencyMissing is an import between packages without link
+	DriftDependencyMissing DriftChangeKind = "dependency.missing"
+)
+
+// Status of a drift report
+const (
+	DriftPending = "pending"
+	DriftPartial = "partial"
+	DriftApplied = "applied"
+)
+
+// DriftChange is one difference between the code and the development view
+// swagger:model
+type DriftChange struct {
+	// id of the change within the report
+	ID string `json:"id"`
+
+	// kind of the change
+	Kind DriftChangeKind `json:"kind"`
+
+	// import path of the package
+	Package string `json:"package"`
+
+	// id of the development component of the package, empty for added packages
+	ComponentID string `json:"componentID,omitempty" bson:"componentid,omitempty"`
+
+	// function, variable or type name
+	Name string `json:"name,omitempty" bson:"name,omitempty"`
+
+	// import path of the imported package of a missing dependency
+	Target string `json:"target,omitempty" bson:"target,omitempty"`
+
+	// id of the development component of the imported package, empty if it is added
+	TargetComponentID string `json:"targetComponentID,omitempty" bson:"targetcomponentid,omitempty"`
+
+	// package clause name and directory of an added package
+	PackageName string `json:"packageName,omitempty" bson:"packagename,omitempty"`
+	Dir         string `json:"dir,omitempty" bson:"dir,omitempty"`
+
+	// exported api of an added package
+	Functions []string `json:"functions,omitempty" bson:"functions,omitempty"`
+	Variables []string `json:"variables,omitempty" bson:"variables,omitempty"`
+	Types     []string `json:"types,omitempty" bson:"types,omitempty"`
+
+	// set once the change was applied to the development view
+	Applied bool `json:"applied"`
+}
+
+// DriftReport is a reviewable batch of changes bringing the development view in line with the code
+// swagger:model
+type DriftReport struct {
+	// the id of the report
+	ID string `json:"id"`
+
+	// id of the project
+	ProjectID string `json:"projectID"`
+
+	// pending, partial or applied
+	Status string `json:"status"`
+
+	// time of the scan
+	CreatedAt time.Time `json:"createdAt"`
+
+	// time the last changes were applied
+	AppliedAt *time.Time `json:"appliedAt,omitempty" bson:"appliedat,omitempty"`
+
+	// differences found
+	Changes []DriftChange `json:"changes"`
+}
+
+// AddDriftReport stores a new drift report
+func AddDriftReport(d DriftReport) (DriftReport, error) {
+	d.ID = guuid.New().String()
+	d.CreatedAt = time.Now()
+	d.Status = DriftPending
+	if d.Changes == nil {
+		d.Changes = []DriftChange{}
+	}
+
+	collection := db.DB.Collection(db.DriftCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), d)
+	if err != nil {
+		return d, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return d, nil
+}
+
+// FindDriftReportByID returns the drift report of the project
+func FindDriftReportByID(projectID string, id string) (DriftReport, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.DriftCollectionName)
+
+	var result DriftReport
+	filter := bson.M{"projectid": projectID, "id": id}
+	err := collection.FindOne(ctx, filter).Decode(&result)
+	return result, err
+}
+
+// UpdateDriftReport replaces drift report with new one
+func UpdateDriftReport(d DriftReport) error {
+	collection := db.DB.Collection(db.DriftCollectionName)
+	query := bson.M{"id": d.ID}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, d)
+	fmt.Println("Replaced a single document:", replaceResult)
+	return err
+}
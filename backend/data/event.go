@@ -0,0 +1,148 @@
+package data
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// EventType names what happened in a project, e.g. "component.created"
+type EventType string
+
+const (
+	// EventProjectCreated is emitted when a project is added
+	EventProjectCreated EventType = "project.created"
+	// EventProjectUpdated is emitted when a project is replaced
+	EventProjectUpdated EventType = "project.updated"
+	// EventViewCreated is emitted when a view is added
+	EventViewCreated EventType = "view.created"
+	// EventViewUpdated is emitted when a view is replaced
+	EventViewUpdated EventType = "view.updated"
+	// EventComponentCreated is emitted when a component is added
+	EventComponentCreated EventType = "component.created"
+	// EventComponentUpdated is emitted when a component is replaced
+	EventComponentUpdated EventType = "component.updated"
+	// EventLinkCreated is emitted when a link is added
+	EventLinkCreated EventType = "link.created"
+	// EventLinkUpdated is emitted when a link is replaced
+	EventLinkUpdated EventType = "link.updated"
+	// EventLinkDeleted is emitted when a link is removed
+	EventLinkDeleted EventType = "link.deleted"
+	// EventCommentCreated is emitted when a comment or reply is written
+	EventCommentCreated EventType = "comment.created"
+	// EventCommentUpdated is emitted when a comment is edited, resolved or reopened
+	EventCommentUpdated EventType = "comment.updated"
+	// EventMemberAdded is emitted when a user joins a project
+	EventMemberAdded EventType = "member.added"
+	// EventMemberUpdated is emitted when the role of a member changes
+	EventMemberUpdated EventType = "member.updated"
+	// EventMemberRemoved is emitted when a user leaves a project
+	EventMemberRemoved EventType = "member.removed"
+)
+
+// eventTypes are the types of the emitted events
+var eventTypes = []EventType{
+	EventProjectCreated, EventProjectUpdated,
+	EventViewCreated, EventViewUpdated,
+	EventComponentCreated, EventComponentUpdated,
+	EventLinkCreated, EventLinkUpdated, EventLinkDeleted,
+	EventCommentCreated, EventCommentUpdated,
+	EventMemberAdded, EventMemberUpdated, EventMemberRemoved,
+}
+
+// validateEventType checks that the value names the type of an emitted event
+func validateEventType(fl validator.FieldLevel) bool {
+	for _, t := range eventTypes {
+		if string(t) == fl.Field().String() {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is a change of a project emitted by the create and update functions
+// swagger:model
+type Event struct {
+	// what happened
+	Type EventType `json:"type"`
+
+	// id of the project
+	ProjectID string `json:"projectID" bson:"projectid"`
+
+	// "project", "view", "component", "link", "comment" or "member"
+	TargetKind string `json:"targetKind" bson:"targetkind"`
+
+	// id of the changed thing
+	TargetID string `json:"targetID" bson:"targetid"`
+
+	// ids of further things the change concerns: the view of a component,
+	// the components of a link, the commented component, link or view
+	Related []string `json:"related,omitempty" bson:"related,omitempty"`
+
+	// ids of the users mentioned by the change
+	Mentions []string `json:"mentions,omitempty" bson:"mentions,omitempty"`
+
+	// id of the user making the change, empty for imports and scans
+	Actor string `json:"actor,omitempty" bson:"actor,omitempty"`
+
+	// time of the change
+	Time time.Time `json:"time"`
+
+	// state before an update
+	Before interface{} `json:"before,omitempty" bson:"before,omitempty"`
+
+	// state after the change
+	After interface{} `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+// EventHandler is called with every emitted event
+type EventHandler func(Event) error
+
+var (
+	eventHandlersMu sync.RWMutex
+	eventHandlers   []EventHandler
+)
+
+// OnEvent registers h to be called synchronously with every emitted event
+func OnEvent(h EventHandler) {
+	eventHandlersMu.Lock()
+	defer eventHandlersMu.Unlock()
+	eventHandlers = append(eventHandlers, h)
+}
+
+// changeAttempts is how often emit tries to append an event to the change feed
+const changeAttempts = 3
+
+// emit appends the event to the change feed and passes it to the registered
+// handlers. Their errors are logged and don't fail the change which is
+// stored already, a change the feed couldn't record is logged with the event
+// so it can be added again.
+func emit(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	var err error
+	for i := 0; i < changeAttempts; i++ {
+		if err = recordChange(e); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		b, _ := json.Marshal(e)
+		log.Printf("[ERROR] change feed of project %s lost %s event of %s: %v: %s", e.ProjectID, e.Type, e.TargetID, err, b)
+	}
+
+	eventHandlersMu.RLock()
+	handlers := eventHandlers
+	eventHandlersMu.RUnlock()
+
+	for _, h := range handlers {
+		if err := h(e); err != nil {
+			log.Printf("[ERROR] handling %s event of %s: %v", e.Type, e.TargetID, err)
+		}
+	}
+}
@@ -1,24 +1,231 @@
 package data
 
 import (
-	"log"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashAndSalt hashes with salt
-func HashAndSalt(pwd []byte) string {
+// Password hashing algorithms
+const (
+	// HashBcrypt hashes passwords with bcrypt
+	HashBcrypt = "bcrypt"
+	// HashArgon2id hashes passwords with argon2id
+	HashArgon2id = "argon2id"
+)
+
+// ErrUnknownHash is returned for a stored password hash of an unknown format
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// HashPolicy tells how new passwords are hashed. Stored hashes with other
+// parameters still work and are rehashed on the next login.
+type HashPolicy struct {
+	// HashBcrypt or HashArgon2id
+	Algorithm string
+
+	// cost of bcrypt hashes
+	BcryptCost int
+
+	// passes over the memory of argon2id hashes
+	Argon2Time uint32
+
+	// memory of argon2id hashes in KiB
+	Argon2Memory uint32
+
+	// threads of argon2id hashes
+	Argon2Threads uint8
+
+	// length of argon2id salts and keys in bytes
+	Argon2SaltLength uint32
+	Argon2KeyLength  uint32
+}
+
+// Validate returns an error if passwords can't be hashed with the policy
+func (p HashPolicy) Validate() error {
+	switch p.Algorithm {
+	case HashBcrypt:
+		if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case HashArgon2id:
+		if p.Argon2Time == 0 || p.Argon2Memory < 8*uint32(p.Argon2Threads) || p.Argon2Threads == 0 {
+			return errors.New("argon2id needs a time and threads of at least 1 and 8 KiB of memory per thread")
+		}
+		if p.Argon2SaltLength < 8 || p.Argon2KeyLength < 16 {
+			return errors.New("argon2id needs salts of at least 8 and keys of at least 16 bytes")
+		}
+	default:
+		return fmt.Errorf("unknown password hash algorithm %q", p.Algorithm)
+	}
+	return nil
+}
+
+// Hashing is the policy of new password hashes, the parameters of argon2id
+// are the recommended ones of its RFC for servers with less memory
+var Hashing = HashPolicy{
+	Algorithm:        HashBcrypt,
+	BcryptCost:       12,
+	Argon2Time:       3,
+	Argon2Memory:     64 * 1024,
+	Argon2Threads:    2,
+	Argon2SaltLength: 16,
+	Argon2KeyLength:  32,
+}
+
+// HashAndSalt hashes the password with a random salt as the Hashing policy tells
+func HashAndSalt(pwd []byte) (string, error) {
+	if Hashing.Algorithm == HashArgon2id {
+		salt := make([]byte, Hashing.Argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey(pwd, salt, Hashing.Argon2Time, Hashing.Argon2Memory, Hashing.Argon2Threads, Hashing.Argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+			Hashing.Argon2Memory, Hashing.Argon2Time, Hashing.Argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
 
-	// Use GenerateFromPassword to hash & salt pwd.
-	// MinCost is just an integer constant provided by the bcrypt
-	// package along with DefaultCost & MaxCost.
-	// The cost can be any value you want provided it isn't lower
-	// than the MinCost (4)
-	hash, err := bcrypt.GenerateFromPassword(pwd, bcrypt.MinCost)
+	hash, err := bcrypt.GenerateFromPassword(pwd, Hashing.BcryptCost)
 	if err != nil {
-		log.Println(err)
+		return "", err
 	}
-	// GenerateFromPassword returns a byte slice so we need to
-	// convert the bytes to a string and return it
-	return string(hash)
+	return string(hash), nil
+}
+
+// argon2Hash is a decoded argon2id hash
+type argon2Hash struct {
+	version int
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func parseArgon2Hash(hash string) (argon2Hash, error) {
+	var h argon2Hash
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != HashArgon2id {
+		return h, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &h.version); err != nil {
+		return h, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil {
+		return h, ErrUnknownHash
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return h, ErrUnknownHash
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return h, ErrUnknownHash
+	}
+	return h, nil
+}
+
+// ComparePassword reports whether the password matches the stored hash of
+// bcrypt or argon2id
+func ComparePassword(hash string, pwd []byte) bool {
+	if strings.HasPrefix(hash, "$"+HashArgon2id+"$") {
+		h, err := parseArgon2Hash(hash)
+		if err != nil || h.version != argon2.Version {
+			return false
+		}
+		key := argon2.IDKey(pwd, h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+		return subtle.ConstantTimeCompare(key, h.key) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), pwd) == nil
+}
+
+// NeedsRehash reports whether the stored hash was made with another
+// algorithm or other parameters than the Hashing policy tells
+func NeedsRehash(hash string) bool {
+	if Hashing.Algorithm == HashArgon2id {
+		h, err := parseArgon2Hash(hash)
+		return err != nil || h.version != argon2.Version || h.memory != Hashing.Argon2Memory ||
+			h.time != Hashing.Argon2Time || h.threads != Hashing.Argon2Threads ||
+			uint32(len(h.salt)) != Hashing.Argon2SaltLength || uint32(len(h.key)) != Hashing.Argon2KeyLength
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != Hashing.BcryptCost
+}
+
+// PasswordRules are the rules of new passwords
+type PasswordRules struct {
+	// least number of characters
+	MinLength int
+
+	// most number of bytes, 0 for the limit of the Hashing algorithm
+	MaxLength int
+
+	// least number of lower case letters, upper case letters, digits and
+	// other characters which have to be in the password
+	MinClasses int
+}
+
+// longest passwords in bytes by hashing algorithm, bcrypt only hashes the
+// first 72 bytes, argon2id hashes any length but long ones take long
+var maxPasswordLength = map[string]int{
+	HashBcrypt:   72,
+	HashArgon2id: 1024,
+}
+
+// Passwords are the rules new passwords are validated with
+var Passwords = PasswordRules{MinLength: 10, MinClasses: 3}
+
+// maxLength returns MaxLength or the limit of the Hashing algorithm
+func (r PasswordRules) maxLength() int {
+	if r.MaxLength > 0 {
+		return r.MaxLength
+	}
+	return maxPasswordLength[Hashing.Algorithm]
+}
+
+// Check returns what the password is missing to follow the rules, or nil
+func (r PasswordRules) Check(pwd string) error {
+	if utf8.RuneCountInString(pwd) < r.MinLength {
+		return fmt.Errorf("password must have at least %d characters", r.MinLength)
+	}
+	if max := r.maxLength(); len(pwd) > max {
+		return fmt.Errorf("password must have at most %d bytes", max)
+	}
+
+	var lower, upper, digit, other bool
+	for _, c := range pwd {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	classes := 0
+	for _, has := range []bool{lower, upper, digit, other} {
+		if has {
+			classes++
+		}
+	}
+	if classes < r.MinClasses {
+		return fmt.Errorf("password must have %d of lower case letters, upper case letters, digits and other characters", r.MinClasses)
+	}
+	return nil
+}
+
+// validatePassword fails for passwords not following the Passwords rules
+func validatePassword(fl validator.FieldLevel) bool {
+	return Passwords.Check(fl.Field().String()) == nil
 }
@@ -0,0 +1,169 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	db "traceability/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// LoginStateTTL is how long a login at a provider may take
+var LoginStateTTL = 10 * time.Minute
+
+var (
+	// ErrEmailNotVerified is returned when an unverified email of a provider would link or create a user
+	ErrEmailNotVerified = errors.New("the provider hasn't verified the email")
+	// ErrUnknownLoginState is returned when a callback has an unknown, used or expired state
+	ErrUnknownLoginState = errors.New("login expired or unknown")
+	// ErrAccountNotVerified is returned when a provider account would be linked
+	// to a user who never verified the email, whoever signed up with it could
+	// keep using the password
+	ErrAccountNotVerified = errors.New("a user with the email exists but hasn't verified it, log in with the password and verify the email first")
+)
+
+// UserIdentity links a user to their account at a login provider
+// swagger:model
+type UserIdentity struct {
+	// name of the provider
+	Provider string `json:"provider"`
+
+	// id of the user at the provider
+	Subject string `json:"subject"`
+
+	// id of the user
+	UserID string `json:"userID" bson:"userid"`
+
+	// email told by the provider when linking
+	Email string `json:"email"`
+
+	// time the identity was linked
+	CreatedAt time.Time `json:"createdAt" bson:"createdat"`
+}
+
+// LoginState is a login in progress at a provider, it is taken by the callback
+type LoginState struct {
+	// random state sent to the provider
+	State string
+
+	// name of the provider
+	Provider string
+
+	// PKCE code verifier of the login
+	Verifier string
+
+	// where the user is sent after the login, empty to answer with json
+	Redirect string `bson:"redirect,omitempty"`
+
+	// time the login can't be completed anymore
+	ExpiresAt time.Time `bson:"expiresat"`
+}
+
+// AddLoginState stores a login in progress
+func AddLoginState(s LoginState) error {
+	s.ExpiresAt = time.Now().Add(LoginStateTTL)
+	collection := db.DB.Collection(db.LoginStateCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), s)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return nil
+}
+
+// TakeLoginState removes and returns the login of the provider with the
+// state, every state is used once
+func TakeLoginState(provider string, state string) (LoginState, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.LoginStateCollectionName)
+
+	var result LoginState
+	err := collection.FindOneAndDelete(ctx, bson.M{"state": state, "provider": provider}).Decode(&result)
+	if err == mongo.ErrNoDocuments || (err == nil && time.Now().After(result.ExpiresAt)) {
+		return result, ErrUnknownLoginState
+	}
+	return result, err
+}
+
+// FindUserIdentity returns the identity of the provider or mongo.ErrNoDocuments
+func FindUserIdentity(provider string, subject string) (UserIdentity, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.IdentityCollectionName)
+
+	var result UserIdentity
+	err := collection.FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&result)
+	return result, err
+}
+
+// AddUserIdentity links the user to the account at the provider
+func AddUserIdentity(i UserIdentity) error {
+	i.CreatedAt = time.Now()
+	collection := db.DB.Collection(db.IdentityCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), i)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return nil
+}
+
+// LoginWithIdentity returns the user of an account at a provider. An
+// unlinked account is linked to the user with its email if that user verified
+// it, or a new user is created for it; both need an email verified by the
+// provider.
+func LoginWithIdentity(provider string, subject string, email string, verified bool, name string) (User, error) {
+	identity, err := FindUserIdentity(provider, subject)
+	if err == nil {
+		return FindUserByID(identity.UserID)
+	}
+	if err != mongo.ErrNoDocuments {
+		return User{}, err
+	}
+	if !verified {
+		return User{}, ErrEmailNotVerified
+	}
+
+	user, err := FindUserByEmail(email)
+	if err == mongo.ErrNoDocuments {
+		if name == "" {
+			name = strings.Split(email, "@")[0]
+		}
+		token, err := newToken()
+		if err != nil {
+			return user, err
+		}
+		// just in time provisioning, the random password is never told so
+		// the user logs in at the provider until they reset it
+		added, err := AddUser(User{Name: name, Email: email, Password: token})
+		if err != nil {
+			return user, err
+		}
+		user = *added
+	} else if err != nil {
+		return user, err
+	} else if user.Unverified {
+		return user, ErrAccountNotVerified
+	}
+
+	err = AddUserIdentity(UserIdentity{Provider: provider, Subject: subject, UserID: user.ID, Email: email})
+	if err != nil {
+		return user, err
+	}
+	// the provider verified the email
+	if _, err := MarkEmailVerified(user.ID, email); err != nil {
+		return user, err
+	}
+	user.Unverified = false
+	return user, nil
+}
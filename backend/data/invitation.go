@@ -0,0 +1,316 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	db "traceability/database"
+
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InvitationStatus is the state of an invitation
+type InvitationStatus string
+
+const (
+	// InvitationPending can be accepted until it expires
+	InvitationPending InvitationStatus = "pending"
+	// InvitationAccepted was accepted by a user who joined the project
+	InvitationAccepted InvitationStatus = "accepted"
+	// InvitationRevoked was taken back before it was accepted
+	InvitationRevoked InvitationStatus = "revoked"
+)
+
+// InvitationTTL is how long invitations are valid if they don't set an expiry
+var InvitationTTL = 7 * 24 * time.Hour
+
+// InvitationMaxTTL is the longest an invitation is valid, later expiries are cut to it
+var InvitationMaxTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrUnknownInvitation is returned when an invitation is not found
+	ErrUnknownInvitation = errors.New("invitation not found")
+	// ErrInvitationExpired is returned when an expired invitation is accepted
+	ErrInvitationExpired = errors.New("invitation has expired")
+	// ErrInvitationClosed is returned when an accepted or revoked invitation is used
+	ErrInvitationClosed = errors.New("invitation was accepted or revoked already")
+	// ErrInvitationEmail is returned when a user accepts an invitation sent to another email
+	ErrInvitationEmail = errors.New("invitation was sent to another email")
+	// ErrInvitationUnverified is returned when a user accepts an invitation before verifying their email
+	ErrInvitationUnverified = errors.New("verify your email before accepting the invitation")
+)
+
+// Invitation invites someone by email to join a project with a role, the
+// invited person may not have an account yet
+// swagger:model
+type Invitation struct {
+	// the id of the invitation
+	//
+	// required: false
+	ID string `json:"id"`
+
+	// belonging project's id
+	//
+	// required: false
+	ProjectID string `json:"projectID" bson:"projectid"`
+
+	// email the invitation is sent to
+	//
+	// required: true
+	Email string `json:"email" validate:"required,email"`
+
+	// name of a built-in or custom role of the project given on joining
+	//
+	// required: true
+	Role string `json:"role" validate:"required"`
+
+	// token of the accept link, only returned when the invitation is created
+	//
+	// required: false
+	Token string `json:"token,omitempty" bson:"-"`
+
+	// sha256 of the token, the token itself isn't stored
+	TokenHash string `json:"-" bson:"tokenhash"`
+
+	// id of the inviting user
+	//
+	// required: false
+	InvitedBy string `json:"invitedBy" bson:"invitedby"`
+
+	// "pending", "accepted" or "revoked"
+	//
+	// required: false
+	Status InvitationStatus `json:"status"`
+
+	// set when the invitation email was sent
+	//
+	// required: false
+	Sent bool `json:"sent"`
+
+	// time the invitation was created
+	//
+	// required: false
+	CreatedAt time.Time `json:"createdAt" bson:"createdat"`
+
+	// time the invitation can't be accepted anymore, 7 days after creation if
+	// empty and at most 30 days after it
+	//
+	// required: false
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresat"`
+
+	// id of the user who accepted the invitation
+	//
+	// required: false
+	AcceptedBy string `json:"acceptedBy,omitempty" bson:"acceptedby,omitempty"`
+
+	// time the invitation was accepted or revoked
+	//
+	// required: false
+	ClosedAt *time.Time `json:"closedAt,omitempty" bson:"closedat,omitempty"`
+}
+
+// Expired reports whether a pending invitation can't be accepted anymore
+func (i Invitation) Expired() bool {
+	return i.Status == InvitationPending && time.Now().After(i.ExpiresAt)
+}
+
+// hashToken returns the stored form of an invitation token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken returns a random url safe token
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// AddInvitation creates a pending invitation with a new token. Only owners
+// invite owners, and members of the project can't be invited.
+func AddInvitation(inv Invitation, actorRole Role) (Invitation, error) {
+	project, err := FindProjectByID(inv.ProjectID)
+	if err != nil {
+		return inv, err
+	}
+	if _, err := FindRole(inv.ProjectID, inv.Role); err != nil {
+		return inv, err
+	}
+	if inv.Role == RoleOwner && actorRole.Name != RoleOwner {
+		return inv, ErrOwnerRequired
+	}
+	inv.Email = strings.ToLower(strings.TrimSpace(inv.Email))
+	if u, err := FindUserByEmail(inv.Email); err == nil && project.HasMember(u.ID) {
+		return inv, ErrMemberExists
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return inv, err
+	}
+	inv.ID = guuid.New().String()
+	inv.Token = token
+	inv.TokenHash = hashToken(token)
+	inv.Status = InvitationPending
+	inv.Sent = false
+	inv.CreatedAt = time.Now()
+	if inv.ExpiresAt.IsZero() {
+		inv.ExpiresAt = inv.CreatedAt.Add(InvitationTTL)
+	}
+	if max := inv.CreatedAt.Add(InvitationMaxTTL); inv.ExpiresAt.After(max) {
+		inv.ExpiresAt = max
+	}
+	inv.AcceptedBy, inv.ClosedAt = "", nil
+
+	collection := db.DB.Collection(db.InvitationCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), inv)
+	if err != nil {
+		return inv, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return inv, nil
+}
+
+// FindInvitationsOfProject returns the invitations of the project, newest first
+func FindInvitationsOfProject(projectID string) ([]Invitation, error) {
+	opts := options.Find().SetSort(bson.M{"createdat": -1})
+	cur, err := db.DB.Collection(db.InvitationCollectionName).Find(context.TODO(), bson.M{"projectid": projectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []Invitation{}
+	for cur.Next(context.TODO()) {
+		var elem Invitation
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
+func findInvitation(filter bson.M) (Invitation, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.InvitationCollectionName)
+
+	var result Invitation
+	err := collection.FindOne(ctx, filter).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, ErrUnknownInvitation
+	}
+	return result, err
+}
+
+// FindInvitationByID returns the invitation of the project or ErrUnknownInvitation
+func FindInvitationByID(projectID string, id string) (Invitation, error) {
+	return findInvitation(bson.M{"projectid": projectID, "id": id})
+}
+
+// FindInvitationByToken returns the invitation with the token or ErrUnknownInvitation
+func FindInvitationByToken(token string) (Invitation, error) {
+	return findInvitation(bson.M{"tokenhash": hashToken(token)})
+}
+
+// UpdateInvitation replaces the invitation with new one
+func UpdateInvitation(inv Invitation) error {
+	collection := db.DB.Collection(db.InvitationCollectionName)
+	query := bson.M{"projectid": inv.ProjectID, "id": inv.ID}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, inv)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Replaced a single document:", replaceResult)
+	if replaceResult.MatchedCount == 0 {
+		return ErrUnknownInvitation
+	}
+	return nil
+}
+
+// RevokeInvitation takes back a pending invitation of the project
+func RevokeInvitation(projectID string, id string) (Invitation, error) {
+	inv, err := FindInvitationByID(projectID, id)
+	if err != nil {
+		return inv, err
+	}
+	if inv.Status != InvitationPending {
+		return inv, ErrInvitationClosed
+	}
+	now := time.Now()
+	inv.Status, inv.ClosedAt = InvitationRevoked, &now
+	return inv, UpdateInvitation(inv)
+}
+
+// AcceptInvitation adds the user to the project of the invitation with its
+// role. Only the user with the verified email the invitation was sent to can
+// accept it, and only once. Users who are members already keep their role.
+func AcceptInvitation(token string, userID string) (Invitation, error) {
+	inv, err := FindInvitationByToken(token)
+	if err != nil {
+		return inv, err
+	}
+	if inv.Status != InvitationPending {
+		return inv, ErrInvitationClosed
+	}
+	if inv.Expired() {
+		return inv, ErrInvitationExpired
+	}
+	user, err := FindUserByID(userID)
+	if err != nil {
+		return inv, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(user.Email), inv.Email) {
+		return inv, ErrInvitationEmail
+	}
+	if user.Unverified {
+		return inv, ErrInvitationUnverified
+	}
+
+	// claiming the pending invitation first lets only one request accept it
+	now := time.Now()
+	collection := db.DB.Collection(db.InvitationCollectionName)
+	filter := bson.M{"id": inv.ID, "status": InvitationPending, "expiresat": bson.M{"$gt": now}}
+	update := bson.M{"$set": bson.M{"status": InvitationAccepted, "acceptedby": userID, "closedat": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = collection.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&inv)
+	if err == mongo.ErrNoDocuments {
+		return inv, ErrInvitationClosed
+	}
+	if err != nil {
+		return inv, err
+	}
+
+	_, err = changeMembers(inv.ProjectID, userID, func(project *Project) error {
+		if !project.HasMember(userID) {
+			project.Members = append(project.Members, ProjectMember{ID: userID, Role: inv.Role})
+		}
+		return nil
+	})
+	if err != nil {
+		// the invitation stays usable if the user couldn't join
+		reopen := bson.M{"$set": bson.M{"status": InvitationPending}, "$unset": bson.M{"acceptedby": "", "closedat": ""}}
+		if _, rerr := collection.UpdateOne(context.TODO(), bson.M{"id": inv.ID, "acceptedby": userID}, reopen); rerr != nil {
+			return inv, rerr
+		}
+		return inv, err
+	}
+	return inv, nil
+}
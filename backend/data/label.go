@@ -0,0 +1,373 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	db "traceability/database"
+
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrUnknownLabel is returned when a label id or name is not defined in the project
+	ErrUnknownLabel = errors.New("label is not defined in the project")
+	// ErrLabelExists is returned when a label with the same name is defined already
+	ErrLabelExists = errors.New("label is defined already")
+)
+
+// Label is a colour-coded tag of a project attachable to components, links and views
+// swagger:model
+type Label struct {
+	// the id of the label
+	//
+	// required: false
+	ID string `json:"id"`
+
+	// belonging project's id
+	//
+	// required: false
+	ProjectID string `json:"projectID" bson:"projectid"`
+
+	// name of the label, unique in the project
+	//
+	// required: true
+	// max length: 50
+	Name string `json:"name" validate:"required,max=50"`
+
+	// colour of the label as #rrggbb
+	//
+	// required: false
+	Color string `json:"color,omitempty" bson:"color,omitempty" validate:"omitempty,hexcolor"`
+
+	// description
+	//
+	// required: false
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+}
+
+// LabelTargets names the components, links and views a bulk label operation applies to
+// swagger:model
+type LabelTargets struct {
+	// ids of components
+	//
+	// required: false
+	Components []string `json:"components,omitempty"`
+
+	// ids of links
+	//
+	// required: false
+	Links []string `json:"links,omitempty"`
+
+	// ids of views
+	//
+	// required: false
+	Views []string `json:"views,omitempty"`
+}
+
+// AddLabel adds a new label to the project
+func AddLabel(l Label) (Label, error) {
+	if _, err := FindLabelByName(l.ProjectID, l.Name); err != ErrUnknownLabel {
+		if err == nil {
+			err = ErrLabelExists
+		}
+		return l, err
+	}
+	l.ID = guuid.New().String()
+
+	collection := db.DB.Collection(db.LabelCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), l)
+	if err != nil {
+		return l, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return l, nil
+}
+
+// FindLabelsOfProject returns the labels of the project
+func FindLabelsOfProject(projectID string) ([]Label, error) {
+	collection := db.DB.Collection(db.LabelCollectionName)
+	cur, err := collection.Find(context.TODO(), bson.M{"projectid": projectID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []Label{}
+	for cur.Next(context.TODO()) {
+		var elem Label
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
+func findLabel(filter bson.M) (Label, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.LabelCollectionName)
+
+	var result Label
+	err := collection.FindOne(ctx, filter).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, ErrUnknownLabel
+	}
+	return result, err
+}
+
+// FindLabelByID returns the label of the project or ErrUnknownLabel
+func FindLabelByID(projectID string, id string) (Label, error) {
+	return findLabel(bson.M{"projectid": projectID, "id": id})
+}
+
+// FindLabelByName returns the label of the project with the name or ErrUnknownLabel
+func FindLabelByName(projectID string, name string) (Label, error) {
+	return findLabel(bson.M{"projectid": projectID, "name": name})
+}
+
+// UpdateLabel replaces the label with new one, the name stays unique in the project
+func UpdateLabel(l Label) error {
+	existing, err := FindLabelByName(l.ProjectID, l.Name)
+	if err == nil && existing.ID != l.ID {
+		return ErrLabelExists
+	}
+	if err != nil && err != ErrUnknownLabel {
+		return err
+	}
+
+	collection := db.DB.Collection(db.LabelCollectionName)
+	query := bson.M{"projectid": l.ProjectID, "id": l.ID}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, l)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Replaced a single document:", replaceResult)
+	if replaceResult.MatchedCount == 0 {
+		return ErrUnknownLabel
+	}
+	return nil
+}
+
+// DeleteLabel removes the label from the project and from everything it is
+// attached to, actor names the user deleting it
+func DeleteLabel(projectID string, id string, actor string) error {
+	collection := db.DB.Collection(db.LabelCollectionName)
+	deleteResult, err := collection.DeleteOne(context.TODO(), bson.M{"projectid": projectID, "id": id})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Deleted a single document:", deleteResult)
+	if deleteResult.DeletedCount == 0 {
+		return ErrUnknownLabel
+	}
+
+	update := bson.M{"$pull": bson.M{"labels": id}}
+	for _, name := range labelledCollections {
+		before, err := findLabelled(name, bson.M{"projectid": projectID, "labels": id})
+		if err != nil {
+			return err
+		}
+		if len(before) == 0 {
+			continue
+		}
+		if _, err := db.DB.Collection(name).UpdateMany(context.TODO(), bson.M{"projectid": projectID, "labels": id}, update); err != nil {
+			return err
+		}
+		if err := emitLabelled(name, projectID, before, actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findLabelled returns the documents of the labelled collection matching the filter by id
+func findLabelled(name string, filter bson.M) (map[string]interface{}, error) {
+	cur, err := db.DB.Collection(name).Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := map[string]interface{}{}
+	for cur.Next(context.TODO()) {
+		switch name {
+		case db.ArchViewComponentCollectionName:
+			var elem ArchViewComponent
+			if err := cur.Decode(&elem); err != nil {
+				return nil, err
+			}
+			result[elem.ID] = elem
+		case db.LinkCollectionName:
+			var elem Link
+			if err := cur.Decode(&elem); err != nil {
+				return nil, err
+			}
+			result[elem.ID] = elem
+		case db.ArchViewCollectionName:
+			var elem ArchView
+			if err := cur.Decode(&elem); err != nil {
+				return nil, err
+			}
+			result[elem.ID] = elem
+		}
+	}
+	return result, cur.Err()
+}
+
+// emitLabelled emits an update event for every document of before whose labels changed
+func emitLabelled(name string, projectID string, before map[string]interface{}, actor string) error {
+	ids := make([]string, 0, len(before))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	after, err := findLabelled(name, bson.M{"projectid": projectID, "id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+	for id, a := range after {
+		if reflect.DeepEqual(before[id], a) {
+			continue
+		}
+		switch a := a.(type) {
+		case ArchViewComponent:
+			emit(Event{Type: EventComponentUpdated, ProjectID: a.ProjectID, TargetKind: "component", TargetID: a.ID, Related: []string{a.ViewID}, Actor: actor, Before: before[id], After: a})
+		case Link:
+			emit(Event{Type: EventLinkUpdated, ProjectID: a.ProjectID, TargetKind: "link", TargetID: a.ID, Related: []string{a.From, a.To}, Actor: actor, Before: before[id], After: a})
+		case ArchView:
+			emit(Event{Type: EventViewUpdated, ProjectID: a.ProjectID, TargetKind: "view", TargetID: a.ID, Actor: actor, Before: before[id], After: a})
+		}
+	}
+	return nil
+}
+
+// labelledCollections are the collections of the things labels can be attached to
+var labelledCollections = []string{
+	db.ArchViewComponentCollectionName,
+	db.LinkCollectionName,
+	db.ArchViewCollectionName,
+}
+
+// CheckLabels returns ErrUnknownLabel if one of the ids is not a label of the project
+func CheckLabels(projectID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	collection := db.DB.Collection(db.LabelCollectionName)
+	unique := map[string]bool{}
+	for _, id := range ids {
+		unique[id] = true
+	}
+	n, err := collection.CountDocuments(context.TODO(), bson.M{"projectid": projectID, "id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+	if int(n) != len(unique) {
+		return ErrUnknownLabel
+	}
+	return nil
+}
+
+// UpdateLabels attaches the labels add to and detaches the labels remove from
+// the targets of the project in one bulk operation per collection, an update
+// event is emitted for every changed target. actor names the user changing them.
+func UpdateLabels(projectID string, add []string, remove []string, targets LabelTargets, actor string) error {
+	if err := CheckLabels(projectID, append(append([]string{}, add...), remove...)); err != nil {
+		return err
+	}
+
+	byCollection := map[string][]string{
+		db.ArchViewComponentCollectionName: targets.Components,
+		db.LinkCollectionName:              targets.Links,
+		db.ArchViewCollectionName:          targets.Views,
+	}
+	for _, name := range labelledCollections {
+		ids := byCollection[name]
+		if len(ids) == 0 {
+			continue
+		}
+		collection := db.DB.Collection(name)
+		filter := bson.M{"projectid": projectID, "id": bson.M{"$in": ids}}
+		before, err := findLabelled(name, filter)
+		if err != nil {
+			return err
+		}
+		// a document can't be the target of $addToSet and $pull in one update
+		if len(add) > 0 {
+			update := bson.M{"$addToSet": bson.M{"labels": bson.M{"$each": add}}}
+			if _, err := collection.UpdateMany(context.TODO(), filter, update); err != nil {
+				return err
+			}
+		}
+		if len(remove) > 0 {
+			update := bson.M{"$pull": bson.M{"labels": bson.M{"$in": remove}}}
+			updateResult, err := collection.UpdateMany(context.TODO(), filter, update)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Updated documents:", updateResult.ModifiedCount)
+		}
+		if err := emitLabelled(name, projectID, before, actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindArchViewsOfProjectWithLabels returns the views of the project matching the label filter
+func FindArchViewsOfProjectWithLabels(projectID string, l LabelFilter) ([]*ArchView, error) {
+	filter := bson.M{"projectid": projectID}
+	if len(l) > 0 {
+		filter["$and"] = []interface{}{bson.M(l)}
+	}
+	cur, err := db.DB.Collection(db.ArchViewCollectionName).Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []*ArchView{}
+	for cur.Next(context.TODO()) {
+		var elem ArchView
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, &elem)
+	}
+
+	return result, cur.Err()
+}
+
+// FindProjectLinksWithLabels returns the links of the project matching the label filter
+func FindProjectLinksWithLabels(projectID string, l LabelFilter) (Links, error) {
+	filter := bson.M{"projectid": projectID}
+	if len(l) > 0 {
+		filter["$and"] = []interface{}{bson.M(l)}
+	}
+	cur, err := db.DB.Collection(db.LinkCollectionName).Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := Links{}
+	for cur.Next(context.TODO()) {
+		var elem Link
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, &elem)
+	}
+
+	return result, cur.Err()
+}
@@ -0,0 +1,217 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// LabelFilter selects components, links or views by their labels
+type LabelFilter bson.M
+
+// labelToken is a token of a label expression, names have kind 'n'
+type labelToken struct {
+	kind  rune
+	value string
+}
+
+// tokenizeLabelExpression splits expr into names, parentheses and the
+// operators &, | and !. The words AND, OR and NOT are operators as well,
+// names with spaces or operator characters are written in double quotes.
+func tokenizeLabelExpression(expr string) ([]labelToken, error) {
+	var tokens []labelToken
+	r := []rune(expr)
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("()&|!", c):
+			tokens = append(tokens, labelToken{kind: c})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(r) && r[end] != '"' {
+				end++
+			}
+			if end == len(r) {
+				return nil, fmt.Errorf("unterminated quote in label expression")
+			}
+			tokens = append(tokens, labelToken{kind: 'n', value: string(r[i+1 : end])})
+			i = end + 1
+		default:
+			end := i
+			for end < len(r) && !unicode.IsSpace(r[end]) && !strings.ContainsRune(`()&|!"`, r[end]) {
+				end++
+			}
+			word := string(r[i:end])
+			switch strings.ToUpper(word) {
+			case "AND":
+				tokens = append(tokens, labelToken{kind: '&'})
+			case "OR":
+				tokens = append(tokens, labelToken{kind: '|'})
+			case "NOT":
+				tokens = append(tokens, labelToken{kind: '!'})
+			default:
+				tokens = append(tokens, labelToken{kind: 'n', value: word})
+			}
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+func (t labelToken) String() string {
+	if t.kind == 'n' {
+		return `"` + t.value + `"`
+	}
+	return string(t.kind)
+}
+
+// labelParser turns a label expression into a mongo filter on the labels
+// field, resolving label names with resolve
+type labelParser struct {
+	tokens  []labelToken
+	pos     int
+	resolve func(name string) (string, error)
+}
+
+func (p *labelParser) peek() rune {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].kind
+	}
+	return 0
+}
+
+// or := and { "|" and }
+func (p *labelParser) or() (bson.M, error) {
+	terms := []interface{}{}
+	for {
+		term, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	if len(terms) == 1 {
+		return terms[0].(bson.M), nil
+	}
+	return bson.M{"$or": terms}, nil
+}
+
+// and := not { "&" not }
+func (p *labelParser) and() (bson.M, error) {
+	terms := []interface{}{}
+	for {
+		term, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if p.peek() != '&' {
+			break
+		}
+		p.pos++
+	}
+	if len(terms) == 1 {
+		return terms[0].(bson.M), nil
+	}
+	return bson.M{"$and": terms}, nil
+}
+
+// not := "!" not | "(" or ")" | name
+func (p *labelParser) not() (bson.M, error) {
+	switch p.peek() {
+	case '!':
+		p.pos++
+		term, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": []interface{}{term}}, nil
+	case '(':
+		p.pos++
+		term, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ) in label expression")
+		}
+		p.pos++
+		return term, nil
+	case 'n':
+		id, err := p.resolve(p.tokens[p.pos].value)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++
+		return bson.M{"labels": id}, nil
+	case 0:
+		return nil, fmt.Errorf("unexpected end of label expression")
+	default:
+		return nil, fmt.Errorf("unexpected %s in label expression", p.tokens[p.pos])
+	}
+}
+
+// ParseLabelFilter parses a label expression like
+// `backend AND (release-1 OR NOT legacy)` into a filter. Labels are given
+// by name, an empty expression matches everything.
+func ParseLabelFilter(projectID string, expr string) (LabelFilter, error) {
+	tokens, err := tokenizeLabelExpression(expr)
+	if err != nil || len(tokens) == 0 {
+		return LabelFilter{}, err
+	}
+
+	labels, err := FindLabelsOfProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]string{}
+	for _, l := range labels {
+		ids[l.Name] = l.ID
+	}
+
+	filter, err := parseLabelTokens(tokens, func(name string) (string, error) {
+		id, ok := ids[name]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownLabel, name)
+		}
+		return id, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return LabelFilter(filter), nil
+}
+
+// parseLabelTokens parses all of tokens into a filter on the labels field
+func parseLabelTokens(tokens []labelToken, resolve func(name string) (string, error)) (bson.M, error) {
+	p := &labelParser{tokens: tokens, resolve: resolve}
+	filter, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(tokens) {
+		return nil, fmt.Errorf("unexpected %s in label expression", tokens[p.pos])
+	}
+	return filter, nil
+}
+
+// With returns the filter f restricted to the labels of l
+func (f ComponentFilter) With(l LabelFilter) ComponentFilter {
+	if len(l) == 0 {
+		return f
+	}
+	result := ComponentFilter{"$and": []interface{}{bson.M(l)}}
+	for k, v := range f {
+		result[k] = v
+	}
+	return result
+}
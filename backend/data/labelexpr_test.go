@@ -0,0 +1,114 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var testLabelIDs = map[string]string{
+	"backend":      "l1",
+	"release-1":    "l2",
+	"legacy":       "l3",
+	"needs review": "l4",
+	"a&b":          "l5",
+}
+
+func parseTestLabelExpression(expr string) (bson.M, error) {
+	tokens, err := tokenizeLabelExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	return parseLabelTokens(tokens, func(name string) (string, error) {
+		id, ok := testLabelIDs[name]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownLabel, name)
+		}
+		return id, nil
+	})
+}
+
+func TestTokenizeLabelExpression(t *testing.T) {
+	tokens, err := tokenizeLabelExpression(` a&&"b c"!(d) or NOT e And|f`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []labelToken{
+		{kind: 'n', value: "a"}, {kind: '&'}, {kind: '&'}, {kind: 'n', value: "b c"}, {kind: '!'},
+		{kind: '('}, {kind: 'n', value: "d"}, {kind: ')'}, {kind: '|'}, {kind: '!'},
+		{kind: 'n', value: "e"}, {kind: '&'}, {kind: '|'}, {kind: 'n', value: "f"},
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("tokens = %v, want %v", tokens, want)
+	}
+
+	tokens, err = tokenizeLabelExpression(" \t ")
+	if err != nil || len(tokens) != 0 {
+		t.Errorf("tokens of a blank expression = %v, %v, want none", tokens, err)
+	}
+	if _, err := tokenizeLabelExpression(`backend AND "needs review`); err == nil {
+		t.Error("an unterminated quote was tokenized")
+	}
+}
+
+func TestParseLabelTokens(t *testing.T) {
+	label := func(id string) bson.M { return bson.M{"labels": id} }
+	tests := []struct {
+		expr string
+		want bson.M
+	}{
+		{"backend", label("l1")},
+		{"(backend)", label("l1")},
+		{"backend AND release-1", bson.M{"$and": []interface{}{label("l1"), label("l2")}}},
+		{"backend & release-1 | legacy", bson.M{"$or": []interface{}{
+			bson.M{"$and": []interface{}{label("l1"), label("l2")}},
+			label("l3"),
+		}}},
+		{"backend | release-1 & legacy", bson.M{"$or": []interface{}{
+			label("l1"),
+			bson.M{"$and": []interface{}{label("l2"), label("l3")}},
+		}}},
+		{"backend AND (release-1 OR NOT legacy)", bson.M{"$and": []interface{}{
+			label("l1"),
+			bson.M{"$or": []interface{}{label("l2"), bson.M{"$nor": []interface{}{label("l3")}}}},
+		}}},
+		{"!!legacy", bson.M{"$nor": []interface{}{bson.M{"$nor": []interface{}{label("l3")}}}}},
+		{`"needs review" and not "a&b"`, bson.M{"$and": []interface{}{
+			label("l4"),
+			bson.M{"$nor": []interface{}{label("l5")}},
+		}}},
+	}
+	for _, tt := range tests {
+		got, err := parseTestLabelExpression(tt.expr)
+		if err != nil {
+			t.Errorf("%q: %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelTokensErrors(t *testing.T) {
+	for _, expr := range []string{
+		"backend AND",
+		"(backend",
+		"backend)",
+		"& backend",
+		"backend legacy",
+		"backend(legacy)",
+		"NOT",
+		"()",
+	} {
+		if got, err := parseTestLabelExpression(expr); err == nil {
+			t.Errorf("%q = %v, want an error", expr, got)
+		}
+	}
+	if _, err := parseTestLabelExpression("backend OR frontend"); !errors.Is(err, ErrUnknownLabel) {
+		t.Errorf("err = %v, want ErrUnknownLabel", err)
+	}
+}
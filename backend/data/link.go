@@ -2,16 +2,21 @@ package data
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"regexp"
 	"time"
 	db "traceability/database"
 
 	guuid "github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrLinkComponent is returned when a linked component is not a component of the project of the link
+var ErrLinkComponent = errors.New("linked component not found in the project")
+
 // Links is list of the Links
 type Links []*Link
 
@@ -47,20 +52,51 @@ type Link struct {
 	//
 	// required: false
 	InView bool `json:"inView"`
+
+	// ExternalID is the identifier of the link in the tool it was imported from
+	//
+	// required: false
+	ExternalID string `json:"externalID,omitempty" bson:"externalid,omitempty"`
+
+	// Labels are the ids of the labels attached
+	//
+	// required: false
+	Labels []string `json:"labels,omitempty" bson:"labels,omitempty"`
+
+	// Actor is the id of the user making the change, it is not stored and
+	// only names the actor of the emitted event
+	Actor string `json:"-" bson:"-"`
 }
 
 // FindAllProjectLinks returns all projects
 func FindAllProjectLinks(projectID string) (Links, error) {
-	var result Links
+	return findLinks(bson.M{"projectid": projectID})
+}
 
-	collection := db.DB.Collection(db.LinkCollectionName)
-	cur, err := collection.Find(context.TODO(), bson.D{{}})
+// FindLinksOfUserProjects returns the links of the projects the user is a member of
+func FindLinksOfUserProjects(userID string) (Links, error) {
+	projectIDs, err := findUserProjectIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	return findLinks(bson.M{"projectid": bson.M{"$in": projectIDs}})
+}
 
-	defer cur.Close(context.TODO())
+// FindProjectLinksWithExternalIDPrefix returns the links of the project
+// whose external id starts with the prefix, e.g. the links of a scan
+func FindProjectLinksWithExternalIDPrefix(projectID string, prefix string) (Links, error) {
+	return findLinks(bson.M{"projectid": projectID, "externalid": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}})
+}
+
+func findLinks(filter bson.M) (Links, error) {
+	result := Links{}
 
+	collection := db.DB.Collection(db.LinkCollectionName)
+	cur, err := collection.Find(context.TODO(), filter)
 	if err != nil {
 		return result, err
 	}
+	defer cur.Close(context.TODO())
 
 	for cur.Next(context.TODO()) {
 		var elem Link
@@ -78,52 +114,75 @@ func FindAllProjectLinks(projectID string) (Links, error) {
 	return result, nil
 }
 
-// FindAllLinks returns all projects
-func FindAllLinks() Links {
-	var result Links
-
+// AddLink adds a new link to the database, both components have to be
+// components of the project of the link or ErrLinkComponent is returned
+func AddLink(l Link) (Link, error) {
 	collection := db.DB.Collection(db.LinkCollectionName)
-	cur, err := collection.Find(context.TODO(), bson.D{{}})
-
-	defer cur.Close(context.TODO())
 
+	l.ID = guuid.New().String()
+	in, err := inView(l.ProjectID, l.To, l.From)
 	if err != nil {
-		log.Fatal(err)
+		return l, err
 	}
-
-	for cur.Next(context.TODO()) {
-		var elem Link
-		err := cur.Decode(&elem)
-		if err != nil {
-			log.Fatal(err)
-		}
-		result = append(result, &elem)
+	l.InView = in
+	insertResult, err := collection.InsertOne(context.TODO(), l)
+	if err != nil {
+		return l, err
 	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	emit(Event{Type: EventLinkCreated, ProjectID: l.ProjectID, TargetKind: "link", TargetID: l.ID, Related: []string{l.From, l.To}, Actor: l.Actor, After: l})
+	return l, nil
+}
 
-	if err := cur.Err(); err != nil {
-		log.Fatal(err)
-	}
+// FindLinkByID returns user or error
+func FindLinkByID(id string) (Link, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
 
-	return result
+	collection := db.DB.Collection(db.LinkCollectionName)
+
+	var resultLink Link
+
+	filter := bson.D{primitive.E{Key: "id", Value: id}}
+	err := collection.FindOne(ctx, filter).Decode(&resultLink)
+	return resultLink, err
 }
 
-// AddLink adds a new link to the database
-func AddLink(l Link) Link {
+// FindProjectLink returns the link of the project or mongo.ErrNoDocuments
+func FindProjectLink(projectID string, id string) (Link, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
 	collection := db.DB.Collection(db.LinkCollectionName)
 
-	l.ID = guuid.New().String()
-	l.InView = inView(l.To, l.From)
-	insertResult, err := collection.InsertOne(context.TODO(), l)
+	var resultLink Link
+	err := collection.FindOne(ctx, bson.M{"projectid": projectID, "id": id}).Decode(&resultLink)
+	return resultLink, err
+}
 
+// DeleteLink removes the link of the project, actor names the user deleting it
+func DeleteLink(projectID string, id string, actor string) (Link, error) {
+	l, err := FindProjectLink(projectID, id)
 	if err != nil {
-		log.Fatal(err)
+		return l, err
 	}
-	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
-	return l
+
+	collection := db.DB.Collection(db.LinkCollectionName)
+	deleteResult, err := collection.DeleteOne(context.TODO(), bson.M{"projectid": projectID, "id": id})
+	if err != nil {
+		return l, err
+	}
+	fmt.Println("Deleted a single document:", deleteResult)
+	if deleteResult.DeletedCount > 0 {
+		emit(Event{Type: EventLinkDeleted, ProjectID: l.ProjectID, TargetKind: "link", TargetID: l.ID, Related: []string{l.From, l.To}, Actor: actor, Before: l})
+	}
+	return l, nil
 }
 
-// FindLinkByID returns user or error
-func FindLinkByID(id string) (Link, error) {
+// FindLinkByExternalID returns the link of the project imported with the external id
+func FindLinkByExternalID(projectID string, externalID string) (Link, error) {
 	exp := 5 * time.Second
 	ctx, cancel := context.WithTimeout(context.Background(), exp)
 	defer cancel()
@@ -132,20 +191,48 @@ func FindLinkByID(id string) (Link, error) {
 
 	var resultLink Link
 
-	filter := bson.D{primitive.E{Key: "id", Value: id}}
+	filter := bson.M{"projectid": projectID, "externalid": externalID}
 	err := collection.FindOne(ctx, filter).Decode(&resultLink)
 	return resultLink, err
 }
 
-// FindLinkedComponents returns list of linked componenets
-func FindLinkedComponents(id string) ([]ArchViewComponent, error) {
+// HasLink reports whether there is a link from one component to the other
+func HasLink(from string, to string) (bool, error) {
+	collection := db.DB.Collection(db.LinkCollectionName)
+	count, err := collection.CountDocuments(context.TODO(), bson.M{"from": from, "to": to})
+	return count > 0, err
+}
+
+// UpdateLink replaces link with new one
+func UpdateLink(l Link) error {
+	linkCollection := db.DB.Collection(db.LinkCollectionName)
+	query := bson.M{"id": l.ID}
+
+	before, _ := FindLinkByID(l.ID)
+	in, err := inView(l.ProjectID, l.To, l.From)
+	if err != nil {
+		return err
+	}
+	l.InView = in
+	replaceResult, err := linkCollection.ReplaceOne(context.TODO(), query, l)
+	fmt.Println("Replaced a single document:", replaceResult)
+	if err != nil {
+		return err
+	}
+	emit(Event{Type: EventLinkUpdated, ProjectID: l.ProjectID, TargetKind: "link", TargetID: l.ID, Related: []string{l.From, l.To}, Actor: l.Actor, Before: before, After: l})
+	return nil
+}
+
+// FindLinkedComponents returns the components of the project linked to the component
+func FindLinkedComponents(projectID string, id string) ([]ArchViewComponent, error) {
 	linkCollection := db.DB.Collection(db.LinkCollectionName)
 	componentCollection := db.DB.Collection(db.ArchViewComponentCollectionName)
 
 	var result []string
-	var ret []ArchViewComponent
+	ret := []ArchViewComponent{}
 
 	filter := bson.M{
+		"projectid": projectID,
 		"$or": []interface{}{
 			bson.M{"from": id},
 			bson.M{"to": id},
@@ -153,18 +240,16 @@ func FindLinkedComponents(id string) ([]ArchViewComponent, error) {
 	}
 
 	cur, err := linkCollection.Find(context.TODO(), filter)
-
-	defer cur.Close(context.TODO())
-
 	if err != nil {
-		log.Fatal(err)
+		return ret, err
 	}
+	defer cur.Close(context.TODO())
 
 	for cur.Next(context.TODO()) {
 		var elem Link
 		err := cur.Decode(&elem)
 		if err != nil {
-			log.Fatal(err)
+			return ret, err
 		}
 		if elem.From == id {
 			result = append(result, elem.To)
@@ -172,34 +257,70 @@ func FindLinkedComponents(id string) ([]ArchViewComponent, error) {
 			result = append(result, elem.From)
 		}
 	}
+	if err := cur.Err(); err != nil {
+		return ret, err
+	}
 	if len(result) < 1 {
+		return ret, nil
+	}
+	filter = bson.M{"projectid": projectID, "id": bson.M{"$in": result}}
+	components, err := componentCollection.Find(context.TODO(), filter)
+	if err != nil {
 		return ret, err
 	}
-	filter = bson.M{"id": bson.M{"$in": result}}
-	cur, err = componentCollection.Find(context.TODO(), filter)
+	defer components.Close(context.TODO())
 
-	for cur.Next(context.TODO()) {
+	for components.Next(context.TODO()) {
 		var elem ArchViewComponent
-		err := cur.Decode(&elem)
+		err := components.Decode(&elem)
 		if err != nil {
-			log.Fatal(err)
+			return ret, err
 		}
 		ret = append(ret, elem)
 	}
+	return ret, components.Err()
+}
 
-	if err := cur.Err(); err != nil {
-		log.Fatal(err)
+// inView reports whether both components are in the same view, they have to
+// be components of the project or ErrLinkComponent is returned
+func inView(projectID string, toID string, fromID string) (bool, error) {
+	c1, err := FindProjectArchViewComponent(projectID, toID)
+	if err == mongo.ErrNoDocuments {
+		return false, ErrLinkComponent
+	}
+	if err != nil {
+		return false, err
+	}
+	c2, err := FindProjectArchViewComponent(projectID, fromID)
+	if err == mongo.ErrNoDocuments {
+		return false, ErrLinkComponent
+	}
+	if err != nil {
+		return false, err
 	}
-	return ret, err
-}
 
-func inView(toID string, fromID string) bool {
-	c1, err := FindArchViewComponentByID(toID)
-	c2, err := FindArchViewComponentByID(fromID)
+	return c1.ViewID == c2.ViewID, nil
+}
 
+// WalkProjectLinks calls fn for every link of the project without loading them all at once
+func WalkProjectLinks(projectID string, fn func(Link) error) error {
+	collection := db.DB.Collection(db.LinkCollectionName)
+	filter := bson.D{primitive.E{Key: "projectid", Value: projectID}}
+	cur, err := collection.Find(context.TODO(), filter)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var elem Link
+		if err := cur.Decode(&elem); err != nil {
+			return err
+		}
+		if err := fn(elem); err != nil {
+			return err
+		}
 	}
 
-	return c1.ViewID == c2.ViewID
+	return cur.Err()
 }
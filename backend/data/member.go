@@ -0,0 +1,257 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	db "traceability/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memberChangeAttempts is how often a member change is applied to a fresh
+// copy of the project when others change the members meanwhile
+const memberChangeAttempts = 5
+
+var (
+	// ErrUnknownUser is returned when a user to add to a project is not found
+	ErrUnknownUser = errors.New("user not found")
+	// ErrMemberExists is returned when a user is added to a project twice
+	ErrMemberExists = errors.New("user is a member of the project already")
+	// ErrOwnerRequired is returned when someone else than an owner grants, changes or removes the owner role
+	ErrOwnerRequired = errors.New("only owners can grant or take the owner role")
+	// ErrLastOwner is returned when a change would leave the project without an owner
+	ErrLastOwner = errors.New("the project must keep an owner")
+	// ErrMembersChanged is returned when the members keep changing while a change is applied
+	ErrMembersChanged = errors.New("the members of the project changed meanwhile, try again")
+)
+
+// MemberDetails is a member of a project with the name and email of the user
+// swagger:model
+type MemberDetails struct {
+	ProjectMember `bson:",inline"`
+
+	// name of the user
+	Name string `json:"name"`
+
+	// email of the user
+	Email string `json:"email"`
+}
+
+// MemberOf returns the member of the project with the user id or ErrNotProjectMember
+func (p Project) MemberOf(userID string) (ProjectMember, error) {
+	for _, m := range p.Members {
+		if m.ID == userID {
+			return m, nil
+		}
+	}
+	return ProjectMember{}, ErrNotProjectMember
+}
+
+// countOwners returns the number of members with the owner role
+func (p Project) countOwners() int {
+	n := 0
+	for _, m := range p.Members {
+		if m.Role == RoleOwner {
+			n++
+		}
+	}
+	return n
+}
+
+// checkOwners keeps Project.Owner one of the owners, it returns ErrLastOwner
+// if there is none left
+func (p *Project) checkOwners() error {
+	if p.countOwners() == 0 {
+		return ErrLastOwner
+	}
+	if m, err := p.MemberOf(p.Owner); err == nil && m.Role == RoleOwner {
+		return nil
+	}
+	for _, m := range p.Members {
+		if m.Role == RoleOwner {
+			p.Owner = m.ID
+			break
+		}
+	}
+	return nil
+}
+
+// FindProjectMembers returns the members of the project with their names and emails
+func FindProjectMembers(projectID string) ([]MemberDetails, error) {
+	project, err := FindProjectByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []MemberDetails{}
+	for _, m := range project.Members {
+		d := MemberDetails{ProjectMember: m}
+		if u, err := FindUserByID(m.ID); err == nil {
+			d.Name, d.Email = u.Name, u.Email
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// changeMembers applies change to the members and owner of the project and
+// stores them only if nobody else changed them since they were read, it
+// applies change to a fresh copy otherwise. Concurrent changes so can't both
+// pass the owner checks or overwrite each other.
+func changeMembers(projectID string, actor string, change func(p *Project) error) (Project, error) {
+	collection := db.DB.Collection(db.ProjectCollectionName)
+	for i := 0; i < memberChangeAttempts; i++ {
+		project, err := FindProjectByID(projectID)
+		if err != nil {
+			return project, err
+		}
+		before := project
+		before.Members = append([]ProjectMember(nil), project.Members...)
+		if err := change(&project); err != nil {
+			return project, err
+		}
+		if project.Owner == before.Owner && reflect.DeepEqual(project.Members, before.Members) {
+			return project, nil
+		}
+
+		filter := bson.M{"id": projectID, "owner": before.Owner, "members": before.Members}
+		update := bson.M{"$set": bson.M{"owner": project.Owner, "members": project.Members}}
+		updateResult, err := collection.UpdateOne(context.TODO(), filter, update)
+		if err != nil {
+			return project, err
+		}
+		fmt.Println("Updated a single document:", updateResult)
+		if updateResult.MatchedCount == 0 {
+			continue
+		}
+
+		project.Actor = actor
+		emit(Event{Type: EventProjectUpdated, ProjectID: projectID, TargetKind: "project", TargetID: projectID, Actor: actor, Before: before, After: project})
+		emitMemberChanges(before, project)
+		return project, nil
+	}
+	return Project{}, ErrMembersChanged
+}
+
+// emitMemberChanges emits the added, updated and removed members of the project
+func emitMemberChanges(before Project, p Project) {
+	for _, m := range p.Members {
+		previous, err := before.MemberOf(m.ID)
+		switch {
+		case err != nil:
+			emit(Event{Type: EventMemberAdded, ProjectID: p.ID, TargetKind: "member", TargetID: m.ID, Actor: p.Actor, After: m})
+		case previous.Role != m.Role:
+			emit(Event{Type: EventMemberUpdated, ProjectID: p.ID, TargetKind: "member", TargetID: m.ID, Actor: p.Actor, Before: previous, After: m})
+		}
+	}
+	for _, m := range before.Members {
+		if !p.HasMember(m.ID) {
+			emit(Event{Type: EventMemberRemoved, ProjectID: p.ID, TargetKind: "member", TargetID: m.ID, Actor: p.Actor, Before: m})
+		}
+	}
+}
+
+// AddProjectMember adds the user to the project with the role. The actor
+// adding the member needs the owner role to grant it.
+func AddProjectMember(projectID string, m ProjectMember, actor string, actorRole Role) (ProjectMember, error) {
+	if _, err := FindUserByID(m.ID); err == mongo.ErrNoDocuments {
+		return m, ErrUnknownUser
+	} else if err != nil {
+		return m, err
+	}
+	if _, err := FindRole(projectID, m.Role); err != nil {
+		return m, err
+	}
+	if m.Role == RoleOwner && actorRole.Name != RoleOwner {
+		return m, ErrOwnerRequired
+	}
+
+	_, err := changeMembers(projectID, actor, func(project *Project) error {
+		if project.HasMember(m.ID) {
+			return ErrMemberExists
+		}
+		project.Members = append(project.Members, m)
+		return nil
+	})
+	return m, err
+}
+
+// UpdateProjectMember changes the role of the member. Only owners grant the
+// owner role or change the role of an owner, and the project keeps an owner.
+func UpdateProjectMember(projectID string, m ProjectMember, actor string, actorRole Role) (ProjectMember, error) {
+	if _, err := FindRole(projectID, m.Role); err != nil {
+		return m, err
+	}
+
+	_, err := changeMembers(projectID, actor, func(project *Project) error {
+		current, err := project.MemberOf(m.ID)
+		if err != nil {
+			return err
+		}
+		if (m.Role == RoleOwner || current.Role == RoleOwner) && actorRole.Name != RoleOwner {
+			return ErrOwnerRequired
+		}
+		for i := range project.Members {
+			if project.Members[i].ID == m.ID {
+				project.Members[i].Role = m.Role
+			}
+		}
+		return project.checkOwners()
+	})
+	return m, err
+}
+
+// RemoveProjectMember removes the user from the project. Only owners remove
+// owners, and the last owner can't be removed.
+func RemoveProjectMember(projectID string, userID string, actor string, actorRole Role) error {
+	_, err := changeMembers(projectID, actor, func(project *Project) error {
+		current, err := project.MemberOf(userID)
+		if err != nil {
+			return err
+		}
+		if current.Role == RoleOwner && actorRole.Name != RoleOwner {
+			return ErrOwnerRequired
+		}
+		members := []ProjectMember{}
+		for _, m := range project.Members {
+			if m.ID != userID {
+				members = append(members, m)
+			}
+		}
+		project.Members = members
+		return project.checkOwners()
+	})
+	return err
+}
+
+// TransferProjectOwnership makes the member the owner of the project. When
+// the owner hands over the project, they stay a maintainer of it, other owners
+// keep their role.
+func TransferProjectOwnership(projectID string, userID string, actor string, actorRole Role) (Project, error) {
+	if actorRole.Name != RoleOwner {
+		return Project{}, ErrOwnerRequired
+	}
+
+	return changeMembers(projectID, actor, func(project *Project) error {
+		if _, err := project.MemberOf(userID); err != nil {
+			return err
+		}
+		previous := project.Owner
+		for i := range project.Members {
+			switch project.Members[i].ID {
+			case userID:
+				project.Members[i].Role = RoleOwner
+			case previous:
+				if previous == actor {
+					project.Members[i].Role = RoleMaintainer
+				}
+			}
+		}
+		project.Owner = userID
+		return nil
+	})
+}
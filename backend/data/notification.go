@@ -0,0 +1,547 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	db "traceability/database"
+
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WatchTarget is the kind of thing a user watches
+type WatchTarget string
+
+const (
+	// WatchProject watches everything in a project
+	WatchProject WatchTarget = "project"
+	// WatchView watches a view and its components
+	WatchView WatchTarget = "view"
+	// WatchComponent watches a component, its links and comments
+	WatchComponent WatchTarget = "component"
+)
+
+// NotificationReason tells why a user is notified
+type NotificationReason string
+
+const (
+	// ReasonWatch notifies about a change of something watched
+	ReasonWatch NotificationReason = "watch"
+	// ReasonMention notifies about a mention in a comment
+	ReasonMention NotificationReason = "mention"
+)
+
+var (
+	// ErrUnknownWatch is returned when a watch of the user is not found
+	ErrUnknownWatch = errors.New("watch not found")
+	// ErrWatchExists is returned when the user watches the target already
+	ErrWatchExists = errors.New("target is watched already")
+	// ErrUnknownWatchTarget is returned when the watched view or component is not in the project
+	ErrUnknownWatchTarget = errors.New("watched view or component not found in the project")
+	// ErrNotProjectMember is returned when a user acts on a project they are no member of
+	ErrNotProjectMember = errors.New("user is not a member of the project")
+	// ErrUnknownNotification is returned when a notification is not in the inbox of the user
+	ErrUnknownNotification = errors.New("notification not found")
+)
+
+// Watch subscribes a user to the changes of a project, view or component
+// swagger:model
+type Watch struct {
+	// the id of the watch
+	//
+	// required: false
+	ID string `json:"id"`
+
+	// id of the watching user
+	//
+	// required: false
+	UserID string `json:"userID" bson:"userid"`
+
+	// id of the project
+	//
+	// required: true
+	ProjectID string `json:"projectID" bson:"projectid" validate:"required"`
+
+	// "project", "view" or "component"
+	//
+	// required: true
+	TargetKind WatchTarget `json:"targetKind" bson:"targetkind" validate:"required,oneof=project view component"`
+
+	// id of the watched view or component, the project id for projects
+	//
+	// required: false
+	TargetID string `json:"targetID" bson:"targetid"`
+
+	// event types to be notified about, all if empty
+	//
+	// required: false
+	Events []EventType `json:"events,omitempty" bson:"events,omitempty" validate:"dive,event"`
+
+	// time the watch was created
+	//
+	// required: false
+	CreatedAt time.Time `json:"createdAt" bson:"createdat"`
+}
+
+// Notification is an entry in the inbox of a user
+// swagger:model
+type Notification struct {
+	// the id of the notification
+	ID string `json:"id"`
+
+	// id of the notified user
+	UserID string `json:"userID" bson:"userid"`
+
+	// id of the project
+	ProjectID string `json:"projectID" bson:"projectid"`
+
+	// type of the event
+	Type EventType `json:"type"`
+
+	// "project", "view", "component", "link", "comment" or "member"
+	TargetKind string `json:"targetKind" bson:"targetkind"`
+
+	// id of the changed thing
+	TargetID string `json:"targetID" bson:"targetid"`
+
+	// ids of further things the change concerns
+	Related []string `json:"related,omitempty" bson:"related,omitempty"`
+
+	// id of the user making the change
+	Actor string `json:"actor,omitempty" bson:"actor,omitempty"`
+
+	// "watch" or "mention"
+	Reason NotificationReason `json:"reason"`
+
+	// id of the matching watch
+	WatchID string `json:"watchID,omitempty" bson:"watchid,omitempty"`
+
+	// time of the change
+	Time time.Time `json:"time"`
+
+	// set when the user has read the notification
+	Read bool `json:"read"`
+
+	// time the notification was read
+	ReadAt *time.Time `json:"readAt,omitempty" bson:"readat,omitempty"`
+}
+
+// NotificationPreferences filter the notifications of a user
+// swagger:model
+type NotificationPreferences struct {
+	// id of the user
+	//
+	// required: false
+	UserID string `json:"userID" bson:"userid"`
+
+	// event types never notified about by watches
+	//
+	// required: false
+	MutedEvents []EventType `json:"mutedEvents" bson:"mutedevents" validate:"dive,event"`
+
+	// ids of projects never notified about
+	//
+	// required: false
+	MutedProjects []string `json:"mutedProjects" bson:"mutedprojects"`
+
+	// don't notify about mentions in comments
+	//
+	// required: false
+	IgnoreMentions bool `json:"ignoreMentions" bson:"ignorementions"`
+}
+
+// allows reports whether the preferences let a notification of the event through
+func (p NotificationPreferences) allows(e Event, reason NotificationReason) bool {
+	for _, id := range p.MutedProjects {
+		if id == e.ProjectID {
+			return false
+		}
+	}
+	if reason == ReasonMention {
+		return !p.IgnoreMentions
+	}
+	for _, t := range p.MutedEvents {
+		if t == e.Type {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether the watch wants to be notified about the event
+func (w Watch) matches(e Event) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, t := range w.Events {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// AddWatch subscribes the user of the watch to the target, the user has to
+// be a member of the project
+func AddWatch(w Watch) (Watch, error) {
+	project, err := FindProjectByID(w.ProjectID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return w, err
+	}
+	if !project.HasMember(w.UserID) {
+		return w, ErrNotProjectMember
+	}
+
+	var projectID string
+	switch w.TargetKind {
+	case WatchProject:
+		w.TargetID, projectID = w.ProjectID, w.ProjectID
+	case WatchView:
+		v, err := FindArchViewByID(w.TargetID)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return w, err
+		}
+		projectID = v.ProjectID
+	case WatchComponent:
+		c, err := FindArchViewComponentByID(w.TargetID)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return w, err
+		}
+		projectID = c.ProjectID
+	}
+	if projectID != w.ProjectID {
+		return w, ErrUnknownWatchTarget
+	}
+
+	collection := db.DB.Collection(db.WatchCollectionName)
+	n, err := collection.CountDocuments(context.TODO(), bson.M{"userid": w.UserID, "targetid": w.TargetID})
+	if err != nil {
+		return w, err
+	}
+	if n > 0 {
+		return w, ErrWatchExists
+	}
+
+	w.ID = guuid.New().String()
+	w.CreatedAt = time.Now()
+	insertResult, err := collection.InsertOne(context.TODO(), w)
+	if err != nil {
+		return w, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return w, nil
+}
+
+// FindWatchesOfUser returns the watches of the user, of one project if projectID is not empty
+func FindWatchesOfUser(userID string, projectID string) ([]Watch, error) {
+	filter := bson.M{"userid": userID}
+	if projectID != "" {
+		filter["projectid"] = projectID
+	}
+	cur, err := db.DB.Collection(db.WatchCollectionName).Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []Watch{}
+	for cur.Next(context.TODO()) {
+		var elem Watch
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
+// DeleteWatch unsubscribes the user from the watch
+func DeleteWatch(userID string, id string) error {
+	collection := db.DB.Collection(db.WatchCollectionName)
+	deleteResult, err := collection.DeleteOne(context.TODO(), bson.M{"userid": userID, "id": id})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Deleted a single document:", deleteResult)
+	if deleteResult.DeletedCount == 0 {
+		return ErrUnknownWatch
+	}
+	return nil
+}
+
+// FindNotificationPreferences returns the preferences of the user, nothing is muted by default
+func FindNotificationPreferences(userID string) (NotificationPreferences, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.NotificationPreferencesCollectionName)
+
+	result := NotificationPreferences{UserID: userID, MutedEvents: []EventType{}, MutedProjects: []string{}}
+	err := collection.FindOne(ctx, bson.M{"userid": userID}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, nil
+	}
+	return result, err
+}
+
+// UpdateNotificationPreferences stores the preferences of the user
+func UpdateNotificationPreferences(p NotificationPreferences) error {
+	collection := db.DB.Collection(db.NotificationPreferencesCollectionName)
+	query := bson.M{"userid": p.UserID}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, p, options.Replace().SetUpsert(true))
+	fmt.Println("Replaced a single document:", replaceResult)
+	return err
+}
+
+// FindNotifications returns the newest notifications of the user, only the
+// unread ones if unread is set
+func FindNotifications(userID string, unread bool, limit int64) ([]Notification, error) {
+	filter := bson.M{"userid": userID}
+	if unread {
+		filter["read"] = false
+	}
+	opts := options.Find().SetSort(bson.M{"time": -1}).SetLimit(limit)
+	cur, err := db.DB.Collection(db.NotificationCollectionName).Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []Notification{}
+	for cur.Next(context.TODO()) {
+		var elem Notification
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
+// CountUnreadNotifications returns the number of unread notifications of the user
+func CountUnreadNotifications(userID string) (int64, error) {
+	collection := db.DB.Collection(db.NotificationCollectionName)
+	return collection.CountDocuments(context.TODO(), bson.M{"userid": userID, "read": false})
+}
+
+// MarkNotificationRead marks the notification of the user read or unread
+func MarkNotificationRead(userID string, id string, read bool) (Notification, error) {
+	update := bson.M{"$set": bson.M{"read": false}, "$unset": bson.M{"readat": ""}}
+	if read {
+		update = bson.M{"$set": bson.M{"read": true, "readat": time.Now()}}
+	}
+	collection := db.DB.Collection(db.NotificationCollectionName)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var result Notification
+	err := collection.FindOneAndUpdate(context.TODO(), bson.M{"userid": userID, "id": id}, update, opts).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, ErrUnknownNotification
+	}
+	return result, err
+}
+
+// MarkAllNotificationsRead marks the unread notifications of the user read,
+// of one project if projectID is not empty
+func MarkAllNotificationsRead(userID string, projectID string) (int64, error) {
+	filter := bson.M{"userid": userID, "read": false}
+	if projectID != "" {
+		filter["projectid"] = projectID
+	}
+	update := bson.M{"$set": bson.M{"read": true, "readat": time.Now()}}
+	updateResult, err := db.DB.Collection(db.NotificationCollectionName).UpdateMany(context.TODO(), filter, update)
+	if err != nil {
+		return 0, err
+	}
+	fmt.Println("Updated documents:", updateResult.ModifiedCount)
+	return updateResult.ModifiedCount, nil
+}
+
+// notify puts a notification of the event into the inboxes of the mentioned
+// members and of the members watching the project or one of the things the
+// event concerns. The actor isn't notified about their own changes. It runs
+// on the notification queue, not in the request making the change.
+func notify(e Event) error {
+	project, err := FindProjectByID(e.ProjectID)
+	if err == mongo.ErrNoDocuments {
+		// the views of a new project are created before the project
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	type recipient struct {
+		reason  NotificationReason
+		watchID string
+	}
+	recipients := map[string]recipient{}
+	for _, id := range e.Mentions {
+		recipients[id] = recipient{reason: ReasonMention}
+	}
+
+	targets, err := watchTargets(e)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"projectid": e.ProjectID, "targetid": bson.M{"$in": targets}}
+	cur, err := db.DB.Collection(db.WatchCollectionName).Find(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(context.TODO())
+	for cur.Next(context.TODO()) {
+		var w Watch
+		if err := cur.Decode(&w); err != nil {
+			return err
+		}
+		if _, ok := recipients[w.UserID]; !ok && w.matches(e) {
+			recipients[w.UserID] = recipient{reason: ReasonWatch, watchID: w.ID}
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+
+	var notifications []interface{}
+	for userID, r := range recipients {
+		if userID == e.Actor || !project.HasMember(userID) {
+			continue
+		}
+		prefs, err := FindNotificationPreferences(userID)
+		if err != nil {
+			return err
+		}
+		if !prefs.allows(e, r.reason) {
+			continue
+		}
+		notifications = append(notifications, Notification{
+			ID:         guuid.New().String(),
+			UserID:     userID,
+			ProjectID:  e.ProjectID,
+			Type:       e.Type,
+			TargetKind: e.TargetKind,
+			TargetID:   e.TargetID,
+			Related:    e.Related,
+			Actor:      e.Actor,
+			Reason:     r.reason,
+			WatchID:    r.watchID,
+			Time:       e.Time,
+		})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	insertResult, err := db.DB.Collection(db.NotificationCollectionName).InsertMany(context.TODO(), notifications)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Inserted documents: ", len(insertResult.InsertedIDs))
+	return nil
+}
+
+// watchTargets returns the ids of the project, views and components whose
+// watchers are notified about the event. Links and comments concern the
+// views of their components, too.
+func watchTargets(e Event) ([]string, error) {
+	targets := append([]string{e.ProjectID, e.TargetID}, e.Related...)
+
+	var components []string
+	switch e.TargetKind {
+	case "link":
+		components = e.Related
+	case "comment":
+		c, _ := e.After.(Comment)
+		switch c.TargetKind {
+		case CommentOnComponent:
+			components = []string{c.TargetID}
+		case CommentOnLink:
+			l, err := FindLinkByID(c.TargetID)
+			if err != nil && err != mongo.ErrNoDocuments {
+				return nil, err
+			}
+			if err == nil {
+				components = []string{l.From, l.To}
+				targets = append(targets, components...)
+			}
+		}
+	}
+	for _, id := range components {
+		c, err := FindArchViewComponentByID(id)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, c.ViewID)
+	}
+	return targets, nil
+}
+
+// notificationQueue holds the events notify hasn't handled yet, writes
+// don't wait for the inboxes of the watchers
+var notificationQueue = make(chan Event, 1024)
+
+// notificationQueueWait is how long a write waits for room in a full queue
+// before the notifications of its event are dropped
+const notificationQueueWait = 5 * time.Second
+
+// pendingNotifications counts the queued events not handled yet
+var pendingNotifications int64
+
+// queueNotification hands the event to the notification worker, it waits up
+// to notificationQueueWait if the worker is far behind and drops the event then
+func queueNotification(e Event) error {
+	atomic.AddInt64(&pendingNotifications, 1)
+	timer := time.NewTimer(notificationQueueWait)
+	defer timer.Stop()
+	select {
+	case notificationQueue <- e:
+		return nil
+	case <-timer.C:
+		atomic.AddInt64(&pendingNotifications, -1)
+		return errors.New("notification queue is full, the notifications are dropped")
+	}
+}
+
+// notifyQueued notifies about the queued events one after the other
+func notifyQueued() {
+	for e := range notificationQueue {
+		if err := notify(e); err != nil {
+			log.Printf("[ERROR] notifying about %s event of %s: %v", e.Type, e.TargetID, err)
+		}
+		atomic.AddInt64(&pendingNotifications, -1)
+	}
+}
+
+// FlushNotifications waits up to timeout until the queued events were
+// notified about and reports whether they were. The queue isn't stored,
+// programs call it before they exit.
+func FlushNotifications(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for atomic.LoadInt64(&pendingNotifications) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+func init() {
+	OnEvent(queueNotification)
+	go notifyQueued()
+}
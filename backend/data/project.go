@@ -23,7 +23,7 @@ type ProjectMember struct {
 	// required: false
 	ID string `json:"id"`
 
-	// role
+	// name of a built-in or custom role of the project
 	//
 	// required: false
 	Role string `json:"role"`
@@ -72,6 +72,20 @@ type Project struct {
 	//
 	// required: false
 	Members []ProjectMember `json:"members,omitempty"`
+
+	// Actor is the id of the user making the change, it is not stored and
+	// only names the actor of the emitted event
+	Actor string `json:"-" bson:"-"`
+}
+
+// HasMember reports whether the user is a member of the project in any role
+func (p Project) HasMember(userID string) bool {
+	for _, m := range p.Members {
+		if m.ID == userID {
+			return true
+		}
+	}
+	return false
 }
 
 // GetAllUserProjects returns all projects
@@ -92,6 +106,26 @@ func GetAllUserProjects(userID string) Projects {
 	return result
 }
 
+// findUserProjectIDs returns the ids of the projects the user is a member of
+func findUserProjectIDs(userID string) ([]string, error) {
+	collection := db.DB.Collection(db.ProjectCollectionName)
+	cur, err := collection.Find(context.TODO(), bson.M{"members.id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	ids := []string{}
+	for cur.Next(context.TODO()) {
+		var elem Project
+		if err := cur.Decode(&elem); err != nil {
+			return ids, err
+		}
+		ids = append(ids, elem.ID)
+	}
+	return ids, cur.Err()
+}
+
 // GetAllProjects returns all projects
 func GetAllProjects() Projects {
 	var result Projects
@@ -121,23 +155,32 @@ func GetAllProjects() Projects {
 	return result
 }
 
-// AddProject adds a new project to the database
-func AddProject(p Project, owner string) Project {
+// AddProject adds a new project with its built-in views to the database
+func AddProject(p Project, owner string) (Project, error) {
 	var members []ProjectMember
-	members = append(members, ProjectMember{ID: owner, Role: "owner"})
+	members = append(members, ProjectMember{ID: owner, Role: RoleOwner})
 	p.Members = members
 	p.ID = guuid.New().String()
 	p.Owner = owner
 
-	userStory := &ArchView{Name: "User Stories", Kind: "userStory", ProjectID: p.ID}
-	functional := &ArchView{Name: "Functional", Kind: "functional", ProjectID: p.ID}
-	development := &ArchView{Name: "Development", Kind: "development", ProjectID: p.ID}
+	userStory := &ArchView{Name: "User Stories", Kind: "userStory", ProjectID: p.ID, Actor: owner}
+	functional := &ArchView{Name: "Functional", Kind: "functional", ProjectID: p.ID, Actor: owner}
+	development := &ArchView{Name: "Development", Kind: "development", ProjectID: p.ID, Actor: owner}
 
 	addedArchview, err := AddArchView(*userStory)
+	if err != nil {
+		return p, err
+	}
 	p.UserStoriesID = addedArchview.ID
 	addedArchview, err = AddArchView(*functional)
+	if err != nil {
+		return p, err
+	}
 	p.FuntionalViewID = addedArchview.ID
 	addedArchview, err = AddArchView(*development)
+	if err != nil {
+		return p, err
+	}
 	p.DevelopmentViewID = addedArchview.ID
 
 	rootFunctionalComponent := &ArchViewComponent{
@@ -146,18 +189,21 @@ func AddProject(p Project, owner string) Project {
 		ViewID:       p.FuntionalViewID,
 		ProjectID:    p.ID,
 		Level:        0,
+		Actor:        owner,
 	}
 
-	AddArchViewComponent(*rootFunctionalComponent)
+	if _, err := AddArchViewComponent(*rootFunctionalComponent); err != nil {
+		return p, err
+	}
 
 	collection := db.DB.Collection(db.ProjectCollectionName)
 	insertResult, err := collection.InsertOne(context.TODO(), p)
-
 	if err != nil {
-		log.Fatal(err)
+		return p, err
 	}
 	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
-	return p
+	emit(Event{Type: EventProjectCreated, ProjectID: p.ID, TargetKind: "project", TargetID: p.ID, Actor: owner, After: p})
+	return p, nil
 }
 
 // FindProjectByID returns user or error
@@ -176,36 +222,39 @@ func FindProjectByID(id string) (Project, error) {
 	return resultProject, err
 }
 
-// FindMemberRoleInProject finds the role of the user in the projext
-func FindMemberRoleInProject(projectID string, userID string) string {
-	project, err := FindProjectByID(projectID)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	members := project.Members
-
-	for _, v := range members {
-		if v.ID == userID {
-			return v.Role
-		}
-	}
-
-	return "anonymos"
-}
-
-// UserHasPermission returns boolean value for about permission
-func UserHasPermission(projectID string, userID string, permission string) bool {
-	memberRole := FindMemberRoleInProject(projectID, userID)
-	return permission == memberRole
+// UserHasPermission reports whether the user is a member of the project
+// whose role allows the action
+func UserHasPermission(projectID string, userID string, permission Permission) bool {
+	role, err := FindProjectRole(projectID, userID)
+	return err == nil && role.Can(permission)
 }
 
-// UpdateProject replaces archview with new
+// UpdateProject stores the name, description and views of the project, its
+// members and owner change only through the member functions
 func UpdateProject(p Project) error {
+	before, _ := FindProjectByID(p.ID)
 	projectCollection := db.DB.Collection(db.ProjectCollectionName)
 	query := bson.M{"id": p.ID}
 
-	replaceResult, err := projectCollection.ReplaceOne(context.TODO(), query, p)
-	fmt.Println("Replaced a single document:", replaceResult)
-	return err
+	set := bson.M{
+		"name":              p.Name,
+		"userstoriesid":     p.UserStoriesID,
+		"funtionalviewid":   p.FuntionalViewID,
+		"developmentviewid": p.DevelopmentViewID,
+	}
+	update := bson.M{"$set": set}
+	if p.Description == "" {
+		update["$unset"] = bson.M{"description": ""}
+	} else {
+		set["description"] = p.Description
+	}
+
+	updateResult, err := projectCollection.UpdateOne(context.TODO(), query, update)
+	fmt.Println("Updated a single document:", updateResult)
+	if err != nil {
+		return err
+	}
+	p.Owner, p.Members = before.Owner, before.Members
+	emit(Event{Type: EventProjectUpdated, ProjectID: p.ID, TargetKind: "project", TargetID: p.ID, Actor: p.Actor, Before: before, After: p})
+	return nil
 }
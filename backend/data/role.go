@@ -0,0 +1,323 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	db "traceability/database"
+
+	"github.com/go-playground/validator/v10"
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Permission names an action a role allows in a project, e.g. "component:write"
+type Permission string
+
+const (
+	// PermProjectRead allows reading the project and everything in it
+	PermProjectRead Permission = "project:read"
+	// PermProjectWrite allows changing the name and description of the project
+	PermProjectWrite Permission = "project:write"
+	// PermViewWrite allows adding and changing views
+	PermViewWrite Permission = "view:write"
+	// PermComponentWrite allows adding and changing components
+	PermComponentWrite Permission = "component:write"
+	// PermLinkWrite allows adding and changing links
+	PermLinkWrite Permission = "link:write"
+	// PermLinkDelete allows removing links
+	PermLinkDelete Permission = "link:delete"
+	// PermLabelWrite allows defining labels and labelling components
+	PermLabelWrite Permission = "label:write"
+	// PermCommentWrite allows writing comments and replies
+	PermCommentWrite Permission = "comment:write"
+	// PermCommentResolve allows resolving and reopening comment threads
+	PermCommentResolve Permission = "comment:resolve"
+	// PermImport allows imports, code scans and applying drift
+	PermImport Permission = "project:import"
+	// PermViewKindManage allows defining custom view kinds
+	PermViewKindManage Permission = "viewkind:manage"
+	// PermWebhookManage allows managing webhooks and their deliveries
+	PermWebhookManage Permission = "webhook:manage"
+	// PermMemberManage allows adding and removing members and changing their roles
+	PermMemberManage Permission = "member:manage"
+	// PermRoleManage allows defining custom roles
+	PermRoleManage Permission = "role:manage"
+)
+
+// permissions are all permissions, in the order of the built-in roles granting them
+var permissions = []Permission{
+	PermProjectRead,
+	PermCommentWrite, PermCommentResolve,
+	PermViewWrite, PermComponentWrite, PermLinkWrite, PermLinkDelete, PermLabelWrite, PermImport,
+	PermProjectWrite, PermViewKindManage, PermWebhookManage, PermMemberManage, PermRoleManage,
+}
+
+const (
+	// RoleViewer can read the project
+	RoleViewer = "viewer"
+	// RoleCommenter can read the project and discuss it in comments
+	RoleCommenter = "commenter"
+	// RoleEditor can change views, components, links and labels
+	RoleEditor = "editor"
+	// RoleMaintainer can also change the project settings and its members
+	RoleMaintainer = "maintainer"
+	// RoleOwner can also define custom roles, a project always has an owner
+	RoleOwner = "owner"
+
+	// roleMember is the role of members added before roles existed
+	roleMember = "member"
+)
+
+var (
+	// ErrUnknownRole is returned when a role is not defined in the project
+	ErrUnknownRole = errors.New("role is not defined in the project")
+	// ErrRoleExists is returned when a role with the same name is defined already
+	ErrRoleExists = errors.New("role is defined already")
+	// ErrBuiltInRole is returned when a built-in role is changed or deleted
+	ErrBuiltInRole = errors.New("built-in roles can't be changed")
+	// ErrRoleInUse is returned when a role which members still have is deleted
+	ErrRoleInUse = errors.New("role is given to members of the project")
+)
+
+// Role is a named set of permissions given to project members
+// swagger:model
+type Role struct {
+	// the id of a custom role
+	//
+	// required: false
+	ID string `json:"id,omitempty" bson:"id,omitempty"`
+
+	// belonging project's id
+	//
+	// required: false
+	ProjectID string `json:"projectID,omitempty" bson:"projectid,omitempty"`
+
+	// name members refer to, letters, digits, "-" and "_"
+	//
+	// required: true
+	// max length: 30
+	Name string `json:"name" validate:"required,viewkind"`
+
+	// display name
+	//
+	// required: false
+	Label string `json:"label,omitempty" bson:"label,omitempty"`
+
+	// actions members with the role may do
+	//
+	// required: true
+	Permissions []Permission `json:"permissions" validate:"required,dive,permission"`
+
+	// set for the roles every project has
+	//
+	// required: false
+	BuiltIn bool `json:"builtIn" bson:"-"`
+}
+
+// builtInRoles are defined in every project, each allows what the previous one does
+var builtInRoles = []Role{
+	{Name: RoleViewer, Label: "Viewer", Permissions: permissions[:1]},
+	{Name: RoleCommenter, Label: "Commenter", Permissions: permissions[:3]},
+	{Name: RoleEditor, Label: "Editor", Permissions: permissions[:9]},
+	{Name: RoleMaintainer, Label: "Maintainer", Permissions: permissions[:13]},
+	{Name: RoleOwner, Label: "Owner", Permissions: permissions},
+}
+
+// validatePermission checks that the value names a permission
+func validatePermission(fl validator.FieldLevel) bool {
+	for _, p := range permissions {
+		if string(p) == fl.Field().String() {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOnly returns the role without the permissions to change anything
+func (r Role) ReadOnly() Role {
+	restricted := Role{ID: r.ID, ProjectID: r.ProjectID, Name: r.Name, Label: r.Label, BuiltIn: r.BuiltIn, Permissions: []Permission{}}
+	if r.Can(PermProjectRead) {
+		restricted.Permissions = append(restricted.Permissions, PermProjectRead)
+	}
+	return restricted
+}
+
+// Can reports whether the role allows the action
+func (r Role) Can(p Permission) bool {
+	for _, granted := range r.Permissions {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+func builtInRole(name string) (Role, bool) {
+	if name == roleMember {
+		name = RoleEditor
+	}
+	for _, r := range builtInRoles {
+		if r.Name == name {
+			r.BuiltIn = true
+			return r, true
+		}
+	}
+	return Role{}, false
+}
+
+// FindRolesOfProject returns the built-in and the custom roles of the project,
+// built-in roles first
+func FindRolesOfProject(projectID string) ([]Role, error) {
+	result := []Role{}
+	for _, r := range builtInRoles {
+		r.BuiltIn = true
+		result = append(result, r)
+	}
+
+	collection := db.DB.Collection(db.RoleCollectionName)
+	cur, err := collection.Find(context.TODO(), bson.M{"projectid": projectID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	custom := []Role{}
+	for cur.Next(context.TODO()) {
+		var elem Role
+		if err := cur.Decode(&elem); err != nil {
+			return nil, err
+		}
+		custom = append(custom, elem)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(custom, func(i, j int) bool {
+		return custom[i].Name < custom[j].Name
+	})
+	return append(result, custom...), nil
+}
+
+// FindRole returns the role of the project with the name or ErrUnknownRole.
+// Members added before roles existed have the role "member", it is the editor role.
+func FindRole(projectID string, name string) (Role, error) {
+	if r, ok := builtInRole(name); ok {
+		return r, nil
+	}
+
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.RoleCollectionName)
+
+	var result Role
+	err := collection.FindOne(ctx, bson.M{"projectid": projectID, "name": name}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, ErrUnknownRole
+	}
+	return result, err
+}
+
+// KeyRole is the context key of the role of the user in the project of the request
+type KeyRole struct{}
+
+// GetRoleFromContext returns the role stored by the project auth middleware
+func GetRoleFromContext(ctx context.Context) (Role, bool) {
+	role, ok := ctx.Value(KeyRole{}).(Role)
+	return role, ok
+}
+
+// FindProjectRole returns the role of the user in the project or
+// ErrNotProjectMember if the user isn't a member of an existing project
+func FindProjectRole(projectID string, userID string) (Role, error) {
+	project, err := FindProjectByID(projectID)
+	if err == mongo.ErrNoDocuments {
+		return Role{}, ErrNotProjectMember
+	}
+	if err != nil {
+		return Role{}, err
+	}
+
+	for _, m := range project.Members {
+		if m.ID == userID {
+			return FindRole(projectID, m.Role)
+		}
+	}
+	return Role{}, ErrNotProjectMember
+}
+
+// AddRole adds a custom role to the project
+func AddRole(r Role) (Role, error) {
+	if _, ok := builtInRole(r.Name); ok {
+		return r, ErrRoleExists
+	}
+	if _, err := FindRole(r.ProjectID, r.Name); err != ErrUnknownRole {
+		if err == nil {
+			err = ErrRoleExists
+		}
+		return r, err
+	}
+	r.ID = guuid.New().String()
+	r.BuiltIn = false
+
+	collection := db.DB.Collection(db.RoleCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), r)
+	if err != nil {
+		return r, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return r, nil
+}
+
+// UpdateRole replaces the custom role with the same id, the name can't be changed
+func UpdateRole(r Role) error {
+	if _, ok := builtInRole(r.Name); ok || r.ID == "" {
+		return ErrBuiltInRole
+	}
+	collection := db.DB.Collection(db.RoleCollectionName)
+	query := bson.M{"id": r.ID, "projectid": r.ProjectID, "name": r.Name}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, r)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Replaced a single document:", replaceResult)
+	if replaceResult.MatchedCount == 0 {
+		return ErrUnknownRole
+	}
+	return nil
+}
+
+// DeleteRole removes the custom role from the project if no member has it
+func DeleteRole(projectID string, name string) error {
+	if _, ok := builtInRole(name); ok {
+		return ErrBuiltInRole
+	}
+	project, err := FindProjectByID(projectID)
+	if err != nil {
+		return err
+	}
+	for _, m := range project.Members {
+		if m.Role == name {
+			return ErrRoleInUse
+		}
+	}
+
+	collection := db.DB.Collection(db.RoleCollectionName)
+	deleteResult, err := collection.DeleteOne(context.TODO(), bson.M{"projectid": projectID, "name": name})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Deleted a single document:", deleteResult)
+	if deleteResult.DeletedCount == 0 {
+		return ErrUnknownRole
+	}
+	return nil
+}
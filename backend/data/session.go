@@ -0,0 +1,175 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "traceability/database"
+
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RefreshTokenTTL is how long a session lasts without being refreshed
+var RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrUnknownRefreshToken is returned when a refresh token isn't known
+	ErrUnknownRefreshToken = errors.New("refresh token not found")
+	// ErrRefreshTokenReused is returned when a rotated refresh token is used
+	// again, the session is revoked as the token may have leaked
+	ErrRefreshTokenReused = errors.New("refresh token was used already, the session is revoked")
+	// ErrSessionEnded is returned when the session of a refresh token was revoked or expired
+	ErrSessionEnded = errors.New("session has ended")
+)
+
+// Session is a login of a user on a device. Its refresh token is rotated on
+// every refresh, access tokens name the session in their "sid" claim.
+// swagger:model
+type Session struct {
+	// id of the session
+	ID string `json:"id"`
+
+	// id of the logged in user
+	UserID string `json:"userID" bson:"userid"`
+
+	// sha256 of the current refresh token
+	TokenHash string `json:"-" bson:"tokenhash"`
+
+	// sha256 of the rotated refresh tokens, using one again revokes the session
+	UsedHashes []string `json:"-" bson:"usedhashes"`
+
+	// user agent of the login
+	UserAgent string `json:"userAgent,omitempty" bson:"useragent,omitempty"`
+
+	// time of the login
+	CreatedAt time.Time `json:"createdAt" bson:"createdat"`
+
+	// time of the last refresh
+	RefreshedAt time.Time `json:"refreshedAt" bson:"refreshedat"`
+
+	// time the session ends if it isn't refreshed
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresat"`
+
+	// time of the logout
+	RevokedAt *time.Time `json:"revokedAt,omitempty" bson:"revokedat,omitempty"`
+}
+
+// Active reports whether the session isn't revoked or expired
+func (s Session) Active() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// AddSession starts a session of the user and returns its refresh token
+func AddSession(userID string, userAgent string) (Session, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return Session{}, "", err
+	}
+	now := time.Now()
+	s := Session{
+		ID:          guuid.New().String(),
+		UserID:      userID,
+		TokenHash:   hashToken(token),
+		UsedHashes:  []string{},
+		UserAgent:   userAgent,
+		CreatedAt:   now,
+		RefreshedAt: now,
+		ExpiresAt:   now.Add(RefreshTokenTTL),
+	}
+
+	collection := db.DB.Collection(db.SessionCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), s)
+	if err != nil {
+		return s, "", err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return s, token, nil
+}
+
+func findSession(filter bson.M) (Session, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.SessionCollectionName)
+
+	var result Session
+	err := collection.FindOne(ctx, filter).Decode(&result)
+	return result, err
+}
+
+// FindActiveSession reports whether the session of the user is neither revoked nor expired
+func FindActiveSession(id string, userID string) bool {
+	s, err := findSession(bson.M{"id": id, "userid": userID})
+	return err == nil && s.Active()
+}
+
+// RotateSession replaces the refresh token of its session with a new one and
+// returns it. A rotated token used again revokes the session.
+func RotateSession(refreshToken string) (Session, string, error) {
+	hash := hashToken(refreshToken)
+	s, err := findSession(bson.M{"tokenhash": hash})
+	if err == mongo.ErrNoDocuments {
+		if used, err := findSession(bson.M{"usedhashes": hash}); err == nil {
+			if err := RevokeSession(used.ID, used.UserID); err != nil {
+				return used, "", err
+			}
+			return used, "", ErrRefreshTokenReused
+		}
+		return s, "", ErrUnknownRefreshToken
+	}
+	if err != nil {
+		return s, "", err
+	}
+	if !s.Active() {
+		return s, "", ErrSessionEnded
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return s, "", err
+	}
+	now := time.Now()
+	update := bson.M{
+		"$set":  bson.M{"tokenhash": hashToken(token), "refreshedat": now, "expiresat": now.Add(RefreshTokenTTL)},
+		"$push": bson.M{"usedhashes": hash},
+	}
+	// matching the old hash lets only one of concurrent refreshes win
+	result, err := db.DB.Collection(db.SessionCollectionName).UpdateOne(context.TODO(), bson.M{"id": s.ID, "tokenhash": hash}, update)
+	if err != nil {
+		return s, "", err
+	}
+	if result.ModifiedCount == 0 {
+		return s, "", ErrUnknownRefreshToken
+	}
+	s.TokenHash, s.RefreshedAt, s.ExpiresAt = hashToken(token), now, now.Add(RefreshTokenTTL)
+	return s, token, nil
+}
+
+// RevokeSession logs the session of the user out
+func RevokeSession(id string, userID string) error {
+	collection := db.DB.Collection(db.SessionCollectionName)
+	filter := bson.M{"id": id, "userid": userID, "revokedat": bson.M{"$exists": false}}
+	updateResult, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": bson.M{"revokedat": time.Now()}})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Updated a single document:", updateResult)
+	return nil
+}
+
+// RevokeAllSessions logs the user out everywhere
+func RevokeAllSessions(userID string) error {
+	collection := db.DB.Collection(db.SessionCollectionName)
+	filter := bson.M{"userid": userID, "revokedat": bson.M{"$exists": false}}
+	updateResult, err := collection.UpdateMany(context.TODO(), filter, bson.M{"$set": bson.M{"revokedat": time.Now()}})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Updated documents:", updateResult)
+	return nil
+}
@@ -11,7 +11,6 @@ import (
 	guuid "github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Users is list of the User
@@ -31,10 +30,8 @@ type User struct {
 	// max length: 30
 	Name string `json:"name" validate:"required"`
 
-	// the password of the user, it is stored as salted hash
-	//
-	// required: true
-	Password string `json:"password"`
+	// salted hash of the password, it is never returned
+	Password string `json:"-"`
 
 	// email
 	//
@@ -42,10 +39,11 @@ type User struct {
 	// pattern: @^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$
 	Email string `json:"email" validate:"required"`
 
-	// token
-	//
-	// required: false
-	AccessToken string `json:"accessToken"`
+	// access token of a new or refreshed session, it isn't stored
+	AccessToken string `json:"-" bson:"-"`
+
+	// refresh token of a new or refreshed session, it isn't stored
+	RefreshToken string `json:"-" bson:"-"`
 
 	// role
 	//
@@ -56,6 +54,12 @@ type User struct {
 	//
 	// required: false
 	ProjectIDs []string `json:"projectIDs,omitempty" bson:"omitempty"`
+
+	// set on signup until the user confirms their email, unverified users
+	// can only read projects
+	//
+	// required: false
+	Unverified bool `json:"unverified,omitempty" bson:"unverified,omitempty"`
 }
 
 // GetAllUsers returns all users
@@ -89,22 +93,89 @@ func GetAllUsers() Users {
 	return result
 }
 
+// FindUsersSharingProjects returns the users who are members of a project
+// the user is a member of, and the user
+func FindUsersSharingProjects(userID string) (Users, error) {
+	projects := db.DB.Collection(db.ProjectCollectionName)
+	cur, err := projects.Find(context.TODO(), bson.M{"members.id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	userIDs := []string{userID}
+	for cur.Next(context.TODO()) {
+		var elem Project
+		if err := cur.Decode(&elem); err != nil {
+			return nil, err
+		}
+		for _, m := range elem.Members {
+			userIDs = append(userIDs, m.ID)
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	result := Users{}
+	users, err := db.DB.Collection(db.UserCollectionName).Find(context.TODO(), bson.M{"id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return result, err
+	}
+	defer users.Close(context.TODO())
+	for users.Next(context.TODO()) {
+		var elem User
+		if err := users.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, &elem)
+	}
+	return result, users.Err()
+}
+
 // AddUser adds a new user to the database
-func AddUser(u User) *User {
+func AddUser(u User) (*User, error) {
+	hash, err := HashAndSalt([]byte(u.Password))
+	if err != nil {
+		return nil, err
+	}
 	u.ID = guuid.New().String()
-	u.Password = HashAndSalt([]byte(u.Password))
+	u.Password = hash
 	u.Role = "developer"
+	u.Unverified = true
 
 	collection := db.DB.Collection(db.UserCollectionName)
 	insertResult, err := collection.InsertOne(context.TODO(), u)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
 
 	userList = append(userList, &u)
 
-	return &u
+	return &u, nil
+}
+
+// updatePassword hashes the password and stores it for the user matching the
+// filter, it reports whether a user matched
+func updatePassword(filter bson.M, password string) (bool, error) {
+	hash, err := HashAndSalt([]byte(password))
+	if err != nil {
+		return false, err
+	}
+	collection := db.DB.Collection(db.UserCollectionName)
+	updateResult, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": bson.M{"password": hash}})
+	if err != nil {
+		return false, err
+	}
+	fmt.Println("Updated a single document:", updateResult)
+	return updateResult.MatchedCount > 0, nil
+}
+
+// UpdateUserPassword hashes the password of the user again as the Hashing policy tells
+func UpdateUserPassword(userID string, password string) error {
+	_, err := updatePassword(bson.M{"id": userID}, password)
+	return err
 }
 
 // FindUserByID returns user or error
@@ -136,13 +207,15 @@ func GetUserIDFromContext(ctx context.Context) string {
 	return ""
 }
 
-// FindUserByAccessToken returns user or error
-func FindUserByAccessToken(token string) (User, error) {
-	collection := db.DB.Collection(db.UserCollectionName)
-	filter := bson.M{"accessToken": token}
-	var resultUser User
-	err := collection.FindOne(context.TODO(), filter).Decode(&resultUser)
-	return resultUser, err
+// GetSessionIDFromContext returns the session id from jwt token context
+func GetSessionIDFromContext(ctx context.Context) string {
+	if user := ctx.Value("user"); user != nil {
+		mapClaims := user.(*jwt.Token).Claims.(jwt.MapClaims)
+		if sessionID, ok := mapClaims["sid"].(string); ok {
+			return sessionID
+		}
+	}
+	return ""
 }
 
 // FindUserByEmail returns user or error
@@ -155,40 +228,6 @@ func FindUserByEmail(email string) (User, error) {
 	return resultUser, err
 }
 
-// FindUserAndUpdateAccessToken updates the user accesstoken
-func FindUserAndUpdateAccessToken(user User) (bson.M, error) {
-	exp := 5 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), exp)
-	defer cancel()
-
-	collection := db.DB.Collection(db.UserCollectionName)
-	// filter with internal id
-	filter := bson.M{"id": user.ID}
-
-	// Create the update
-	update := bson.M{
-		"$set": bson.M{"accesstoken": user.AccessToken},
-	}
-
-	// Create an instance of an options and set the desired options
-	upsert := true
-	after := options.After
-	opt := options.FindOneAndUpdateOptions{
-		ReturnDocument: &after,
-		Upsert:         &upsert,
-	}
-
-	// Find one result and update it
-	result := collection.FindOneAndUpdate(ctx, filter, update, &opt)
-	if result.Err() != nil {
-		return nil, result.Err()
-	}
-	// Decode the result
-	doc := bson.M{}
-	decodeErr := result.Decode(&doc)
-	return doc, decodeErr
-}
-
 // FindUserRole returns boolean value for about permission
 func FindUserRole(userID string) (string, error) {
 	user, err := FindUserByID(userID)
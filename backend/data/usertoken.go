@@ -0,0 +1,165 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "traceability/database"
+
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UserTokenPurpose tells what an emailed user token may be used for
+type UserTokenPurpose string
+
+const (
+	// PurposeVerifyEmail tokens confirm the email of a user
+	PurposeVerifyEmail UserTokenPurpose = "verify_email"
+	// PurposeResetPassword tokens set a new password of a user
+	PurposeResetPassword UserTokenPurpose = "reset_password"
+)
+
+var (
+	// EmailVerificationTTL is how long an email verification link works
+	EmailVerificationTTL = 48 * time.Hour
+	// PasswordResetTTL is how long a password reset link works
+	PasswordResetTTL = time.Hour
+)
+
+// ErrUnknownUserToken is returned when an emailed token is unknown, used or expired
+var ErrUnknownUserToken = errors.New("link is invalid, used or expired")
+
+// UserToken is an emailed token letting its holder verify the email or reset
+// the password of a user once. The token itself is signed, only its id is stored.
+type UserToken struct {
+	// id of the token, the signed token carries it
+	ID string
+
+	// id of the user
+	UserID string `bson:"userid"`
+
+	// what the token may be used for
+	Purpose UserTokenPurpose
+
+	// email the token was sent to, it stops working if the user's email changes
+	Email string
+
+	// time the token was created
+	CreatedAt time.Time `bson:"createdat"`
+
+	// time the token stops working
+	ExpiresAt time.Time `bson:"expiresat"`
+
+	// time the token was used
+	UsedAt *time.Time `bson:"usedat,omitempty"`
+}
+
+// AddUserToken creates a token of the user for the purpose
+func AddUserToken(user User, purpose UserTokenPurpose) (UserToken, error) {
+	ttl := EmailVerificationTTL
+	if purpose == PurposeResetPassword {
+		ttl = PasswordResetTTL
+	}
+	now := time.Now()
+	t := UserToken{
+		ID:        guuid.New().String(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	collection := db.DB.Collection(db.UserTokenCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), t)
+	if err != nil {
+		return t, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return t, nil
+}
+
+// UseUserToken marks the unused and unexpired token of the purpose as used
+// and returns it, every token is used once
+func UseUserToken(id string, purpose UserTokenPurpose) (UserToken, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"id": id, "purpose": purpose, "usedat": bson.M{"$exists": false}, "expiresat": bson.M{"$gt": now}}
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{ReturnDocument: &after}
+
+	var result UserToken
+	err := db.DB.Collection(db.UserTokenCollectionName).FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"usedat": now}}, &opt).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, ErrUnknownUserToken
+	}
+	return result, err
+}
+
+// IsEmailVerified reports whether the user confirmed their email, users
+// from before email verification count as verified
+func IsEmailVerified(userID string) bool {
+	user, err := FindUserByID(userID)
+	return err == nil && !user.Unverified
+}
+
+// MarkEmailVerified confirms the email of the user if it is still the user's email
+func MarkEmailVerified(userID string, email string) (bool, error) {
+	collection := db.DB.Collection(db.UserCollectionName)
+	filter := bson.M{"id": userID, "email": email}
+	updateResult, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$unset": bson.M{"unverified": ""}})
+	if err != nil {
+		return false, err
+	}
+	fmt.Println("Updated a single document:", updateResult)
+	return updateResult.MatchedCount > 0, nil
+}
+
+// VerifyEmail confirms the email of the user of the verification token
+func VerifyEmail(id string) (UserToken, error) {
+	t, err := UseUserToken(id, PurposeVerifyEmail)
+	if err != nil {
+		return t, err
+	}
+	matched, err := MarkEmailVerified(t.UserID, t.Email)
+	if err == nil && !matched {
+		err = ErrUnknownUserToken
+	}
+	return t, err
+}
+
+// ResetPassword sets the password of the user of the reset token. Other reset
+// tokens of the user stop working and all sessions are logged out. The reset
+// link was emailed, so the email is verified too.
+func ResetPassword(id string, password string) (UserToken, error) {
+	t, err := UseUserToken(id, PurposeResetPassword)
+	if err != nil {
+		return t, err
+	}
+
+	matched, err := updatePassword(bson.M{"id": t.UserID, "email": t.Email}, password)
+	if err != nil {
+		return t, err
+	}
+	if !matched {
+		return t, ErrUnknownUserToken
+	}
+
+	tokens := db.DB.Collection(db.UserTokenCollectionName)
+	filter := bson.M{"userid": t.UserID, "purpose": PurposeResetPassword, "usedat": bson.M{"$exists": false}}
+	if _, err := tokens.UpdateMany(context.TODO(), filter, bson.M{"$set": bson.M{"usedat": time.Now()}}); err != nil {
+		return t, err
+	}
+	if _, err := MarkEmailVerified(t.UserID, t.Email); err != nil {
+		return t, err
+	}
+	return t, RevokeAllSessions(t.UserID)
+}
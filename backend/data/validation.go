@@ -10,13 +10,29 @@ import (
 // expose this to out code
 type ValidationError struct {
 	validator.FieldError
+
+	// attribute is the name of the custom attribute, they are validated one
+	// by one and their errors have no namespace
+	attribute string
 }
 
 func (v ValidationError) Error() string {
+	namespace, field := v.Namespace(), v.Field()
+	if v.attribute != "" {
+		namespace, field = "ArchViewComponent.CustomAttributes."+v.attribute, v.attribute
+	}
+	if v.Tag() == "password" {
+		// tell which rule the password breaks, the tag alone doesn't
+		if pwd, ok := v.Value().(string); ok {
+			if err := Passwords.Check(pwd); err != nil {
+				return fmt.Sprintf("Key: '%s' Error: %s", namespace, err)
+			}
+		}
+	}
 	return fmt.Sprintf(
 		"Key: '%s' Error: Field validation for '%s' failed on the '%s' tag",
-		v.Namespace(),
-		v.Field(),
+		namespace,
+		field,
 		v.Tag(),
 	)
 }
@@ -42,7 +58,14 @@ type Validation struct {
 // NewValidation creates a new Validation type
 func NewValidation() *Validation {
 	validate := validator.New()
-	// validate.RegisterValidation("sku", validateSKU)
+	validate.RegisterValidation("viewkind", validateViewKind)
+	validate.RegisterValidation("attribute", validateAttributeType)
+	validate.RegisterValidation("user", validateUser)
+	validate.RegisterValidation("schema", validateSchema)
+	validate.RegisterValidation("event", validateEventType)
+	validate.RegisterValidation("permission", validatePermission)
+	validate.RegisterValidation("password", validatePassword)
+	validate.RegisterStructValidation(validateAttributeSchema, AttributeSchema{})
 
 	return &Validation{validate}
 }
@@ -54,7 +77,7 @@ func (v *Validation) Validate(i interface{}) ValidationErrors {
 		var returnErrs []ValidationError
 		for _, err := range errs.(validator.ValidationErrors) {
 			// cast the FieldError into our ValidationError and append to the slice
-			ve := ValidationError{err.(validator.FieldError)}
+			ve := ValidationError{FieldError: err.(validator.FieldError)}
 			returnErrs = append(returnErrs, ve)
 		}
 
@@ -0,0 +1,350 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	db "traceability/database"
+
+	"github.com/go-playground/validator/v10"
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Component attributes a view kind can allow, named like their json fields
+const (
+	// AttributeUserKind is the actor of a user story
+	AttributeUserKind = "userKind"
+	// AttributeFunctions is the FunctionList of a component
+	AttributeFunctions = "functions"
+	// AttributeVariables is the VarList of a component
+	AttributeVariables = "variables"
+	// AttributeTypes is the TypeList of a component
+	AttributeTypes = "types"
+)
+
+var (
+	// ErrUnknownViewKind is returned when a view kind is not defined in the project
+	ErrUnknownViewKind = errors.New("view kind is not defined in the project")
+	// ErrViewKindExists is returned when a view kind with the same name is defined already
+	ErrViewKindExists = errors.New("view kind is defined already")
+	// ErrBuiltInViewKind is returned when a built-in view kind is changed or deleted
+	ErrBuiltInViewKind = errors.New("built-in view kinds can't be changed, except for their attribute schemas")
+	// ErrViewKindInUse is returned when a view kind which still has views is deleted
+	ErrViewKindInUse = errors.New("view kind is used by views of the project")
+	// ErrComponentProject is returned when a component is put into a view of another project
+	ErrComponentProject = errors.New("component and view belong to different projects")
+	// ErrViewKindMismatch is returned when a component has another kind than its view
+	ErrViewKindMismatch = errors.New("component kind doesn't match the kind of its view")
+	// ErrAttributeNotAllowed is returned when a component sets an attribute its view kind doesn't allow
+	ErrAttributeNotAllowed = errors.New("attribute is not allowed")
+)
+
+// ViewKindDefinition describes a kind of architecture view, the kind of views
+// and components refers to the definition by its name
+// swagger:model
+type ViewKindDefinition struct {
+	// the id of the definition, empty for built-in kinds
+	//
+	// required: false
+	ID string `json:"id,omitempty"`
+
+	// belonging project's id, empty for built-in kinds
+	//
+	// required: false
+	ProjectID string `json:"projectID,omitempty" bson:"projectid"`
+
+	// name used as kind of views and components, e.g. "deployment"
+	//
+	// required: true
+	// max length: 30
+	Name string `json:"name" validate:"required,viewkind"`
+
+	// name shown to users, e.g. "Deployment View"
+	//
+	// required: false
+	Label string `json:"label"`
+
+	// icon hint for clients
+	//
+	// required: false
+	Icon string `json:"icon,omitempty" bson:"icon,omitempty"`
+
+	// colour hint for clients as #rrggbb
+	//
+	// required: false
+	Color string `json:"color,omitempty" bson:"color,omitempty" validate:"omitempty,hexcolor"`
+
+	// component attributes allowed in views of the kind, any of
+	// "userKind", "functions", "variables", "types"
+	//
+	// required: false
+	Attributes []string `json:"attributes" validate:"dive,oneof=userKind functions variables types"`
+
+	// custom attributes of the components in views of the kind, stored per
+	// project apart from the definition so built-in kinds can have them too
+	//
+	// required: false
+	AttributeSchemas []AttributeSchema `json:"attributeSchemas,omitempty" bson:"-" validate:"unique=Name,dive"`
+
+	// set for the kinds every project has
+	//
+	// required: false
+	BuiltIn bool `json:"builtIn" bson:"-"`
+}
+
+// builtInViewKinds are defined in every project
+var builtInViewKinds = []ViewKindDefinition{
+	{Name: string(UserStory), Label: "User Stories", Icon: "user", Color: "#4e79a7", Attributes: []string{AttributeUserKind}},
+	{Name: string(Functional), Label: "Functional", Icon: "sitemap", Color: "#59a14f", Attributes: []string{}},
+	{Name: string(Development), Label: "Development", Icon: "code", Color: "#f28e2b", Attributes: []string{AttributeFunctions, AttributeVariables, AttributeTypes}},
+}
+
+var viewKindPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,29}$`)
+
+// validateViewKind checks the syntax of a view kind name, whether the
+// project defines it is checked when views and components are stored
+func validateViewKind(fl validator.FieldLevel) bool {
+	return viewKindPattern.MatchString(fl.Field().String())
+}
+
+// Allows reports whether components of the kind may have the attribute
+func (k ViewKindDefinition) Allows(attribute string) bool {
+	for _, a := range k.Attributes {
+		if a == attribute {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckComponent returns an error if c has attributes the kind doesn't allow
+func (k ViewKindDefinition) CheckComponent(c ArchViewComponent) error {
+	used := map[string]bool{
+		AttributeUserKind:  c.UserKind != "",
+		AttributeFunctions: len(c.FunctionList) > 0,
+		AttributeVariables: len(c.VarList) > 0,
+		AttributeTypes:     len(c.TypeList) > 0,
+	}
+	for _, a := range []string{AttributeUserKind, AttributeFunctions, AttributeVariables, AttributeTypes} {
+		if used[a] && !k.Allows(a) {
+			return fmt.Errorf("%w: %s in %s views", ErrAttributeNotAllowed, a, k.Name)
+		}
+	}
+	return nil
+}
+
+// sameDefinition reports whether the kinds differ in nothing but their attribute schemas
+func sameDefinition(a ViewKindDefinition, b ViewKindDefinition) bool {
+	if a.Label != b.Label || a.Icon != b.Icon || a.Color != b.Color || len(a.Attributes) != len(b.Attributes) {
+		return false
+	}
+	for i := range a.Attributes {
+		if a.Attributes[i] != b.Attributes[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func builtInViewKind(name string) (ViewKindDefinition, bool) {
+	for _, k := range builtInViewKinds {
+		if k.Name == name {
+			k.BuiltIn = true
+			return k, true
+		}
+	}
+	return ViewKindDefinition{}, false
+}
+
+// FindViewKindsOfProject returns the built-in and the custom view kinds of the project sorted by name
+func FindViewKindsOfProject(projectID string) ([]ViewKindDefinition, error) {
+	result := []ViewKindDefinition{}
+	for _, k := range builtInViewKinds {
+		k.BuiltIn = true
+		result = append(result, k)
+	}
+
+	collection := db.DB.Collection(db.ViewKindCollectionName)
+	cur, err := collection.Find(context.TODO(), bson.M{"projectid": projectID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var elem ViewKindDefinition
+		if err := cur.Decode(&elem); err != nil {
+			return nil, err
+		}
+		result = append(result, elem)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	schemas, err := findProjectAttributeSchemas(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].AttributeSchemas = schemas[result[i].Name]
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// FindViewKind returns the view kind of the project with the name or ErrUnknownViewKind
+func FindViewKind(projectID string, name string) (ViewKindDefinition, error) {
+	result, ok := builtInViewKind(name)
+	if !ok {
+		exp := 5 * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), exp)
+		defer cancel()
+
+		collection := db.DB.Collection(db.ViewKindCollectionName)
+
+		filter := bson.M{"projectid": projectID, "name": name}
+		err := collection.FindOne(ctx, filter).Decode(&result)
+		if err == mongo.ErrNoDocuments {
+			return result, ErrUnknownViewKind
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	schemas, err := findKindAttributeSchemas(projectID, name)
+	if err != nil {
+		return result, err
+	}
+	result.AttributeSchemas = schemas
+	return result, nil
+}
+
+// AddViewKind adds a custom view kind to the project
+func AddViewKind(k ViewKindDefinition) (ViewKindDefinition, error) {
+	if _, err := FindViewKind(k.ProjectID, k.Name); err != ErrUnknownViewKind {
+		if err == nil {
+			err = ErrViewKindExists
+		}
+		return k, err
+	}
+	k.ID = guuid.New().String()
+	k.BuiltIn = false
+	if k.Attributes == nil {
+		k.Attributes = []string{}
+	}
+
+	collection := db.DB.Collection(db.ViewKindCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), k)
+	if err != nil {
+		return k, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	if len(k.AttributeSchemas) > 0 {
+		if err := setAttributeSchemas(k.ProjectID, k.Name, k.AttributeSchemas); err != nil {
+			return k, err
+		}
+	}
+	return k, nil
+}
+
+// UpdateViewKind replaces the custom view kind with the same id, the name
+// can't be changed. Of built-in kinds only the attribute schemas can be changed.
+func UpdateViewKind(k ViewKindDefinition) error {
+	if builtIn, ok := builtInViewKind(k.Name); ok {
+		if !sameDefinition(builtIn, k) {
+			return ErrBuiltInViewKind
+		}
+		return setAttributeSchemas(k.ProjectID, k.Name, k.AttributeSchemas)
+	}
+	if k.ID == "" {
+		return ErrBuiltInViewKind
+	}
+	collection := db.DB.Collection(db.ViewKindCollectionName)
+	query := bson.M{"id": k.ID, "projectid": k.ProjectID, "name": k.Name}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, k)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Replaced a single document:", replaceResult)
+	if replaceResult.MatchedCount == 0 {
+		return ErrUnknownViewKind
+	}
+	return setAttributeSchemas(k.ProjectID, k.Name, k.AttributeSchemas)
+}
+
+// DeleteViewKind removes the custom view kind from the project if no view uses it
+func DeleteViewKind(projectID string, name string) error {
+	if _, ok := builtInViewKind(name); ok {
+		return ErrBuiltInViewKind
+	}
+	views := db.DB.Collection(db.ArchViewCollectionName)
+	n, err := views.CountDocuments(context.TODO(), bson.M{"projectid": projectID, "kind": name})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrViewKindInUse
+	}
+
+	collection := db.DB.Collection(db.ViewKindCollectionName)
+	deleteResult, err := collection.DeleteOne(context.TODO(), bson.M{"projectid": projectID, "name": name})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Deleted a single document:", deleteResult)
+	if deleteResult.DeletedCount == 0 {
+		return ErrUnknownViewKind
+	}
+	return deleteAttributeSchemas(projectID, name)
+}
+
+// CheckArchViewComponent checks that the component belongs to the project
+// of its view, its kind against the view and the attributes it sets against
+// the kind definition. An empty kind is taken from the view, as is the kind
+// "none" of components stored before views had kinds.
+func CheckArchViewComponent(c *ArchViewComponent) error {
+	view, err := FindArchViewByID(c.ViewID)
+	if err != nil {
+		return err
+	}
+	if c.ProjectID == "" {
+		c.ProjectID = view.ProjectID
+	}
+	if c.ProjectID != view.ProjectID {
+		return ErrComponentProject
+	}
+	if c.Kind == "" || c.Kind == None {
+		c.Kind = ViewKind(view.Kind)
+	}
+	if string(c.Kind) != view.Kind {
+		return ErrViewKindMismatch
+	}
+	k, err := FindViewKind(view.ProjectID, view.Kind)
+	if err != nil {
+		return err
+	}
+	return k.CheckComponent(*c)
+}
+
+// FilterArchViewComponentsByKind returns the components of the kind
+func FilterArchViewComponentsByKind(components []ArchViewComponent, kind ViewKind) []ArchViewComponent {
+	result := []ArchViewComponent{}
+	for _, c := range components {
+		if c.Kind == kind {
+			result = append(result, c)
+		}
+	}
+	return result
+}
@@ -0,0 +1,285 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "traceability/database"
+
+	guuid "github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeliveryStatus is the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is waiting for its first attempt or a retry
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded got a 2xx response
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed ran out of attempts
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+var (
+	// ErrUnknownWebhook is returned when a webhook is not found in the project
+	ErrUnknownWebhook = errors.New("webhook not found")
+	// ErrUnknownDelivery is returned when a delivery is not found for the webhook
+	ErrUnknownDelivery = errors.New("delivery not found")
+	// ErrWebhookInactive is returned when a delivery of a deactivated webhook is replayed
+	ErrWebhookInactive = errors.New("webhook is not active")
+)
+
+// Webhook posts the events of a project to a url
+// swagger:model
+type Webhook struct {
+	// the id of the webhook
+	//
+	// required: false
+	ID string `json:"id"`
+
+	// belonging project's id
+	//
+	// required: false
+	ProjectID string `json:"projectID" bson:"projectid"`
+
+	// url the events are posted to
+	//
+	// required: true
+	URL string `json:"url" validate:"required,url"`
+
+	// event types posted, e.g. "component.created"
+	//
+	// required: true
+	Events []EventType `json:"events" validate:"required,min=1,dive,event"`
+
+	// key of the HMAC-SHA256 signature of the deliveries, generated if
+	// empty and only returned when the webhook is created
+	//
+	// required: false
+	Secret string `json:"secret,omitempty"`
+
+	// inactive webhooks get no deliveries
+	//
+	// required: false
+	Active bool `json:"active"`
+
+	// time the webhook was created
+	//
+	// required: false
+	CreatedAt time.Time `json:"createdAt" bson:"createdat"`
+}
+
+// DeliveryAttempt is one request of a delivery
+// swagger:model
+type DeliveryAttempt struct {
+	// time of the request
+	Time time.Time `json:"time"`
+
+	// http status code of the response, 0 if there was none
+	StatusCode int `json:"statusCode" bson:"statuscode"`
+
+	// error of the request or beginning of the response body
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+
+	// duration of the request in milliseconds
+	DurationMS int64 `json:"durationMs" bson:"durationms"`
+}
+
+// WebhookDelivery is the log of posting one event to a webhook
+// swagger:model
+type WebhookDelivery struct {
+	// the id of the delivery, sent in the X-Traceability-Delivery header
+	ID string `json:"id"`
+
+	// id of the webhook
+	WebhookID string `json:"webhookID" bson:"webhookid"`
+
+	// belonging project's id
+	ProjectID string `json:"projectID" bson:"projectid"`
+
+	// type of the delivered event
+	Event EventType `json:"event"`
+
+	// json body posted
+	Payload string `json:"payload"`
+
+	// "pending", "succeeded" or "failed"
+	Status DeliveryStatus `json:"status"`
+
+	// requests made, oldest first
+	Attempts []DeliveryAttempt `json:"attempts"`
+
+	// time of the next retry of pending deliveries
+	NextAttempt *time.Time `json:"nextAttempt,omitempty" bson:"nextattempt,omitempty"`
+
+	// time the event was emitted
+	CreatedAt time.Time `json:"createdAt" bson:"createdat"`
+}
+
+// AddWebhook adds a new active webhook to the project
+func AddWebhook(w Webhook) (Webhook, error) {
+	w.ID = guuid.New().String()
+	w.Active = true
+	w.CreatedAt = time.Now()
+
+	collection := db.DB.Collection(db.WebhookCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), w)
+	if err != nil {
+		return w, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return w, nil
+}
+
+func findWebhooks(filter bson.M) ([]Webhook, error) {
+	cur, err := db.DB.Collection(db.WebhookCollectionName).Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []Webhook{}
+	for cur.Next(context.TODO()) {
+		var elem Webhook
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
+// FindWebhooksOfProject returns the webhooks of the project
+func FindWebhooksOfProject(projectID string) ([]Webhook, error) {
+	return findWebhooks(bson.M{"projectid": projectID})
+}
+
+// FindWebhooksForEvent returns the active webhooks of the project posting events of the type
+func FindWebhooksForEvent(projectID string, t EventType) ([]Webhook, error) {
+	return findWebhooks(bson.M{"projectid": projectID, "active": true, "events": t})
+}
+
+// FindWebhookByID returns the webhook of the project or ErrUnknownWebhook
+func FindWebhookByID(projectID string, id string) (Webhook, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.WebhookCollectionName)
+
+	var result Webhook
+	err := collection.FindOne(ctx, bson.M{"projectid": projectID, "id": id}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, ErrUnknownWebhook
+	}
+	return result, err
+}
+
+// UpdateWebhook replaces the webhook with new one
+func UpdateWebhook(w Webhook) error {
+	collection := db.DB.Collection(db.WebhookCollectionName)
+	query := bson.M{"projectid": w.ProjectID, "id": w.ID}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, w)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Replaced a single document:", replaceResult)
+	if replaceResult.MatchedCount == 0 {
+		return ErrUnknownWebhook
+	}
+	return nil
+}
+
+// DeleteWebhook removes the webhook and its delivery logs from the project
+func DeleteWebhook(projectID string, id string) error {
+	collection := db.DB.Collection(db.WebhookCollectionName)
+	deleteResult, err := collection.DeleteOne(context.TODO(), bson.M{"projectid": projectID, "id": id})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Deleted a single document:", deleteResult)
+	if deleteResult.DeletedCount == 0 {
+		return ErrUnknownWebhook
+	}
+	_, err = db.DB.Collection(db.WebhookDeliveryCollectionName).DeleteMany(context.TODO(), bson.M{"webhookid": id})
+	return err
+}
+
+// AddWebhookDelivery stores a new pending delivery
+func AddWebhookDelivery(d WebhookDelivery) (WebhookDelivery, error) {
+	d.ID = guuid.New().String()
+	d.Status = DeliveryPending
+	d.Attempts = []DeliveryAttempt{}
+
+	collection := db.DB.Collection(db.WebhookDeliveryCollectionName)
+	insertResult, err := collection.InsertOne(context.TODO(), d)
+	if err != nil {
+		return d, err
+	}
+	fmt.Println("Inserted a single document: ", insertResult.InsertedID)
+	return d, nil
+}
+
+// FindWebhookDelivery returns the delivery of the webhook or ErrUnknownDelivery
+func FindWebhookDelivery(webhookID string, id string) (WebhookDelivery, error) {
+	exp := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), exp)
+	defer cancel()
+
+	collection := db.DB.Collection(db.WebhookDeliveryCollectionName)
+
+	var result WebhookDelivery
+	err := collection.FindOne(ctx, bson.M{"webhookid": webhookID, "id": id}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return result, ErrUnknownDelivery
+	}
+	return result, err
+}
+
+func findWebhookDeliveries(filter bson.M, opts *options.FindOptions) ([]WebhookDelivery, error) {
+	cur, err := db.DB.Collection(db.WebhookDeliveryCollectionName).Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context.TODO())
+
+	result := []WebhookDelivery{}
+	for cur.Next(context.TODO()) {
+		var elem WebhookDelivery
+		if err := cur.Decode(&elem); err != nil {
+			return result, err
+		}
+		result = append(result, elem)
+	}
+
+	return result, cur.Err()
+}
+
+// FindWebhookDeliveries returns the newest deliveries of the webhook
+func FindWebhookDeliveries(webhookID string, limit int64) ([]WebhookDelivery, error) {
+	opts := options.Find().SetSort(bson.M{"createdat": -1}).SetLimit(limit)
+	return findWebhookDeliveries(bson.M{"webhookid": webhookID}, opts)
+}
+
+// FindPendingWebhookDeliveries returns the deliveries waiting for an attempt
+func FindPendingWebhookDeliveries() ([]WebhookDelivery, error) {
+	return findWebhookDeliveries(bson.M{"status": DeliveryPending}, options.Find())
+}
+
+// UpdateWebhookDelivery replaces the delivery with new one
+func UpdateWebhookDelivery(d WebhookDelivery) error {
+	collection := db.DB.Collection(db.WebhookDeliveryCollectionName)
+	query := bson.M{"id": d.ID}
+
+	replaceResult, err := collection.ReplaceOne(context.TODO(), query, d)
+	fmt.Println("Replaced a single document:", replaceResult)
+	return err
+}
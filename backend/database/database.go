@@ -1,6 +1,11 @@
 package database
 
-import "go.mongodb.org/mongo-driver/mongo"
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
 
 const (
 	// UserCollectionName is the table name of the users
@@ -17,6 +22,69 @@ const (
 
 	// LinkCollectionName is the table name of the links
 	LinkCollectionName = "links"
+
+	// CommitCollectionName is the table name of the commits referencing components
+	CommitCollectionName = "commits"
+
+	// GitScanCollectionName is the table name of the last scanned commit per repository
+	GitScanCollectionName = "gitscans"
+
+	// DriftCollectionName is the table name of the drift reports between code and development view
+	DriftCollectionName = "drifts"
+
+	// ViewKindCollectionName is the table name of the custom view kinds of projects
+	ViewKindCollectionName = "viewkinds"
+
+	// AttributeSchemaCollectionName is the table name of the attribute schemas of the view kinds of projects
+	AttributeSchemaCollectionName = "attributeschemas"
+
+	// LabelCollectionName is the table name of the labels of projects
+	LabelCollectionName = "labels"
+
+	// CommentCollectionName is the table name of the comments on components, links and views
+	CommentCollectionName = "comments"
+
+	// WatchCollectionName is the table name of the watched projects, views and components
+	WatchCollectionName = "watches"
+
+	// NotificationCollectionName is the table name of the notifications in the inboxes of users
+	NotificationCollectionName = "notifications"
+
+	// NotificationPreferencesCollectionName is the table name of the notification preferences of users
+	NotificationPreferencesCollectionName = "notificationpreferences"
+
+	// WebhookCollectionName is the table name of the webhooks of projects
+	WebhookCollectionName = "webhooks"
+
+	// WebhookDeliveryCollectionName is the table name of the delivery logs of webhooks
+	WebhookDeliveryCollectionName = "webhookdeliveries"
+
+	// ChangeCollectionName is the table name of the change feeds of projects
+	ChangeCollectionName = "changes"
+
+	// CounterCollectionName is the table name of sequence counters
+	CounterCollectionName = "counters"
+
+	// RoleCollectionName is the table name of the custom roles of projects
+	RoleCollectionName = "roles"
+
+	// InvitationCollectionName is the table name of the invitations to projects
+	InvitationCollectionName = "invitations"
+
+	// IdentityCollectionName is the table name of the accounts of users at login providers
+	IdentityCollectionName = "identities"
+
+	// LoginStateCollectionName is the table name of the logins in progress at login providers
+	LoginStateCollectionName = "loginstates"
+
+	// SessionCollectionName is the table name of the login sessions with their refresh tokens
+	SessionCollectionName = "sessions"
+
+	// AccessTokenCollectionName is the table name of the personal access tokens of users
+	AccessTokenCollectionName = "accesstokens"
+
+	// UserTokenCollectionName is the table name of the emailed email verification and password reset tokens
+	UserTokenCollectionName = "usertokens"
 )
 
 var (
@@ -25,3 +93,22 @@ var (
 	// DB is the database
 	DB *mongo.Database
 )
+
+// Connect connects to the MongoDB at uri and sets DBCon and DB
+func Connect(uri string, name string) error {
+	clientOptions := options.Client().ApplyURI(uri)
+
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
+		return err
+	}
+
+	// Check the connection
+	err = client.Ping(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+	DBCon = client
+	DB = client.Database(name)
+	return nil
+}
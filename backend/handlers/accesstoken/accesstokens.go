@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"log"
+	"traceability/data"
+)
+
+// KeyAccessToken is a key used for the personal access token object in the context
+type KeyAccessToken struct{}
+
+// AccessTokens handler manages the personal access tokens of users
+type AccessTokens struct {
+	l *log.Logger
+	v *data.Validation
+}
+
+// NewAccessTokens returns a new access tokens handler with the given logger
+func NewAccessTokens(l *log.Logger, v *data.Validation) *AccessTokens {
+	return &AccessTokens{l, v}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route DELETE /tokens/{tokenID}/ RevokeAccessToken
+// Revoke a personal access token, it stops working right away
+//
+// responses:
+//	200: accessTokenResponse
+//  403: errorResponse
+//  404: errorResponse
+
+// RevokeAccessToken handles DELETE requests and revokes a token of the user
+func (a *AccessTokens) RevokeAccessToken(rw http.ResponseWriter, r *http.Request) {
+	userID := data.GetUserIDFromContext(r.Context())
+
+	token, err := data.RevokeAccessToken(userID, mux.Vars(r)["tokenID"])
+	if err == data.ErrUnknownAccessToken {
+		http.Error(rw, `{{"error": "access token not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "access token couldn't be revoked"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(token, rw)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	data "traceability/data"
+)
+
+// swagger:route GET /tokens/ ListAccessTokens
+// Return the personal access tokens of the user without the tokens themselves
+//
+// responses:
+//	200: accessTokensResponse
+//  403: errorResponse
+
+// ListAccessTokens handles GET requests and returns the tokens of the user
+func (a *AccessTokens) ListAccessTokens(rw http.ResponseWriter, r *http.Request) {
+	userID := data.GetUserIDFromContext(r.Context())
+
+	tokens, err := data.FindAccessTokensOfUser(userID)
+	if err != nil {
+		http.Error(rw, `{{"error": "access tokens not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(tokens, rw)
+}
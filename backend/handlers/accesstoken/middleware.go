@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"traceability/data"
+)
+
+// MiddlewareValidateAccessToken validates the access token in the request and calls next if ok
+func (a *AccessTokens) MiddlewareValidateAccessToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		token := &data.PersonalAccessToken{}
+
+		err := data.FromJSON(token, r.Body)
+		if err != nil {
+			a.l.Println("[ERROR] deserializing access token", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+
+		// validate the access token
+		errs := a.v.Validate(token)
+		if len(errs) != 0 {
+
+			a.l.Println("[ERROR] validating access token", errs)
+
+			// return the validation messages as an array
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// add the access token to the context
+		ctx := context.WithValue(r.Context(), KeyAccessToken{}, token)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	data "traceability/data"
+)
+
+// swagger:route POST /tokens/ CreateAccessToken
+// Create a named personal access token, optionally read-only, limited to
+// projects and expiring. The token is only returned here.
+//
+// responses:
+//	200: accessTokenResponse
+//  403: errorResponse
+//  422: errorValidation
+
+// CreateAccessToken handles POST requests to create a personal access token
+func (a *AccessTokens) CreateAccessToken(rw http.ResponseWriter, r *http.Request) {
+	token := r.Context().Value(KeyAccessToken{}).(*data.PersonalAccessToken)
+
+	userID := data.GetUserIDFromContext(r.Context())
+
+	token.UserID = userID
+	added, err := data.AddAccessToken(*token)
+	switch err {
+	case nil:
+		data.ToJSON(added, rw)
+	case data.ErrNotProjectMember:
+		rw.WriteHeader(http.StatusForbidden)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	case data.ErrExpiryInPast:
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	default:
+		http.Error(rw, `{{"error": "access token couldn't be created"}}`, http.StatusInternalServerError)
+	}
+}
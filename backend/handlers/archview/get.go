@@ -7,6 +7,7 @@ import (
 	data "traceability/data"
 
 	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // GetArchView handles GET requests and returns the archview by ID
@@ -20,17 +21,21 @@ func (aw *ArchViews) GetArchView(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	archView, err := data.FindArchViewByID(id)
-
+	archView, err := data.FindProjectArchView(vars["projectID"], id)
+	if err == mongo.ErrNoDocuments {
+		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		io.WriteString(rw, `{{"error": "architecture view not found"}}`)
+		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusInternalServerError)
 		return
 	}
 
 	err = data.ToJSON(archView, rw)
 }
 
-// ListArchViews handles GET requests and returns the list of archviews belongs to the project
+// ListArchViews handles GET requests and returns the list of archviews belongs
+// to the project, the labels parameter filters them by a label expression
 func (aw *ArchViews) ListArchViews(rw http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
@@ -41,7 +46,14 @@ func (aw *ArchViews) ListArchViews(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	archViews, err := data.FindArchViewsOfProject(id)
+	labels, err := data.ParseLabelFilter(id, r.URL.Query().Get("labels"))
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	archViews, err := data.FindArchViewsOfProjectWithLabels(id, labels)
 
 	if err != nil {
 		io.WriteString(rw, `{{"error": "architecture view not found"}}`)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 	"traceability/data"
+
+	"github.com/gorilla/mux"
 )
 
 // MiddlewareValidateArchView validates the project in the request and calls next if ok
@@ -33,6 +35,18 @@ func (aw *ArchViews) MiddlewareValidateArchView(next http.Handler) http.Handler
 			return
 		}
 
+		// attached labels have to be labels of the project
+		err = data.CheckLabels(mux.Vars(r)["projectID"], archView.Labels)
+		if err == data.ErrUnknownLabel {
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+			return
+		}
+		if err != nil {
+			http.Error(rw, `{{"error": "labels not found"}}`, http.StatusInternalServerError)
+			return
+		}
+
 		// add the archview to the context
 		ctx := context.WithValue(r.Context(), KeyArchView{}, archView)
 		r = r.WithContext(ctx)
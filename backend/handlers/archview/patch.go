@@ -10,6 +10,7 @@ import (
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // UpdateArchView handles PATCH requests and updates archview
@@ -23,12 +24,15 @@ func (aw *ArchViews) UpdateArchView(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	archView, err := data.FindArchViewByID(id)
-
-	if err != nil {
+	archView, err := data.FindProjectArchView(vars["projectID"], id)
+	if err == mongo.ErrNoDocuments {
 		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusInternalServerError)
+		return
+	}
 	jsonArch, err := json.Marshal(archView)
 	if err != nil {
 		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusInternalServerError)
@@ -37,6 +41,43 @@ func (aw *ArchViews) UpdateArchView(rw http.ResponseWriter, r *http.Request) {
 	modifiedJSON, err := jsonpatch.MergePatch(jsonArch, jsonBody)
 	modifiedArchView := &data.ArchView{}
 	err = json.Unmarshal(modifiedJSON, modifiedArchView)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	// the patch can't move the view to another project
+	modifiedArchView.ID, modifiedArchView.ProjectID = archView.ID, archView.ProjectID
+	if modifiedArchView.Kind != archView.Kind {
+		// the components keep the kind of their view
+		_, err := data.FindViewKind(archView.ProjectID, modifiedArchView.Kind)
+		if err == nil && len(archView.Components) > 0 {
+			err = data.ErrViewKindMismatch
+		}
+		switch err {
+		case nil:
+		case data.ErrUnknownViewKind, data.ErrViewKindMismatch:
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+			return
+		default:
+			http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	// attached labels have to be labels of the project
+	err = data.CheckLabels(archView.ProjectID, modifiedArchView.Labels)
+	if err == data.ErrUnknownLabel {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "labels not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	modifiedArchView.Actor = data.GetUserIDFromContext(r.Context())
 	data.UpdateArchView(*modifiedArchView)
 	err = data.ToJSON(modifiedArchView, rw)
 }
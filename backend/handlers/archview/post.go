@@ -29,7 +29,13 @@ func (aw *ArchViews) CreateArchView(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	archView.ProjectID = projectID
+	archView.Actor = data.GetUserIDFromContext(r.Context())
 	_, err := data.AddArchView(*archView)
+	if err == data.ErrUnknownViewKind {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+		return
+	}
 	if err != nil {
 		io.WriteString(rw, `{{"error": "architecture view couldn't be added"}}`)
 	}
@@ -7,6 +7,7 @@ import (
 	data "traceability/data"
 
 	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // GetArchViewComponent handles GET requests and returns the archview by ID
@@ -20,8 +21,11 @@ func (ac *ArchViewComponents) GetArchViewComponent(rw http.ResponseWriter, r *ht
 		return
 	}
 
-	archViewComponent, err := data.FindArchViewComponentByID(id)
-
+	archViewComponent, err := data.FindProjectArchViewComponent(vars["projectID"], id)
+	if err == mongo.ErrNoDocuments || (err == nil && archViewComponent.ViewID != vars["viewID"]) {
+		http.Error(rw, `{{"error": "component not found"}}`, http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(rw, `{{"error": "component not found"}}`, http.StatusInternalServerError)
 		return
@@ -30,7 +34,9 @@ func (ac *ArchViewComponents) GetArchViewComponent(rw http.ResponseWriter, r *ht
 	err = data.ToJSON(archViewComponent, rw)
 }
 
-// ListArchViewComponents handles GET requests and returns the archview by ID
+// ListArchViewComponents handles GET requests and returns the components of
+// the view, filtered by custom attributes with "attr.<name>=<value>" parameters
+// and by a label expression like "labels=backend AND NOT legacy"
 func (ac *ArchViewComponents) ListArchViewComponents(rw http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
@@ -41,7 +47,22 @@ func (ac *ArchViewComponents) ListArchViewComponents(rw http.ResponseWriter, r *
 		return
 	}
 
-	archViewComponents, err := data.FindArchViewComponentsByViewID(viewID)
+	_, err := data.FindProjectArchView(vars["projectID"], viewID)
+	if err == mongo.ErrNoDocuments {
+		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	filter, ok := ac.componentFilter(rw, r, vars["projectID"])
+	if !ok {
+		return
+	}
+
+	archViewComponents, err := data.FindArchViewComponentsByViewIDAndFilter(viewID, filter)
 
 	if err != nil {
 		http.Error(rw, `{{"error": "component not found"}}`, http.StatusInternalServerError)
@@ -51,7 +72,10 @@ func (ac *ArchViewComponents) ListArchViewComponents(rw http.ResponseWriter, r *
 	err = data.ToJSON(archViewComponents, rw)
 }
 
-// ListAllComponents handles GET requests
+// ListAllComponents handles GET requests and returns the components of the
+// project, the kind query parameter keeps the components of views of that kind
+// only, "attr.<name>=<value>" parameters filter by custom attributes and
+// the labels parameter by a label expression
 func (ac *ArchViewComponents) ListAllComponents(rw http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
@@ -62,12 +86,80 @@ func (ac *ArchViewComponents) ListAllComponents(rw http.ResponseWriter, r *http.
 		return
 	}
 
-	archViewComponents, err := data.FindArchViewComponentsByProjectID(projectID)
+	kind := r.URL.Query().Get("kind")
+	if kind != "" {
+		_, err := data.FindViewKind(projectID, kind)
+		if err == data.ErrUnknownViewKind {
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+		if err != nil {
+			http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	filter, ok := ac.componentFilter(rw, r, projectID)
+	if !ok {
+		return
+	}
+
+	archViewComponents, err := data.FindArchViewComponentsByProjectIDAndFilter(projectID, filter)
 
 	if err != nil {
 		http.Error(rw, `{{"error": "component not found"}}`, http.StatusInternalServerError)
 		return
 	}
+	if kind != "" {
+		archViewComponents = data.FilterArchViewComponentsByKind(archViewComponents, data.ViewKind(kind))
+	}
 
 	err = data.ToJSON(archViewComponents, rw)
 }
+
+// ListCommits handles GET requests and returns the commits referencing the component, newest first
+func (ac *ArchViewComponents) ListCommits(rw http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	id, ok := vars["componentID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	commits, err := data.FindCommitsOfComponent(vars["projectID"], id)
+
+	if err != nil {
+		http.Error(rw, `{{"error": "commits not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	err = data.ToJSON(commits, rw)
+}
+
+// componentFilter parses the custom attribute and label filters of the
+// request, it writes the error response and returns false if they are invalid
+func (ac *ArchViewComponents) componentFilter(rw http.ResponseWriter, r *http.Request, projectID string) (data.ComponentFilter, bool) {
+	kinds, err := data.FindViewKindsOfProject(projectID)
+	if err != nil {
+		http.Error(rw, `{{"error": "view kinds not found"}}`, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	filter, err := data.ParseComponentFilter(r.URL.Query(), kinds)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return nil, false
+	}
+
+	labels, err := data.ParseLabelFilter(projectID, r.URL.Query().Get("labels"))
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return nil, false
+	}
+	return filter.With(labels), true
+}
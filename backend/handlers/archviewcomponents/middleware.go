@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	data "traceability/data"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // MiddlewareValidateArchViewComponent validates the component in the request and calls next if ok
@@ -21,6 +24,9 @@ func (ac *ArchViewComponents) MiddlewareValidateArchViewComponent(next http.Hand
 			data.ToJSON(&GenericError{Message: err.Error()}, rw)
 			return
 		}
+		// the component belongs to the project and view of the route
+		vars := mux.Vars(r)
+		archViewComponent.ProjectID, archViewComponent.ViewID = vars["projectID"], vars["viewID"]
 
 		// validate the component
 		errs := ac.v.Validate(archViewComponent)
@@ -34,6 +40,38 @@ func (ac *ArchViewComponents) MiddlewareValidateArchViewComponent(next http.Hand
 			return
 		}
 
+		// validate the custom attributes against the schemas of the view kind
+		schemas, err := data.FindAttributeSchemas(*archViewComponent)
+		if err == mongo.ErrNoDocuments {
+			http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusNotFound)
+			return
+		}
+		if err != nil && err != data.ErrUnknownViewKind {
+			http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusInternalServerError)
+			return
+		}
+		errs = ac.v.ValidateAttributes(archViewComponent.CustomAttributes, schemas)
+		if len(errs) != 0 {
+
+			ac.l.Println("[ERROR] validating component attributes", errs)
+
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// attached labels have to be labels of the project
+		err = data.CheckLabels(vars["projectID"], archViewComponent.Labels)
+		if err == data.ErrUnknownLabel {
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+			return
+		}
+		if err != nil {
+			http.Error(rw, `{{"error": "labels not found"}}`, http.StatusInternalServerError)
+			return
+		}
+
 		// add the archview component to the context
 		ctx := context.WithValue(r.Context(), KeyArchViewComponent{}, archViewComponent)
 		r = r.WithContext(ctx)
@@ -10,6 +10,7 @@ import (
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // UpdateArchViewComponent handles PATCH requests and updates archview component
@@ -24,12 +25,15 @@ func (ac *ArchViewComponents) UpdateArchViewComponent(rw http.ResponseWriter, r
 		return
 	}
 
-	component, err := data.FindArchViewComponentByID(id)
-
-	if err != nil {
+	component, err := data.FindProjectArchViewComponent(vars["projectID"], id)
+	if err == mongo.ErrNoDocuments || (err == nil && component.ViewID != vars["viewID"]) {
 		http.Error(rw, `{{"error": "component view not found"}}`, http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(rw, `{{"error": "component view not found"}}`, http.StatusInternalServerError)
+		return
+	}
 	jsonProj, err := json.Marshal(component)
 	if err != nil {
 		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusInternalServerError)
@@ -38,6 +42,54 @@ func (ac *ArchViewComponents) UpdateArchViewComponent(rw http.ResponseWriter, r
 	modifiedJSON, err := jsonpatch.MergePatch(jsonProj, jsonBody)
 	modifiedComponent := &data.ArchViewComponent{}
 	err = json.Unmarshal(modifiedJSON, modifiedComponent)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	// the patch can't move the component to another project
+	modifiedComponent.ID, modifiedComponent.ProjectID = component.ID, component.ProjectID
+
+	errs := ac.v.Validate(modifiedComponent)
+	if len(errs) != 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+		return
+	}
+	err = data.CheckArchViewComponent(modifiedComponent)
+	if isViewKindError(err) {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	schemas, err := data.FindAttributeSchemas(*modifiedComponent)
+	if err != nil {
+		http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	errs = ac.v.ValidateAttributes(modifiedComponent.CustomAttributes, schemas)
+	if len(errs) != 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+		return
+	}
+	// attached labels have to be labels of the project
+	err = data.CheckLabels(modifiedComponent.ProjectID, modifiedComponent.Labels)
+	if err == data.ErrUnknownLabel {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "labels not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	modifiedComponent.Actor = data.GetUserIDFromContext(r.Context())
 	data.UpdateArchViewComponent(*modifiedComponent)
 	err = data.ToJSON(modifiedComponent, rw)
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	data "traceability/data"
 )
@@ -17,6 +18,20 @@ import (
 func (ac *ArchViewComponents) AddArchViewComponent(rw http.ResponseWriter, r *http.Request) {
 	archViewComponent := r.Context().Value(KeyArchViewComponent{}).(*data.ArchViewComponent)
 
+	archViewComponent.Actor = data.GetUserIDFromContext(r.Context())
 	ac.l.Printf("[DEBUG] Inserting archview component: %#v, to project", archViewComponent)
-	data.AddArchViewComponent(*archViewComponent)
+	_, err := data.AddArchViewComponent(*archViewComponent)
+	if isViewKindError(err) {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "component couldn't be added"}}`, http.StatusInternalServerError)
+	}
+}
+
+// isViewKindError reports whether err rejects the component for the kind of its view
+func isViewKindError(err error) bool {
+	return err == data.ErrUnknownViewKind || err == data.ErrViewKindMismatch || err == data.ErrComponentProject || errors.Is(err, data.ErrAttributeNotAllowed)
 }
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"log"
+	"traceability/data"
+)
+
+// Changes handler serves the change feeds of projects
+type Changes struct {
+	l *log.Logger
+	v *data.Validation
+}
+
+// NewChanges returns a new changes handler with the given logger
+func NewChanges(l *log.Logger, v *data.Validation) *Changes {
+	return &Changes{l, v}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+)
+
+// swagger:route GET /projects/{projectID}/changes ListChanges
+// Return the changes of the project after the cursor "?since=", oldest first.
+// Without since the feed starts at the beginning, "?limit=" is up to 1000.
+// Clients keep the cursor of the page and resume with it later.
+//
+// responses:
+//	200: changePageResponse
+//  400: errorResponse
+
+// ListChanges handles GET requests and returns a page of the change feed
+func (ch *Changes) ListChanges(rw http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["projectID"]
+	query := r.URL.Query()
+
+	limit := int64(defaultChangesLimit)
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 || n > maxChangesLimit {
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: "limit has to be between 1 and 1000"}, rw)
+			return
+		}
+		limit = n
+	}
+
+	page, err := data.FindChanges(projectID, query.Get("since"), limit)
+	if err == data.ErrInvalidCursor {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "changes not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(page, rw)
+}
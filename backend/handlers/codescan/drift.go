@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"traceability/codescan"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ApplyDriftRequest selects the changes of a drift report to apply
+// swagger:model
+type ApplyDriftRequest struct {
+	// ids of the changes to apply, all pending changes if empty
+	//
+	// required: false
+	Changes []string `json:"changes"`
+}
+
+// swagger:route POST /projects/{projectID}/drift DetectDrift
+// Compare a zip archive of a Go module with the development view
+//
+// responses:
+//	200: driftReport
+//  400: errorResponse
+
+// DetectDrift handles POST requests with a zip archive of a Go module and stores the differences to the development view as a drift report
+func (s *Scans) DetectDrift(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	extendDeadlines(rw)
+	dir, err := codescan.ExtractZip(r.Body)
+	if err != nil {
+		s.l.Println("[ERROR] extracting go module", err)
+
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	root := codescan.FindModuleRoot(dir)
+	pkgs, err := codescan.ScanGoModule(root)
+	if err != nil {
+		s.l.Println("[ERROR] scanning go module", err)
+
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	report, err := codescan.DetectDrift(projectID, codescan.ModulePath(root), pkgs)
+	if err != nil {
+		s.l.Println("[ERROR] detecting drift", err)
+		http.Error(rw, `{{"error": "drift report couldn't be created"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(report, rw)
+}
+
+// swagger:route GET /projects/{projectID}/drift/{driftID} GetDrift
+// Return a drift report of the project
+//
+// responses:
+//	200: driftReport
+//  404: errorResponse
+
+// GetDrift handles GET requests and returns the drift report
+func (s *Scans) GetDrift(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	driftID, ok := vars["driftID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	report, err := data.FindDriftReportByID(projectID, driftID)
+	if err != nil {
+		s.l.Println("[ERROR] finding drift report", err)
+		http.Error(rw, `{{"error": "drift report not found"}}`, http.StatusNotFound)
+		return
+	}
+
+	data.ToJSON(report, rw)
+}
+
+// swagger:route POST /projects/{projectID}/drift/{driftID}/apply ApplyDrift
+// Apply the selected changes of a drift report to the development view
+//
+// responses:
+//	200: driftReport
+//  400: errorResponse
+//  404: errorResponse
+//  409: errorResponse
+
+// ApplyDrift handles POST requests and applies the selected changes of the drift report
+func (s *Scans) ApplyDrift(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	driftID, ok := vars["driftID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	req := &ApplyDriftRequest{}
+	if r.ContentLength != 0 {
+		err := data.FromJSON(req, r.Body)
+		if err != nil && err != io.EOF {
+			s.l.Println("[ERROR] deserializing drift selection", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+	}
+
+	report, err := codescan.ApplyDrift(projectID, driftID, req.Changes)
+	switch err {
+	case nil:
+		data.ToJSON(report, rw)
+	case mongo.ErrNoDocuments:
+		http.Error(rw, `{{"error": "drift report not found"}}`, http.StatusNotFound)
+	case codescan.ErrDriftApplied, data.ErrLinkComponent:
+		// a linked component deleted meanwhile leaves its change pending
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	default:
+		s.l.Println("[ERROR] applying drift", err)
+		http.Error(rw, `{{"error": "drift report couldn't be applied"}}`, http.StatusInternalServerError)
+	}
+}
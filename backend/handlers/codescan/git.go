@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"traceability/codescan"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// GitScanRequest names the local repository to scan
+// swagger:model
+type GitScanRequest struct {
+	// path of a local clone of the repository on the server, relative to
+	// the repository root of the server
+	//
+	// required: true
+	Path string `json:"path" validate:"required"`
+
+	// scan the whole history instead of continuing after the last scan
+	//
+	// required: false
+	Full bool `json:"full"`
+}
+
+// swagger:route POST /projects/{projectID}/scans/git ScanGitHistory
+// Record commits referencing components as trace evidence
+//
+// responses:
+//	200: gitReport
+//  400: errorResponse
+//  403: errorResponse
+//  422: errorValidation
+
+// ScanGitHistory handles POST requests and scans the commit messages of a local repository
+func (s *Scans) ScanGitHistory(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	req := &GitScanRequest{}
+	err := data.FromJSON(req, r.Body)
+	if err != nil {
+		s.l.Println("[ERROR] deserializing git scan", err)
+
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	errs := s.v.Validate(req)
+	if len(errs) != 0 {
+		s.l.Println("[ERROR] validating git scan", errs)
+
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+		return
+	}
+
+	report, err := codescan.SyncGitHistory(projectID, codescan.RepositoryRoot, req.Path, req.Full)
+	if err == codescan.ErrRepositoryOutsideRoot {
+		rw.WriteHeader(http.StatusForbidden)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if err != nil {
+		s.l.Println("[ERROR] scanning git history", err)
+
+		// the git errors name paths of the server
+		http.Error(rw, `{{"error": "repository couldn't be scanned"}}`, http.StatusBadRequest)
+		return
+	}
+
+	data.ToJSON(report, rw)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"traceability/codescan"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /projects/{projectID}/scans/go ScanGoModule
+// Populate the development view from a zip archive of a Go module
+//
+// responses:
+//	200: syncReport
+//  400: errorResponse
+
+// ScanGoModule handles POST requests with a zip archive of a Go module and syncs its packages to the development view
+func (s *Scans) ScanGoModule(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	extendDeadlines(rw)
+	dir, err := codescan.ExtractZip(r.Body)
+	if err != nil {
+		s.l.Println("[ERROR] extracting go module", err)
+
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	pkgs, err := codescan.ScanGoModule(codescan.FindModuleRoot(dir))
+	if err != nil {
+		s.l.Println("[ERROR] scanning go module", err)
+
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	report, err := codescan.SyncDevelopmentView(projectID, pkgs)
+	if err != nil {
+		s.l.Println("[ERROR] syncing development view", err)
+		http.Error(rw, `{{"error": "development view couldn't be updated"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(report, rw)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+	"traceability/data"
+)
+
+// scanTimeout is how long reading an uploaded archive and scanning it may
+// take, archives of up to codescan.MaxArchiveSize take longer than the
+// server's read and write timeouts
+const scanTimeout = 5 * time.Minute
+
+// Scans handler updates projects from uploaded source code
+type Scans struct {
+	l *log.Logger
+	v *data.Validation
+}
+
+// NewScans returns a new scans handler with the given logger
+func NewScans(l *log.Logger, v *data.Validation) *Scans {
+	return &Scans{l, v}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
+
+// extendDeadlines gives the upload and the scan of an archive scanTimeout
+// to finish instead of the server's timeouts
+func extendDeadlines(rw http.ResponseWriter) {
+	rc := http.NewResponseController(rw)
+	deadline := time.Now().Add(scanTimeout)
+	if err := rc.SetReadDeadline(deadline); err != nil && err != http.ErrNotSupported {
+		return
+	}
+	rc.SetWriteDeadline(deadline)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"traceability/codescan"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /projects/{projectID}/scans/trace ScanTraceAnnotations
+// Link components to the code annotated with "// trace: <ref>" comments
+//
+// responses:
+//	200: traceReport
+//  400: errorResponse
+
+// ScanTraceAnnotations handles POST requests with a zip archive of a Go module and stores its trace annotations
+func (s *Scans) ScanTraceAnnotations(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	extendDeadlines(rw)
+	dir, err := codescan.ExtractZip(r.Body)
+	if err != nil {
+		s.l.Println("[ERROR] extracting go module", err)
+
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	annotations, err := codescan.ScanTraceAnnotations(codescan.FindModuleRoot(dir))
+	if err != nil {
+		s.l.Println("[ERROR] scanning trace annotations", err)
+
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	report, err := codescan.SyncTraceAnnotations(projectID, annotations)
+	if err != nil {
+		s.l.Println("[ERROR] syncing trace annotations", err)
+		http.Error(rw, `{{"error": "trace annotations couldn't be stored"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(report, rw)
+}
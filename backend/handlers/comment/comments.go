@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"log"
+	"traceability/data"
+)
+
+// KeyComment is a key used for the comment object in the context
+type KeyComment struct{}
+
+// Comments handler manages the comment threads on components, links and views
+type Comments struct {
+	l *log.Logger
+	v *data.Validation
+}
+
+// NewComments returns a new comments handler with the given logger
+func NewComments(l *log.Logger, v *data.Validation) *Comments {
+	return &Comments{l, v}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
+
+// targets map the path segments of the commented things to their kinds
+var targets = map[string]data.CommentTarget{
+	"components": data.CommentOnComponent,
+	"links":      data.CommentOnLink,
+	"views":      data.CommentOnView,
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /projects/{projectID}/{target}/{id}/comments/ ListComments
+// Return the comment threads on a component, link or view, "?resolved=false" returns the open threads only
+//
+// responses:
+//	200: commentThreadsResponse
+//  400: errorResponse
+//  404: errorResponse
+
+// ListComments handles GET requests and returns the threads with their replies
+func (ch *Comments) ListComments(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var resolved *bool
+	if raw := r.URL.Query().Get("resolved"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+		resolved = &b
+	}
+
+	threads, err := data.FindCommentThreads(targets[vars["target"]], vars["id"], resolved)
+	if err != nil {
+		http.Error(rw, `{{"error": "comments not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(threads, rw)
+}
+
+// swagger:route GET /projects/{projectID}/{target}/{id}/comments/{commentID}/ GetComment
+// Return a comment with its edit history
+//
+// responses:
+//	200: commentResponse
+//  404: errorResponse
+
+// GetComment handles GET requests and returns the comment by ID
+func (ch *Comments) GetComment(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	comment, err := data.FindCommentByID(targets[vars["target"]], vars["id"], vars["commentID"])
+	if err == data.ErrUnknownComment {
+		http.Error(rw, `{{"error": "comment not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "comment not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(comment, rw)
+}
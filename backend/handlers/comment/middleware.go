@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// MiddlewareCommentTarget checks that the commented component, link or view
+// belongs to the project and calls next if ok
+func (ch *Comments) MiddlewareCommentTarget(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		kind, ok := targets[vars["target"]]
+		if !ok {
+			http.Error(rw, `{{"error": "target not found"}}`, http.StatusNotFound)
+			return
+		}
+
+		projectID, err := data.FindCommentTargetProject(kind, vars["id"])
+		if err != nil || projectID != vars["projectID"] {
+			http.Error(rw, `{{"error": "target not found"}}`, http.StatusNotFound)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// MiddlewareValidateComment validates the comment in the request and calls next if ok
+func (ch *Comments) MiddlewareValidateComment(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		comment := &data.Comment{}
+
+		err := data.FromJSON(comment, r.Body)
+		if err != nil {
+			ch.l.Println("[ERROR] deserializing comment", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+
+		// validate the comment
+		errs := ch.v.Validate(comment)
+		if len(errs) != 0 {
+
+			ch.l.Println("[ERROR] validating comment", errs)
+
+			// return the validation messages as an array
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// add the comment to the context
+		ctx := context.WithValue(r.Context(), KeyComment{}, comment)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route PATCH /projects/{projectID}/{target}/{id}/comments/{commentID}/ UpdateComment
+// Edit the body of an own comment, the former body is kept in the history
+//
+// responses:
+//	200: commentResponse
+//  403: errorResponse
+//  404: errorResponse
+//  422: errorValidation
+
+// UpdateComment handles PATCH requests and replaces the body of the comment
+func (ch *Comments) UpdateComment(rw http.ResponseWriter, r *http.Request) {
+	edit := r.Context().Value(KeyComment{}).(*data.Comment)
+	vars := mux.Vars(r)
+	userID := data.GetUserIDFromContext(r.Context())
+
+	comment, err := data.UpdateComment(targets[vars["target"]], vars["id"], vars["commentID"], userID, edit.Body)
+	switch err {
+	case nil:
+		data.ToJSON(comment, rw)
+	case data.ErrUnknownComment:
+		http.Error(rw, `{{"error": "comment not found"}}`, http.StatusNotFound)
+	case data.ErrNotCommentAuthor:
+		rw.WriteHeader(http.StatusForbidden)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	default:
+		http.Error(rw, `{{"error": "comment couldn't be updated"}}`, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /projects/{projectID}/{target}/{id}/comments/ CreateComment
+// Start a thread, or reply to one if threadID is set. Members mentioned with
+// @name or @email-name in the markdown body are stored as mentions.
+//
+// responses:
+//	200: commentResponse
+//  404: errorResponse
+//  422: errorValidation
+
+// CreateComment handles POST requests to add a comment written by the user
+func (ch *Comments) CreateComment(rw http.ResponseWriter, r *http.Request) {
+	comment := r.Context().Value(KeyComment{}).(*data.Comment)
+	vars := mux.Vars(r)
+
+	comment.ProjectID = vars["projectID"]
+	comment.TargetKind = targets[vars["target"]]
+	comment.TargetID = vars["id"]
+	comment.Author = data.GetUserIDFromContext(r.Context())
+
+	added, err := data.AddComment(*comment)
+	if err == data.ErrUnknownComment {
+		rw.WriteHeader(http.StatusNotFound)
+		data.ToJSON(&GenericError{Message: "thread not found"}, rw)
+		return
+	}
+	if err != nil {
+		ch.l.Println("[ERROR] adding comment", err)
+		http.Error(rw, `{{"error": "comment couldn't be added"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(added, rw)
+}
+
+// swagger:route POST /projects/{projectID}/{target}/{id}/comments/{commentID}/resolve CommentResolve
+// Mark a thread resolved
+//
+// responses:
+//	200: commentResponse
+//  404: errorResponse
+//  422: errorResponse
+
+// ResolveThread handles POST requests to resolve a thread
+func (ch *Comments) ResolveThread(rw http.ResponseWriter, r *http.Request) {
+	ch.setResolved(rw, r, true)
+}
+
+// swagger:route POST /projects/{projectID}/{target}/{id}/comments/{commentID}/reopen CommentReopen
+// Reopen a resolved thread
+//
+// responses:
+//	200: commentResponse
+//  404: errorResponse
+//  422: errorResponse
+
+// ReopenThread handles POST requests to reopen a thread
+func (ch *Comments) ReopenThread(rw http.ResponseWriter, r *http.Request) {
+	ch.setResolved(rw, r, false)
+}
+
+func (ch *Comments) setResolved(rw http.ResponseWriter, r *http.Request, resolved bool) {
+	vars := mux.Vars(r)
+	userID := data.GetUserIDFromContext(r.Context())
+
+	comment, err := data.ResolveCommentThread(targets[vars["target"]], vars["id"], vars["commentID"], userID, resolved)
+	switch err {
+	case nil:
+		data.ToJSON(comment, rw)
+	case data.ErrUnknownComment:
+		http.Error(rw, `{{"error": "comment not found"}}`, http.StatusNotFound)
+	case data.ErrNotThreadRoot:
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	default:
+		http.Error(rw, `{{"error": "thread couldn't be updated"}}`, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	data "traceability/data"
+	"traceability/interchange"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /projects/{projectID}/features/import ImportGherkin
+// Import a gherkin feature file as user story with acceptance criteria
+//
+// responses:
+//	200: gherkinImportReport
+//  400: errorResponse
+//  500: errorResponse
+
+// ImportGherkin handles POST requests with a .feature file, the path query parameter
+// identifies the file so that repeated imports update the same components
+func (i *Interchange) ImportGherkin(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: "path of the feature file is required"}, rw)
+		return
+	}
+
+	report, err := interchange.ImportGherkin(r.Body, path, projectID)
+	if err != nil {
+		i.l.Println("[ERROR] importing gherkin feature", err)
+		writeImportError(rw, err, "feature file couldn't be imported")
+		return
+	}
+
+	data.ToJSON(report, rw)
+}
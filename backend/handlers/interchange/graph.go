@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	data "traceability/data"
+	"traceability/interchange"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /projects/{projectID}/graph ExportGraph
+// Export the project graph as GraphML or GEXF
+//
+// responses:
+//	200: graphResponse
+//  400: errorResponse
+//  404: errorResponse
+
+// exportWriteTimeout is how long a write of the graph export may take, the
+// export as a whole may take longer than the server's write timeout
+const exportWriteTimeout = 10 * time.Second
+
+// deadlineWriter moves the write deadline of the response ahead before every
+// write, so large exports finish while stalled clients still time out
+type deadlineWriter struct {
+	rw http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (d deadlineWriter) Write(p []byte) (int, error) {
+	if err := d.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil && err != http.ErrNotSupported {
+		return 0, err
+	}
+	return d.rw.Write(p)
+}
+
+// Flush sends what was written so far to the client
+func (d deadlineWriter) Flush() {
+	d.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	d.rc.Flush()
+}
+
+// ExportGraph handles GET requests and streams the project graph in the requested format
+func (i *Interchange) ExportGraph(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	format := interchange.GraphFormat(r.URL.Query().Get("format"))
+	if format != interchange.GraphML && format != interchange.GEXF {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: interchange.ErrUnknownGraphFormat.Error()}, rw)
+		return
+	}
+
+	project, err := data.FindProjectByID(projectID)
+	if err != nil {
+		http.Error(rw, `{{"error": "project not found"}}`, http.StatusNotFound)
+		return
+	}
+
+	rw.Header().Set("Content-Type", format.ContentType())
+	rw.Header().Set("Content-Disposition", `attachment; filename="`+project.ID+"."+string(format)+`"`)
+
+	w := deadlineWriter{rw: rw, rc: http.NewResponseController(rw)}
+	err = interchange.ExportGraph(w, format, project)
+	if err != nil {
+		// the response is already streaming, so the error can only be logged
+		i.l.Println("[ERROR] exporting graph", err)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"traceability/data"
+	"traceability/interchange"
+)
+
+// Interchange handler imports and exports projects in formats of other tools
+type Interchange struct {
+	l *log.Logger
+	v *data.Validation
+}
+
+// NewInterchange returns a new interchange handler with the given logger
+func NewInterchange(l *log.Logger, v *data.Validation) *Interchange {
+	return &Interchange{l, v}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
+
+// writeImportError answers 400 with the message of errors reading the
+// imported file and 500 with the message for all other errors
+func writeImportError(rw http.ResponseWriter, err error, message string) {
+	var ie *interchange.ImportError
+	if errors.As(err, &ie) {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: ie.Error()}, rw)
+		return
+	}
+	http.Error(rw, `{{"error": "`+message+`"}}`, http.StatusInternalServerError)
+}
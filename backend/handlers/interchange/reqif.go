@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	data "traceability/data"
+	"traceability/interchange"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /projects/{projectID}/reqif ExportReqIF
+// Export the project as ReqIF
+//
+// responses:
+//	200: reqifResponse
+//  404: errorResponse
+
+// ExportReqIF handles GET requests and returns the project as ReqIF document
+func (i *Interchange) ExportReqIF(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	// the document is built in memory, so errors can still be reported
+	var buf bytes.Buffer
+	err := interchange.ExportReqIF(&buf, projectID)
+	if err != nil {
+		i.l.Println("[ERROR] exporting reqif", err)
+		http.Error(rw, `{{"error": "project couldn't be exported"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/xml")
+	rw.Header().Set("Content-Disposition", `attachment; filename="`+projectID+`.reqif"`)
+	buf.WriteTo(rw)
+}
+
+// swagger:route POST /projects/{projectID}/reqif ImportReqIF
+// Import a ReqIF document into the user story view
+//
+// responses:
+//	200: reqifImportReport
+//  400: errorResponse
+//  500: errorResponse
+
+// ImportReqIF handles POST requests with a ReqIF document and imports it into the project
+func (i *Interchange) ImportReqIF(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	report, err := interchange.ImportReqIF(r.Body, projectID)
+	if err != nil {
+		i.l.Println("[ERROR] importing reqif", err)
+		writeImportError(rw, err, "reqif document couldn't be imported")
+		return
+	}
+
+	data.ToJSON(report, rw)
+}
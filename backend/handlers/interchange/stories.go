@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	data "traceability/data"
+	"traceability/interchange"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /projects/{projectID}/stories/import ImportUserStories
+// Import "As a ..., I want ... so that ..." lines as user stories
+//
+// responses:
+//	200: storyImportReport
+//  400: errorResponse
+//  500: errorResponse
+
+// ImportUserStories handles POST requests with a plain text story file and adds its stories to the project
+func (i *Interchange) ImportUserStories(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	report, err := interchange.ImportUserStories(r.Body, projectID)
+	if err != nil {
+		i.l.Println("[ERROR] importing user stories", err)
+		writeImportError(rw, err, "user stories couldn't be imported")
+		return
+	}
+
+	data.ToJSON(report, rw)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route DELETE /projects/{projectID}/invitations/{invitationID}/ RevokeInvitation
+// Revoke a pending invitation, its token can't be accepted anymore
+//
+// responses:
+//	200: invitationResponse
+//  404: errorResponse
+//  409: errorResponse
+
+// RevokeInvitation handles DELETE requests and revokes a pending invitation
+func (i *Invitations) RevokeInvitation(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	inv, err := data.RevokeInvitation(vars["projectID"], vars["invitationID"])
+	if err != nil {
+		writeInvitationError(rw, err)
+		return
+	}
+
+	data.ToJSON(inv, rw)
+}
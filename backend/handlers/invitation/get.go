@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /projects/{projectID}/invitations/ ListInvitations
+// Return the invitations of the project, newest first
+//
+// responses:
+//	200: invitationsResponse
+
+// ListInvitations handles GET requests and returns the invitations of the project
+func (i *Invitations) ListInvitations(rw http.ResponseWriter, r *http.Request) {
+	invitations, err := data.FindInvitationsOfProject(mux.Vars(r)["projectID"])
+	if err != nil {
+		http.Error(rw, `{{"error": "invitations not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(invitations, rw)
+}
+
+// swagger:route GET /invitations/{token} GetInvitation
+// Return the project and role of an invitation to show before accepting it
+//
+// responses:
+//	200: invitationDetailsResponse
+//  404: errorResponse
+
+// GetInvitation handles GET requests and returns the invitation of the token
+func (i *Invitations) GetInvitation(rw http.ResponseWriter, r *http.Request) {
+	inv, err := data.FindInvitationByToken(mux.Vars(r)["token"])
+	if err != nil {
+		writeInvitationError(rw, err)
+		return
+	}
+	project, err := data.FindProjectByID(inv.ProjectID)
+	if err != nil {
+		writeInvitationError(rw, data.ErrUnknownInvitation)
+		return
+	}
+
+	data.ToJSON(&InvitationDetails{
+		ProjectID:   project.ID,
+		ProjectName: project.Name,
+		Email:       inv.Email,
+		Role:        inv.Role,
+		Status:      inv.Status,
+		Expired:     inv.Expired(),
+		ExpiresAt:   inv.ExpiresAt,
+	}, rw)
+}
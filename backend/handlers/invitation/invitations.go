@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"traceability/data"
+	"traceability/mail"
+)
+
+// KeyInvitation is a key used for the invitation object in the context
+type KeyInvitation struct{}
+
+// Invitations handler manages the invitations to projects and mails them
+type Invitations struct {
+	l *log.Logger
+	v *data.Validation
+	m mail.Mailer
+
+	// baseURL prefixes the accept links in the emails
+	baseURL string
+}
+
+// NewInvitations returns a new invitations handler sending the emails with
+// the mailer, their links start with baseURL
+func NewInvitations(l *log.Logger, v *data.Validation, m mail.Mailer, baseURL string) *Invitations {
+	return &Invitations{l, v, m, baseURL}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
+
+// InvitationDetails is what the holder of an invitation token sees before accepting it
+// swagger:model
+type InvitationDetails struct {
+	// id of the project
+	ProjectID string `json:"projectID"`
+
+	// name of the project
+	ProjectName string `json:"projectName"`
+
+	// invited email
+	Email string `json:"email"`
+
+	// role given on joining
+	Role string `json:"role"`
+
+	// "pending", "accepted" or "revoked"
+	Status data.InvitationStatus `json:"status"`
+
+	// set when a pending invitation can't be accepted anymore
+	Expired bool `json:"expired"`
+
+	// time the invitation can't be accepted anymore
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// writeInvitationError answers the request with the status of an error of the invitation functions
+func writeInvitationError(rw http.ResponseWriter, err error) {
+	switch err {
+	case data.ErrUnknownInvitation:
+		rw.WriteHeader(http.StatusNotFound)
+	case data.ErrOwnerRequired, data.ErrInvitationEmail, data.ErrInvitationUnverified:
+		rw.WriteHeader(http.StatusForbidden)
+	case data.ErrMemberExists, data.ErrInvitationClosed:
+		rw.WriteHeader(http.StatusConflict)
+	case data.ErrInvitationExpired:
+		rw.WriteHeader(http.StatusGone)
+	case data.ErrUnknownRole:
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		http.Error(rw, `{{"error": "invitation couldn't be processed"}}`, http.StatusInternalServerError)
+		return
+	}
+	data.ToJSON(&GenericError{Message: err.Error()}, rw)
+}
+
+// message returns the email of the invitation to the project
+func (i *Invitations) message(inv data.Invitation, project data.Project) mail.Message {
+	inviter := "A member"
+	if u, err := data.FindUserByID(inv.InvitedBy); err == nil && u.Name != "" {
+		inviter = u.Name
+	}
+	body := fmt.Sprintf(`%s invited you to join the project "%s" as %s.
+
+Open the link to accept the invitation, sign up first if you have no account yet:
+%s/invitations/%s
+
+The invitation expires on %s.
+`, inviter, project.Name, inv.Role, i.baseURL, inv.Token, inv.ExpiresAt.Format("January 2, 2006 15:04 MST"))
+
+	return mail.Message{
+		To:      []string{inv.Email},
+		Subject: fmt.Sprintf("Invitation to %s", project.Name),
+		Body:    body,
+	}
+}
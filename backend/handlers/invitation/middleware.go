@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"traceability/data"
+)
+
+// MiddlewareValidateInvitation validates the invitation in the request and calls next if ok
+func (i *Invitations) MiddlewareValidateInvitation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		invitation := &data.Invitation{}
+
+		err := data.FromJSON(invitation, r.Body)
+		if err != nil {
+			i.l.Println("[ERROR] deserializing invitation", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+
+		// validate the invitation
+		errs := i.v.Validate(invitation)
+		if len(errs) != 0 {
+
+			i.l.Println("[ERROR] validating invitation", errs)
+
+			// return the validation messages as an array
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// add the invitation to the context
+		ctx := context.WithValue(r.Context(), KeyInvitation{}, invitation)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /projects/{projectID}/invitations/ CreateInvitation
+// Invite someone by email to join the project, the token is only returned here
+//
+// responses:
+//	200: invitationResponse
+//  403: errorResponse
+//  409: errorResponse
+//  422: errorValidation
+
+// CreateInvitation handles POST requests, it creates an invitation and mails it
+func (i *Invitations) CreateInvitation(rw http.ResponseWriter, r *http.Request) {
+	inv := r.Context().Value(KeyInvitation{}).(*data.Invitation)
+
+	inv.ProjectID = mux.Vars(r)["projectID"]
+	inv.InvitedBy = data.GetUserIDFromContext(r.Context())
+	role, _ := data.GetRoleFromContext(r.Context())
+	added, err := data.AddInvitation(*inv, role)
+	if err != nil {
+		writeInvitationError(rw, err)
+		return
+	}
+
+	// a failed email doesn't fail the invitation, its link can be shared otherwise
+	project, err := data.FindProjectByID(added.ProjectID)
+	if err == nil {
+		err = i.m.Send(i.message(added, project))
+	}
+	if err != nil {
+		i.l.Println("[ERROR] sending invitation", added.ID, err)
+	} else {
+		added.Sent = true
+		if err := data.UpdateInvitation(added); err != nil {
+			i.l.Println("[ERROR] updating invitation", added.ID, err)
+		}
+	}
+
+	data.ToJSON(added, rw)
+}
+
+// swagger:route POST /invitations/{token}/accept AcceptInvitation
+// Join the project of the invitation as the logged in user, after signing up
+// or logging in with the verified email the invitation was sent to
+//
+// responses:
+//	200: invitationResponse
+//  403: errorResponse
+//  404: errorResponse
+//  409: errorResponse
+//  410: errorResponse
+
+// AcceptInvitation handles POST requests and adds the user to the project of the invitation
+func (i *Invitations) AcceptInvitation(rw http.ResponseWriter, r *http.Request) {
+	userID := data.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(rw, `{{"error": "401 user not authenticated"}}`, http.StatusUnauthorized)
+		return
+	}
+
+	inv, err := data.AcceptInvitation(mux.Vars(r)["token"], userID)
+	if err != nil {
+		writeInvitationError(rw, err)
+		return
+	}
+
+	data.ToJSON(inv, rw)
+}
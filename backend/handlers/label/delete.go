@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route DELETE /projects/{projectID}/labels/{labelID}/ DeleteLabel
+// Remove a label from the project and everything it is attached to
+//
+// responses:
+//	204: noContent
+//  404: errorResponse
+
+// DeleteLabel handles DELETE requests and removes the label
+func (lh *Labels) DeleteLabel(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	id, ok := vars["labelID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	err := data.DeleteLabel(projectID, id, data.GetUserIDFromContext(r.Context()))
+	switch err {
+	case nil:
+		rw.WriteHeader(http.StatusNoContent)
+	case data.ErrUnknownLabel:
+		http.Error(rw, `{{"error": "label not found"}}`, http.StatusNotFound)
+	default:
+		http.Error(rw, `{{"error": "label couldn't be deleted"}}`, http.StatusInternalServerError)
+	}
+}
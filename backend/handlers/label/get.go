@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /projects/{projectID}/labels/ ListLabels
+// Return the labels of the project
+//
+// responses:
+//	200: labelsResponse
+
+// ListLabels handles GET requests and returns the labels of the project
+func (lh *Labels) ListLabels(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	labels, err := data.FindLabelsOfProject(projectID)
+	if err != nil {
+		http.Error(rw, `{{"error": "labels not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(labels, rw)
+}
+
+// swagger:route GET /projects/{projectID}/labels/{labelID}/ GetLabel
+// Return a label of the project
+//
+// responses:
+//	200: labelResponse
+//  404: errorResponse
+
+// GetLabel handles GET requests and returns the label by ID
+func (lh *Labels) GetLabel(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	id, ok := vars["labelID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	label, err := data.FindLabelByID(projectID, id)
+	if err == data.ErrUnknownLabel {
+		http.Error(rw, `{{"error": "label not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "label not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(label, rw)
+}
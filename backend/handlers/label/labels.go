@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"log"
+	"traceability/data"
+)
+
+// KeyLabel is a key used for the label object in the context
+type KeyLabel struct{}
+
+// Labels handler manages the labels of projects
+type Labels struct {
+	l *log.Logger
+	v *data.Validation
+}
+
+// NewLabels returns a new labels handler with the given logger
+func NewLabels(l *log.Logger, v *data.Validation) *Labels {
+	return &Labels{l, v}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
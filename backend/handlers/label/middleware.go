@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"traceability/data"
+)
+
+// MiddlewareValidateLabel validates the label in the request and calls next if ok
+func (lh *Labels) MiddlewareValidateLabel(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		label := &data.Label{}
+
+		err := data.FromJSON(label, r.Body)
+		if err != nil {
+			lh.l.Println("[ERROR] deserializing label", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+
+		// validate the label
+		errs := lh.v.Validate(label)
+		if len(errs) != 0 {
+
+			lh.l.Println("[ERROR] validating label", errs)
+
+			// return the validation messages as an array
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// add the label to the context
+		ctx := context.WithValue(r.Context(), KeyLabel{}, label)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	data "traceability/data"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gorilla/mux"
+)
+
+// swagger:route PATCH /projects/{projectID}/labels/{labelID}/ UpdateLabel
+// Rename or recolour a label
+//
+// responses:
+//	200: labelResponse
+//  404: errorResponse
+//  409: errorResponse
+//  422: errorValidation
+
+// UpdateLabel handles PATCH requests and updates the label
+func (lh *Labels) UpdateLabel(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	id, ok := vars["labelID"]
+	jsonBody, err := ioutil.ReadAll(r.Body)
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	label, err := data.FindLabelByID(projectID, id)
+	if err != nil {
+		http.Error(rw, `{{"error": "label not found"}}`, http.StatusNotFound)
+		return
+	}
+	jsonLabel, err := json.Marshal(label)
+	if err != nil {
+		http.Error(rw, `{{"error": "label not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	modifiedJSON, err := jsonpatch.MergePatch(jsonLabel, jsonBody)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	modifiedLabel := &data.Label{}
+	err = json.Unmarshal(modifiedJSON, modifiedLabel)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	modifiedLabel.ID, modifiedLabel.ProjectID = label.ID, label.ProjectID
+
+	errs := lh.v.Validate(modifiedLabel)
+	if len(errs) != 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+		return
+	}
+
+	err = data.UpdateLabel(*modifiedLabel)
+	if err == data.ErrLabelExists {
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "label couldn't be updated"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(modifiedLabel, rw)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// BulkLabelRequest attaches and detaches labels of many components, links and views at once
+// swagger:model
+type BulkLabelRequest struct {
+	// ids of the labels to attach
+	//
+	// required: false
+	Add []string `json:"add"`
+
+	// ids of the labels to detach
+	//
+	// required: false
+	Remove []string `json:"remove"`
+
+	data.LabelTargets
+}
+
+// swagger:route POST /projects/{projectID}/labels/ CreateLabel
+// Create a new label in the project
+//
+// responses:
+//	200: labelResponse
+//  409: errorResponse
+//  422: errorValidation
+
+// CreateLabel handles POST requests to add a new label
+func (lh *Labels) CreateLabel(rw http.ResponseWriter, r *http.Request) {
+	label := r.Context().Value(KeyLabel{}).(*data.Label)
+
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	label.ProjectID = projectID
+	added, err := data.AddLabel(*label)
+	if err == data.ErrLabelExists {
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "label couldn't be added"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(added, rw)
+}
+
+// swagger:route POST /projects/{projectID}/labels/bulk BulkLabel
+// Attach and detach labels of many components, links and views
+//
+// responses:
+//	204: noContent
+//  400: errorResponse
+//  422: errorValidation
+
+// BulkLabel handles POST requests to label and unlabel components, links and views in bulk
+func (lh *Labels) BulkLabel(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	req := &BulkLabelRequest{}
+	err := data.FromJSON(req, r.Body)
+	if err != nil {
+		lh.l.Println("[ERROR] deserializing bulk label request", err)
+
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: "nothing to add or remove"}, rw)
+		return
+	}
+
+	err = data.UpdateLabels(projectID, req.Add, req.Remove, req.LabelTargets, data.GetUserIDFromContext(r.Context()))
+	if errors.Is(err, data.ErrUnknownLabel) {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+		return
+	}
+	if err != nil {
+		lh.l.Println("[ERROR] updating labels", err)
+		http.Error(rw, `{{"error": "labels couldn't be updated"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// swagger:route DELETE /projects/{projectID}/links/{linkID}/ deleteLink
+// Remove a link between components
+// responses:
+//	200: linkResponse
+//  404: errorResponse
+
+// DeleteLink handles DELETE requests and removes the link of the project
+func (l *Links) DeleteLink(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	link, err := data.DeleteLink(vars["projectID"], vars["linkID"], data.GetUserIDFromContext(r.Context()))
+	if err == mongo.ErrNoDocuments {
+		http.Error(rw, `{{"error": "link not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "link couldn't be deleted"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(link, rw)
+}
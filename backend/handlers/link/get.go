@@ -7,20 +7,25 @@ import (
 	data "traceability/data"
 
 	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// swagger:route GET /links listUsers
-// Return a list of users from the database
+// swagger:route GET /links listLinks
+// Return the links of the projects the user is a member of
 // responses:
-// 200: usersResponse
+// 200: linksResponse
 
-// ListAll handles GET requests and returns all links
+// ListAll handles GET requests and returns the links of the user's projects
 func (l *Links) ListAll(rw http.ResponseWriter, r *http.Request) {
 	l.l.Println("[DEBUG] get all links")
 
-	links := data.FindAllLinks()
+	links, err := data.FindLinksOfUserProjects(data.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		http.Error(rw, `{{"error": "links not found"}}`, http.StatusInternalServerError)
+		return
+	}
 
-	err := data.ToJSON(links, rw)
+	err = data.ToJSON(links, rw)
 	if err != nil {
 		l.l.Println("[ERROR] serializing link", err)
 	}
@@ -42,7 +47,7 @@ func (l *Links) GetLink(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	link, err := data.FindLinkByID(id)
+	link, err := data.FindProjectLink(vars["projectID"], id)
 
 	if err != nil {
 		io.WriteString(rw, `{{"error": "link not found"}}`)
@@ -57,7 +62,8 @@ func (l *Links) GetLink(rw http.ResponseWriter, r *http.Request) {
 // responses:
 // 200: usersResponse
 
-// GetProjectLinks handles GET requests and returns all links
+// GetProjectLinks handles GET requests and returns all links, the labels
+// parameter filters them by a label expression
 func (l *Links) GetProjectLinks(rw http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
@@ -68,7 +74,20 @@ func (l *Links) GetProjectLinks(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	project, err := data.FindAllProjectLinks(projectID)
+	expr := r.URL.Query().Get("labels")
+	labels, err := data.ParseLabelFilter(projectID, expr)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	var project data.Links
+	if expr != "" {
+		project, err = data.FindProjectLinksWithLabels(projectID, labels)
+	} else {
+		project, err = data.FindAllProjectLinks(projectID)
+	}
 
 	if err != nil {
 		io.WriteString(rw, `{{"error": "user not found"}}`)
@@ -78,7 +97,8 @@ func (l *Links) GetProjectLinks(rw http.ResponseWriter, r *http.Request) {
 	err = data.ToJSON(project, rw)
 }
 
-// GetLinkedComponents handles GET requests and returns all linked components
+// GetLinkedComponents handles GET requests and returns all linked components,
+// the kind query parameter keeps the components of views of that kind only
 func (l *Links) GetLinkedComponents(rw http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
@@ -89,12 +109,39 @@ func (l *Links) GetLinkedComponents(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	archViews, err := data.FindLinkedComponents(id)
+	kind := r.URL.Query().Get("kind")
+	if kind != "" {
+		_, err := data.FindViewKind(vars["projectID"], kind)
+		if err == data.ErrUnknownViewKind {
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+		if err != nil {
+			http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusInternalServerError)
+			return
+		}
+	}
 
-	if err != nil || archViews == nil {
-		io.WriteString(rw, `{{"error": "user not found"}}`)
+	_, err := data.FindProjectArchViewComponent(vars["projectID"], id)
+	if err == mongo.ErrNoDocuments {
+		http.Error(rw, `{{"error": "component not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "component not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	archViews, err := data.FindLinkedComponents(vars["projectID"], id)
+
+	if err != nil {
+		http.Error(rw, `{{"error": "linked components not found"}}`, http.StatusInternalServerError)
 		return
 	}
+	if kind != "" {
+		archViews = data.FilterArchViewComponentsByKind(archViews, data.ViewKind(kind))
+	}
 
 	err = data.ToJSON(archViews, rw)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 	"traceability/data"
+
+	"github.com/gorilla/mux"
 )
 
 // MiddlewareValidateLink validates the link in the request and calls next if ok
@@ -20,6 +22,8 @@ func (l *Links) MiddlewareValidateLink(next http.Handler) http.Handler {
 			data.ToJSON(&GenericError{Message: err.Error()}, rw)
 			return
 		}
+		// the link belongs to the project of the route
+		link.ProjectID = mux.Vars(r)["projectID"]
 
 		errs := l.v.Validate(link)
 		if len(errs) != 0 {
@@ -31,6 +35,18 @@ func (l *Links) MiddlewareValidateLink(next http.Handler) http.Handler {
 			return
 		}
 
+		// attached labels have to be labels of the project
+		err = data.CheckLabels(link.ProjectID, link.Labels)
+		if err == data.ErrUnknownLabel {
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+			return
+		}
+		if err != nil {
+			http.Error(rw, `{{"error": "labels not found"}}`, http.StatusInternalServerError)
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), KeyLink{}, link)
 		r = r.WithContext(ctx)
 
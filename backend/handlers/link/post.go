@@ -11,13 +11,26 @@ import (
 // responses:
 //	200: linkResponse
 //  422: errorValidation
+//  500: errorResponse
 //  501: errorResponse
 
-// AddLink handles POST requests to add new link
+// AddLink handles POST requests to add new link, both components have to be
+// components of the project of the route
 func (l *Links) AddLink(rw http.ResponseWriter, r *http.Request) {
 	link := r.Context().Value(KeyLink{}).(*data.Link)
+	link.Actor = data.GetUserIDFromContext(r.Context())
 	l.l.Printf("[DEBUG] Inserting link: %#v\n", link)
-	addedLink := data.AddLink(*link)
+	addedLink, err := data.AddLink(*link)
+	if err == data.ErrLinkComponent {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: []string{err.Error()}}, rw)
+		return
+	}
+	if err != nil {
+		l.l.Println("[ERROR] adding link", err)
+		http.Error(rw, `{{"error": "link couldn't be added"}}`, http.StatusInternalServerError)
+		return
+	}
 	l.l.Println(addedLink)
 	data.ToJSON(addedLink, rw)
 }
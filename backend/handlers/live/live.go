@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"log"
+	"traceability/data"
+	"traceability/live"
+)
+
+// Live handler streams the changes of projects and the presence of their viewers
+type Live struct {
+	l   *log.Logger
+	v   *data.Validation
+	hub *live.Hub
+}
+
+// NewLive returns a new live handler serving the connections of the hub
+func NewLive(l *log.Logger, v *data.Validation, hub *live.Hub) *Live {
+	return &Live{l, v, hub}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// PresenceRequest names the view a connection is viewing
+// swagger:model
+type PresenceRequest struct {
+	// "presence", only read from websocket messages
+	//
+	// required: false
+	Type string `json:"type,omitempty"`
+
+	// id of the connection from its hello message, only read from requests
+	//
+	// required: false
+	Client string `json:"client,omitempty"`
+
+	// id of the viewed view of the project, empty if none
+	//
+	// required: false
+	ViewID string `json:"viewID"`
+}
+
+// checkView reports whether the view is a view of the project, no view is fine
+func checkView(projectID string, viewID string) bool {
+	if viewID == "" {
+		return true
+	}
+	view, err := data.FindArchViewByID(viewID)
+	return err == nil && view.ProjectID == projectID
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	data "traceability/data"
+	"traceability/live"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /projects/{projectID}/presence GetPresence
+// Return who is connected to the project and which view they are viewing
+//
+// responses:
+//	200: presenceResponse
+
+// GetPresence handles GET requests and returns the viewers of the project
+func (lh *Live) GetPresence(rw http.ResponseWriter, r *http.Request) {
+	data.ToJSON(lh.hub.Presence(mux.Vars(r)["projectID"]), rw)
+}
+
+// swagger:route POST /projects/{projectID}/presence UpdatePresence
+// Name the view an event stream connection of the user is viewing
+//
+// responses:
+//	200: presenceResponse
+//  400: errorResponse
+//  404: errorResponse
+
+// UpdatePresence handles POST requests and records the view of a connection
+func (lh *Live) UpdatePresence(rw http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["projectID"]
+	userID := data.GetUserIDFromContext(r.Context())
+
+	req := &PresenceRequest{}
+	if err := data.FromJSON(req, r.Body); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if !checkView(projectID, req.ViewID) {
+		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusNotFound)
+		return
+	}
+
+	err := lh.hub.SetView(projectID, req.Client, userID, req.ViewID)
+	if err == live.ErrUnknownClient {
+		rw.WriteHeader(http.StatusNotFound)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	data.ToJSON(lh.hub.Presence(projectID), rw)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	authentication "traceability/auth"
+	data "traceability/data"
+	"traceability/live"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// keepAliveInterval is the time between pings of idle connections, the
+	// session and membership of a connection are checked again as often
+	keepAliveInterval = 25 * time.Second
+	// pongWait is the time a websocket client has to answer a ping
+	pongWait = keepAliveInterval + 10*time.Second
+	// writeTimeout limits the time a write to a websocket client may block
+	writeTimeout = 10 * time.Second
+	// maxMessageSize limits the messages read from websocket clients
+	maxMessageSize = 64 << 10
+)
+
+// upgrader accepts websocket handshakes of the own host and the origins allowed by CORS
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || authentication.IsAllowedOrigin(origin) {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	},
+}
+
+// swagger:route GET /projects/{projectID}/live LiveStream
+// Stream the changes of views, components and links of the project and the
+// presence of its viewers. Websocket clients tell the view they are viewing
+// with {"type": "presence", "viewID": "..."} messages, other clients get a
+// server-sent event stream and name their view with "?viewID=" or
+// POST /projects/{projectID}/presence. The token can be given as
+// "?access_token=".
+//
+// responses:
+//	101: description: switching to the websocket protocol
+//	200: description: server-sent event stream
+//  401: errorResponse
+
+// Stream handles GET requests with a websocket or an event stream
+func (lh *Live) Stream(rw http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["projectID"]
+	userID := data.GetUserIDFromContext(r.Context())
+
+	if websocket.IsWebSocketUpgrade(r) {
+		lh.serveWebSocket(rw, r, projectID, userID)
+		return
+	}
+	lh.serveEventStream(rw, r, projectID, userID)
+}
+
+// allowed reports whether the session or access token of the request still
+// works and its user may still read the project, connections outlive both
+func allowed(r *http.Request, projectID string, userID string) bool {
+	if pat, ok := data.GetAccessTokenFromContext(r.Context()); ok {
+		if !data.FindActiveAccessToken(pat.ID, userID) {
+			return false
+		}
+	} else if !data.FindActiveSession(data.GetSessionIDFromContext(r.Context()), userID) {
+		return false
+	}
+	role, err := data.FindProjectRole(projectID, userID)
+	return err == nil && role.Can(data.PermProjectRead)
+}
+
+func (lh *Live) serveWebSocket(rw http.ResponseWriter, r *http.Request, projectID string, userID string) {
+	// the upgrader answers failed handshakes itself
+	ws, err := upgrader.Upgrade(rw, r, nil)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	client := lh.hub.Join(projectID, userID)
+	defer lh.hub.Leave(client)
+
+	ws.SetReadLimit(maxMessageSize)
+	ws.SetReadDeadline(time.Now().Add(pongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			_, msg, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			req := &PresenceRequest{}
+			if json.Unmarshal(msg, req) != nil || req.Type != live.MessagePresence || !checkView(projectID, req.ViewID) {
+				lh.l.Println("[ERROR] ignoring live message", string(msg))
+				continue
+			}
+			lh.hub.SetView(projectID, client.ID, userID, req.ViewID)
+		}
+	}()
+
+	closeWith := func(code int, text string) {
+		ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(writeTimeout))
+	}
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case m := <-client.Send:
+			ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := ws.WriteJSON(m); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if !allowed(r, projectID, userID) {
+				closeWith(websocket.ClosePolicyViolation, "access revoked")
+				return
+			}
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case <-client.Closed():
+			closeWith(websocket.ClosePolicyViolation, "access revoked")
+			return
+		case <-done:
+			closeWith(websocket.CloseNormalClosure, "")
+			return
+		}
+	}
+}
+
+func (lh *Live) serveEventStream(rw http.ResponseWriter, r *http.Request, projectID string, userID string) {
+	viewID := r.URL.Query().Get("viewID")
+	if !checkView(projectID, viewID) {
+		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusNotFound)
+		return
+	}
+
+	stream, err := live.StartEventStream(rw)
+	if err != nil {
+		http.Error(rw, `{{"error": "event stream couldn't be started"}}`, http.StatusInternalServerError)
+		return
+	}
+	defer stream.Close()
+
+	client := lh.hub.Join(projectID, userID)
+	defer lh.hub.Leave(client)
+	if viewID != "" {
+		lh.hub.SetView(projectID, client.ID, userID, viewID)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stream.Wait()
+	}()
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case m := <-client.Send:
+			b, err := json.Marshal(m)
+			if err != nil {
+				lh.l.Println("[ERROR] serializing live message", err)
+				continue
+			}
+			if err := stream.Send(m.Type, b); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if !allowed(r, projectID, userID) {
+				return
+			}
+			if err := stream.KeepAlive(); err != nil {
+				return
+			}
+		case <-client.Closed():
+			return
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route DELETE /watches/{watchID}/ DeleteWatch
+// Stop watching
+//
+// responses:
+//	204: noContent
+//  404: errorResponse
+
+// DeleteWatch handles DELETE requests and removes the watch of the user
+func (nh *Notifications) DeleteWatch(rw http.ResponseWriter, r *http.Request) {
+	userID := data.GetUserIDFromContext(r.Context())
+
+	err := data.DeleteWatch(userID, mux.Vars(r)["watchID"])
+	if err == data.ErrUnknownWatch {
+		http.Error(rw, `{{"error": "watch not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "watch couldn't be deleted"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
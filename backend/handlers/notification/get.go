@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	data "traceability/data"
+)
+
+const (
+	defaultInboxLimit = 50
+	maxInboxLimit     = 200
+)
+
+// swagger:route GET /notifications/ ListNotifications
+// Return the newest notifications of the user, "?unread=true" returns the unread ones only
+//
+// responses:
+//	200: inboxResponse
+//  400: errorResponse
+
+// ListNotifications handles GET requests and returns the inbox of the user
+func (nh *Notifications) ListNotifications(rw http.ResponseWriter, r *http.Request) {
+	userID := data.GetUserIDFromContext(r.Context())
+	query := r.URL.Query()
+
+	unread := false
+	if raw := query.Get("unread"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+		unread = b
+	}
+	limit := int64(defaultInboxLimit)
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 || n > maxInboxLimit {
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: "limit has to be between 1 and 200"}, rw)
+			return
+		}
+		limit = n
+	}
+
+	notifications, err := data.FindNotifications(userID, unread, limit)
+	if err != nil {
+		http.Error(rw, `{{"error": "notifications not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	count, err := data.CountUnreadNotifications(userID)
+	if err != nil {
+		http.Error(rw, `{{"error": "notifications not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(&Inbox{Unread: count, Notifications: notifications}, rw)
+}
+
+// swagger:route GET /watches/ ListWatches
+// Return the watches of the user, "?projectID=" returns the ones of a project
+//
+// responses:
+//	200: watchesResponse
+
+// ListWatches handles GET requests and returns the watches of the user
+func (nh *Notifications) ListWatches(rw http.ResponseWriter, r *http.Request) {
+	userID := data.GetUserIDFromContext(r.Context())
+
+	watches, err := data.FindWatchesOfUser(userID, r.URL.Query().Get("projectID"))
+	if err != nil {
+		http.Error(rw, `{{"error": "watches not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(watches, rw)
+}
+
+// swagger:route GET /notifications/preferences GetNotificationPreferences
+// Return the notification preferences of the user
+//
+// responses:
+//	200: notificationPreferencesResponse
+
+// GetPreferences handles GET requests and returns the notification preferences of the user
+func (nh *Notifications) GetPreferences(rw http.ResponseWriter, r *http.Request) {
+	prefs, err := data.FindNotificationPreferences(data.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		http.Error(rw, `{{"error": "preferences not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(prefs, rw)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"traceability/data"
+)
+
+// MiddlewareValidateWatch validates the watch in the request and calls next if ok
+func (nh *Notifications) MiddlewareValidateWatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		watch := &data.Watch{}
+
+		err := data.FromJSON(watch, r.Body)
+		if err != nil {
+			nh.l.Println("[ERROR] deserializing watch", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+
+		// validate the watch
+		errs := nh.v.Validate(watch)
+		if len(errs) != 0 {
+
+			nh.l.Println("[ERROR] validating watch", errs)
+
+			// return the validation messages as an array
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// add the watch to the context
+		ctx := context.WithValue(r.Context(), KeyWatch{}, watch)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
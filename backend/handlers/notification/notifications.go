@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"log"
+	"traceability/data"
+)
+
+// KeyWatch is a key used for the watch object in the context
+type KeyWatch struct{}
+
+// Notifications handler manages the inboxes, watches and notification preferences of users
+type Notifications struct {
+	l *log.Logger
+	v *data.Validation
+}
+
+// NewNotifications returns a new notifications handler with the given logger
+func NewNotifications(l *log.Logger, v *data.Validation) *Notifications {
+	return &Notifications{l, v}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
+
+// Inbox is a page of the notifications of a user
+// swagger:model
+type Inbox struct {
+	// number of all unread notifications
+	Unread int64 `json:"unread"`
+
+	// notifications, newest first
+	Notifications []data.Notification `json:"notifications"`
+}
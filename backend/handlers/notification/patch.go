@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	data "traceability/data"
+
+	jsonpatch "github.com/evanphx/json-patch"
+)
+
+// swagger:route PATCH /notifications/preferences UpdateNotificationPreferences
+// Change the muted event types and projects and whether mentions are notified
+//
+// responses:
+//	200: notificationPreferencesResponse
+//  422: errorValidation
+
+// UpdatePreferences handles PATCH requests and updates the notification preferences of the user
+func (nh *Notifications) UpdatePreferences(rw http.ResponseWriter, r *http.Request) {
+	userID := data.GetUserIDFromContext(r.Context())
+	jsonBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	prefs, err := data.FindNotificationPreferences(userID)
+	if err != nil {
+		http.Error(rw, `{{"error": "preferences not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	jsonPrefs, err := json.Marshal(prefs)
+	if err != nil {
+		http.Error(rw, `{{"error": "preferences not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	modifiedJSON, err := jsonpatch.MergePatch(jsonPrefs, jsonBody)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	modifiedPrefs := &data.NotificationPreferences{}
+	err = json.Unmarshal(modifiedJSON, modifiedPrefs)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	modifiedPrefs.UserID = userID
+
+	errs := nh.v.Validate(modifiedPrefs)
+	if len(errs) != 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+		return
+	}
+
+	if err := data.UpdateNotificationPreferences(*modifiedPrefs); err != nil {
+		http.Error(rw, `{{"error": "preferences couldn't be updated"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(modifiedPrefs, rw)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /watches/ CreateWatch
+// Watch a project, view or component of a project the user is a member of
+//
+// responses:
+//	200: watchResponse
+//  403: errorResponse
+//  404: errorResponse
+//  409: errorResponse
+//  422: errorValidation
+
+// CreateWatch handles POST requests to watch a project, view or component
+func (nh *Notifications) CreateWatch(rw http.ResponseWriter, r *http.Request) {
+	watch := r.Context().Value(KeyWatch{}).(*data.Watch)
+	watch.UserID = data.GetUserIDFromContext(r.Context())
+
+	added, err := data.AddWatch(*watch)
+	switch err {
+	case nil:
+		data.ToJSON(added, rw)
+	case data.ErrNotProjectMember:
+		rw.WriteHeader(http.StatusForbidden)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	case data.ErrUnknownWatchTarget:
+		rw.WriteHeader(http.StatusNotFound)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	case data.ErrWatchExists:
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	default:
+		http.Error(rw, `{{"error": "watch couldn't be added"}}`, http.StatusInternalServerError)
+	}
+}
+
+// swagger:route POST /notifications/{notificationID}/read MarkNotificationRead
+// Mark a notification read
+//
+// responses:
+//	200: notificationResponse
+//  404: errorResponse
+
+// MarkRead handles POST requests to mark a notification read
+func (nh *Notifications) MarkRead(rw http.ResponseWriter, r *http.Request) {
+	nh.setRead(rw, r, true)
+}
+
+// swagger:route POST /notifications/{notificationID}/unread MarkNotificationUnread
+// Mark a notification unread
+//
+// responses:
+//	200: notificationResponse
+//  404: errorResponse
+
+// MarkUnread handles POST requests to mark a notification unread
+func (nh *Notifications) MarkUnread(rw http.ResponseWriter, r *http.Request) {
+	nh.setRead(rw, r, false)
+}
+
+func (nh *Notifications) setRead(rw http.ResponseWriter, r *http.Request, read bool) {
+	userID := data.GetUserIDFromContext(r.Context())
+	id := mux.Vars(r)["notificationID"]
+
+	notification, err := data.MarkNotificationRead(userID, id, read)
+	if err == data.ErrUnknownNotification {
+		http.Error(rw, `{{"error": "notification not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "notification couldn't be updated"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(notification, rw)
+}
+
+// swagger:route POST /notifications/read MarkAllNotificationsRead
+// Mark all notifications read, "?projectID=" marks the ones of a project only
+//
+// responses:
+//	200: inboxResponse
+
+// MarkAllRead handles POST requests to mark all notifications of the user read
+func (nh *Notifications) MarkAllRead(rw http.ResponseWriter, r *http.Request) {
+	userID := data.GetUserIDFromContext(r.Context())
+
+	if _, err := data.MarkAllNotificationsRead(userID, r.URL.Query().Get("projectID")); err != nil {
+		http.Error(rw, `{{"error": "notifications couldn't be updated"}}`, http.StatusInternalServerError)
+		return
+	}
+	count, err := data.CountUnreadNotifications(userID)
+	if err != nil {
+		http.Error(rw, `{{"error": "notifications not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(&Inbox{Unread: count, Notifications: []data.Notification{}}, rw)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	authentication "traceability/auth"
+	data "traceability/data"
+	"traceability/oauth"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /auth/providers ListProviders
+// Return the login providers besides email and password
+//
+// responses:
+//	200: providersResponse
+
+// ListProviders handles GET requests and returns the configured login providers
+func (o *OAuth) ListProviders(rw http.ResponseWriter, r *http.Request) {
+	providers := []ProviderInfo{}
+	for _, p := range o.providers {
+		providers = append(providers, ProviderInfo{Name: p.Name, Label: p.Label})
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Name < providers[j].Name
+	})
+
+	data.ToJSON(providers, rw)
+}
+
+// swagger:route GET /auth/{provider}/login Login
+// Send the user to the provider to log in, optionally returning to the redirect url
+//
+// responses:
+//	302: redirect
+//  400: errorResponse
+//  404: errorResponse
+
+// Login handles GET requests and redirects to the login page of the provider
+func (o *OAuth) Login(rw http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["provider"]
+	provider, ok := o.providers[name]
+	if !ok {
+		rw.WriteHeader(http.StatusNotFound)
+		data.ToJSON(&GenericError{Message: oauth.ErrUnknownProvider.Error()}, rw)
+		return
+	}
+
+	redirect := r.URL.Query().Get("redirect")
+	if redirect != "" && !o.allowedRedirect(redirect) {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: "redirect url not allowed"}, rw)
+		return
+	}
+
+	state, err := oauth.NewState()
+	if err != nil {
+		http.Error(rw, `{{"error": "login couldn't be started"}}`, http.StatusInternalServerError)
+		return
+	}
+	verifier, err := oauth.NewVerifier()
+	if err != nil {
+		http.Error(rw, `{{"error": "login couldn't be started"}}`, http.StatusInternalServerError)
+		return
+	}
+	err = data.AddLoginState(data.LoginState{State: state, Provider: name, Verifier: verifier, Redirect: redirect})
+	if err != nil {
+		http.Error(rw, `{{"error": "login couldn't be started"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(rw, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/auth/",
+		MaxAge:   int(data.LoginStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		// the provider sends the browser back with a top level navigation
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(rw, r, provider.AuthCodeURL(state, oauth.Challenge(verifier), o.callbackURL(name)), http.StatusFound)
+}
+
+// swagger:route GET /auth/{provider}/callback LoginCallback
+// Finish the login at the provider, link or create the user and log them in
+//
+// responses:
+//	200: userResponse
+//	302: redirect
+//  400: errorResponse
+//  401: errorResponse
+//  403: errorResponse
+
+// Callback handles the redirect of the provider after the user logged in there
+func (o *OAuth) Callback(rw http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["provider"]
+	provider, ok := o.providers[name]
+	if !ok {
+		rw.WriteHeader(http.StatusNotFound)
+		data.ToJSON(&GenericError{Message: oauth.ErrUnknownProvider.Error()}, rw)
+		return
+	}
+
+	query := r.URL.Query()
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(query.Get("state"))) != 1 {
+		// the login wasn't started by this browser
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: data.ErrUnknownLoginState.Error()}, rw)
+		return
+	}
+	http.SetCookie(rw, &http.Cookie{Name: stateCookie, Path: "/auth/", MaxAge: -1, HttpOnly: true, Secure: true, SameSite: http.SameSiteLaxMode})
+
+	state, err := data.TakeLoginState(name, query.Get("state"))
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: data.ErrUnknownLoginState.Error()}, rw)
+		return
+	}
+	if e := query.Get("error"); e != "" {
+		rw.WriteHeader(http.StatusUnauthorized)
+		data.ToJSON(&GenericError{Message: e + ": " + query.Get("error_description")}, rw)
+		return
+	}
+
+	token, err := provider.Exchange(query.Get("code"), state.Verifier, o.callbackURL(name))
+	if err != nil {
+		o.l.Println("[ERROR] exchanging code of", name, err)
+		rw.WriteHeader(http.StatusUnauthorized)
+		data.ToJSON(&GenericError{Message: "login at the provider failed"}, rw)
+		return
+	}
+	identity, err := provider.Identity(token)
+	if err != nil {
+		o.l.Println("[ERROR] reading identity of", name, err)
+		rw.WriteHeader(http.StatusUnauthorized)
+		data.ToJSON(&GenericError{Message: "login at the provider failed"}, rw)
+		return
+	}
+
+	user, err := data.LoginWithIdentity(name, identity.Subject, identity.Email, identity.EmailVerified, identity.Name)
+	if err == data.ErrEmailNotVerified || err == data.ErrAccountNotVerified {
+		rw.WriteHeader(http.StatusForbidden)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if err != nil {
+		o.l.Println("[ERROR] logging in with", name, err)
+		http.Error(rw, `{{"error": "server is not working"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	err = authentication.Login(rw, r, &user)
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(rw, `{{"error":"server is not working"}}`)
+		return
+	}
+
+	if state.Redirect != "" {
+		// the tokens go into the fragment, it isn't sent to servers
+		fragment := url.Values{"access_token": {user.AccessToken}, "refresh_token": {user.RefreshToken}}
+		http.Redirect(rw, r, state.Redirect+"#"+fragment.Encode(), http.StatusFound)
+		return
+	}
+	data.ToJSON(user.WithTokens(), rw)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"traceability/data"
+	"traceability/oauth"
+)
+
+// OAuth handler logs users in with OpenID Connect providers and GitHub
+type OAuth struct {
+	l         *log.Logger
+	v         *data.Validation
+	providers map[string]*oauth.Provider
+
+	// baseURL of the server, the callbacks of the providers are below it
+	baseURL string
+
+	// redirects are the urls users may be sent to after logging in
+	redirects []string
+}
+
+// stateCookie carries the state of a login to the callback, only the browser
+// which started the login can finish it
+const stateCookie = "oauthstate"
+
+// NewOAuth returns a new login handler for the providers. Their callback
+// urls are baseURL/auth/<provider>/callback, after logging in users are
+// sent to urls with the scheme, host and port of one of redirects and a
+// path below its path.
+func NewOAuth(l *log.Logger, v *data.Validation, providers map[string]*oauth.Provider, baseURL string, redirects []string) *OAuth {
+	return &OAuth{l, v, providers, baseURL, redirects}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ProviderInfo is a login provider shown on the login page
+// swagger:model
+type ProviderInfo struct {
+	// name in the login url /auth/{name}/login
+	Name string `json:"name"`
+
+	// display name
+	Label string `json:"label"`
+}
+
+// callbackURL returns the redirect uri registered at the provider
+func (o *OAuth) callbackURL(provider string) string {
+	return strings.TrimSuffix(o.baseURL, "/") + "/auth/" + provider + "/callback"
+}
+
+// allowedRedirect reports whether users may be sent to the url after logging
+// in, the tokens are handed to it
+func (o *OAuth) allowedRedirect(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.User != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	for _, redirect := range o.redirects {
+		allowed, err := url.Parse(redirect)
+		if err != nil || allowed.Host == "" {
+			continue
+		}
+		if u.Scheme != allowed.Scheme || !strings.EqualFold(u.Hostname(), allowed.Hostname()) || port(u) != port(allowed) {
+			continue
+		}
+		path := strings.TrimSuffix(allowed.Path, "/")
+		if path == "" || u.Path == path || strings.HasPrefix(u.Path, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// port returns the port of the url, the default of its scheme if it has none
+func port(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route DELETE /projects/{projectID}/members/{userID}/ RemoveMember
+// Remove a member from the project. Only owners remove owners, and the last
+// owner can't be removed.
+//
+// responses:
+//	204: noContent
+//  403: errorResponse
+//  404: errorResponse
+//  409: errorResponse
+
+// RemoveMember handles DELETE requests and removes a member from the project
+func (p *Projects) RemoveMember(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["projectID"]
+	userID, ok := vars["userID"]
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	role, _ := data.GetRoleFromContext(r.Context())
+	actor := data.GetUserIDFromContext(r.Context())
+	if err := data.RemoveProjectMember(id, userID, actor, role); err != nil {
+		writeMemberError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -53,3 +53,28 @@ func (p *Projects) GetProject(rw http.ResponseWriter, r *http.Request) {
 
 	err = data.ToJSON(project, rw)
 }
+
+// swagger:route GET /projects/{projectID}/members/ ListMembers
+// Return the members of the project with their roles
+//
+// responses:
+//	200: membersResponse
+//  404: errorResponse
+
+// ListMembers handles GET requests and returns the members of the project
+func (p *Projects) ListMembers(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, ok := vars["projectID"]
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	members, err := data.FindProjectMembers(id)
+	if err != nil {
+		http.Error(rw, `{{"error": "project not found"}}`, http.StatusNotFound)
+		return
+	}
+
+	data.ToJSON(members, rw)
+}
@@ -38,38 +38,43 @@ func (p *Projects) UpdateProject(rw http.ResponseWriter, r *http.Request) {
 	modifiedJSON, err := jsonpatch.MergePatch(jsonProj, jsonBody)
 	modifiedProj := &data.Project{}
 	err = json.Unmarshal(modifiedJSON, modifiedProj)
+	// members and the owner are changed with the member endpoints
+	modifiedProj.Owner, modifiedProj.Members = project.Owner, project.Members
+	modifiedProj.Actor = data.GetUserIDFromContext(r.Context())
 	data.UpdateProject(*modifiedProj)
 	err = data.ToJSON(modifiedProj, rw)
 }
 
-// AddMember handles PATCH requests and add a member to the project
-func (p *Projects) AddMember(rw http.ResponseWriter, r *http.Request) {
+// swagger:route PATCH /projects/{projectID}/members/{userID}/ UpdateMember
+// Change the role of a member. Only owners grant the owner role or change
+// the role of an owner, and the project keeps an owner.
+//
+// responses:
+//	200: memberResponse
+//  403: errorResponse
+//  404: errorResponse
+//  409: errorResponse
+//  422: errorValidation
 
-	vars := mux.Vars(r)
-	id, ok := vars["projectID"]
-	jsonBody, err := ioutil.ReadAll(r.Body)
-	user := &data.User{}
-	err = data.FromJSON(user, r.Body)
+// UpdateMember handles PATCH requests and changes the role of a member
+func (p *Projects) UpdateMember(rw http.ResponseWriter, r *http.Request) {
+	member := r.Context().Value(KeyMember{}).(*MemberRequest)
 
+	vars := mux.Vars(r)
+	id := vars["projectID"]
+	userID, ok := vars["userID"]
 	if !ok {
 		io.WriteString(rw, `{{"error": "id not found"}}`)
 		return
 	}
 
-	project, err := data.FindProjectByID(id)
-
-	if err != nil {
-		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusNotFound)
-		return
-	}
-	jsonProj, err := json.Marshal(project)
+	role, _ := data.GetRoleFromContext(r.Context())
+	actor := data.GetUserIDFromContext(r.Context())
+	updated, err := data.UpdateProjectMember(id, data.ProjectMember{ID: userID, Role: member.Role}, actor, role)
 	if err != nil {
-		http.Error(rw, `{{"error": "architecture view not found"}}`, http.StatusInternalServerError)
+		writeMemberError(rw, err)
 		return
 	}
-	modifiedJSON, err := jsonpatch.MergePatch(jsonProj, jsonBody)
-	modifiedProj := &data.Project{}
-	err = json.Unmarshal(modifiedJSON, modifiedProj)
-	data.UpdateProject(*modifiedProj)
-	err = data.ToJSON(modifiedProj, rw)
+
+	data.ToJSON(updated, rw)
 }
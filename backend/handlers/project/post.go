@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"io"
 	"net/http"
 	data "traceability/data"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // swagger:route POST /project CreateProject
@@ -11,6 +15,7 @@ import (
 // responses:
 //	200: productResponse
 //  422: errorValidation
+//  500: errorResponse
 //  501: errorResponse
 
 // CreateProject handles POST requests to add new project
@@ -18,6 +23,91 @@ func (p *Projects) CreateProject(rw http.ResponseWriter, r *http.Request) {
 	project := r.Context().Value(KeyProject{}).(*data.Project)
 	ownerID := data.GetUserIDFromContext(r.Context())
 	p.l.Printf("[DEBUG] Inserting user: %#v, from owner with id: %#v\n", project, ownerID)
-	addedProject := data.AddProject(*project, ownerID)
+	addedProject, err := data.AddProject(*project, ownerID)
+	if err != nil {
+		p.l.Println("[ERROR] creating project", err)
+		http.Error(rw, `{{"error": "project couldn't be created"}}`, http.StatusInternalServerError)
+		return
+	}
 	data.ToJSON(addedProject, rw)
 }
+
+// swagger:route POST /projects/{projectID}/members/ AddMember
+// Add a user to the project by id or email. Only owners add owners.
+//
+// responses:
+//	200: memberResponse
+//  403: errorResponse
+//  404: errorResponse
+//  409: errorResponse
+//  422: errorValidation
+
+// AddMember handles POST requests and adds a user to the project
+func (p *Projects) AddMember(rw http.ResponseWriter, r *http.Request) {
+	member := r.Context().Value(KeyMember{}).(*MemberRequest)
+
+	vars := mux.Vars(r)
+	id, ok := vars["projectID"]
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	userID := member.ID
+	if userID == "" && member.Email == "" {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&GenericError{Message: "id or email of the user is required"}, rw)
+		return
+	}
+	if userID == "" {
+		user, err := data.FindUserByEmail(member.Email)
+		if err == mongo.ErrNoDocuments {
+			writeMemberError(rw, data.ErrUnknownUser)
+			return
+		}
+		if err != nil {
+			http.Error(rw, `{{"error": "user not found"}}`, http.StatusInternalServerError)
+			return
+		}
+		userID = user.ID
+	}
+
+	role, _ := data.GetRoleFromContext(r.Context())
+	actor := data.GetUserIDFromContext(r.Context())
+	added, err := data.AddProjectMember(id, data.ProjectMember{ID: userID, Role: member.Role}, actor, role)
+	if err != nil {
+		writeMemberError(rw, err)
+		return
+	}
+
+	data.ToJSON(added, rw)
+}
+
+// swagger:route POST /projects/{projectID}/members/{userID}/transfer TransferOwnership
+// Make the member the owner of the project, the former owner stays a maintainer
+//
+// responses:
+//	200: projectResponse
+//  403: errorResponse
+//  404: errorResponse
+
+// TransferOwnership handles POST requests and hands the project over to a member
+func (p *Projects) TransferOwnership(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["projectID"]
+	userID, ok := vars["userID"]
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	role, _ := data.GetRoleFromContext(r.Context())
+	actor := data.GetUserIDFromContext(r.Context())
+	project, err := data.TransferProjectOwnership(id, userID, actor, role)
+	if err != nil {
+		writeMemberError(rw, err)
+		return
+	}
+
+	data.ToJSON(project, rw)
+}
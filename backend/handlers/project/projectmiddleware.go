@@ -41,3 +41,39 @@ func (p *Projects) MiddlewareValidateProject(next http.Handler) http.Handler {
 		next.ServeHTTP(rw, r)
 	})
 }
+
+// MiddlewareValidateMember validates the member request and calls next if ok
+func (p *Projects) MiddlewareValidateMember(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		member := &MemberRequest{}
+
+		err := data.FromJSON(member, r.Body)
+		if err != nil {
+			p.l.Println("[ERROR] deserializing member", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+
+		// validate the member
+		errs := p.v.Validate(member)
+		if len(errs) != 0 {
+
+			p.l.Println("[ERROR] validating member", errs)
+
+			// return the validation messages as an array
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// add the member to the context
+		ctx := context.WithValue(r.Context(), KeyMember{}, member)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
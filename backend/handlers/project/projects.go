@@ -3,12 +3,16 @@ package handlers
 import (
 	"fmt"
 	"log"
+	"net/http"
 	"traceability/data"
 )
 
 // KeyProject is a key used for the Project object in the context
 type KeyProject struct{}
 
+// KeyMember is a key used for the MemberRequest object in the context
+type KeyMember struct{}
+
 // Projects handler
 type Projects struct {
 	l *log.Logger
@@ -32,3 +36,40 @@ type GenericError struct {
 type ValidationError struct {
 	Messages []string `json:"messages"`
 }
+
+// MemberRequest adds a user to a project or changes the role of a member
+// swagger:model
+type MemberRequest struct {
+	// id of the user to add, either it or the email is needed to add a member
+	//
+	// required: false
+	ID string `json:"id,omitempty"`
+
+	// email of the user to add
+	//
+	// required: false
+	Email string `json:"email,omitempty" validate:"omitempty,email"`
+
+	// name of a built-in or custom role of the project
+	//
+	// required: true
+	Role string `json:"role" validate:"required"`
+}
+
+// writeMemberError answers the request with the status of an error of the member functions
+func writeMemberError(rw http.ResponseWriter, err error) {
+	switch err {
+	case data.ErrNotProjectMember, data.ErrUnknownUser:
+		rw.WriteHeader(http.StatusNotFound)
+	case data.ErrOwnerRequired:
+		rw.WriteHeader(http.StatusForbidden)
+	case data.ErrMemberExists, data.ErrLastOwner, data.ErrMembersChanged:
+		rw.WriteHeader(http.StatusConflict)
+	case data.ErrUnknownRole:
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		http.Error(rw, `{{"error": "members couldn't be changed"}}`, http.StatusInternalServerError)
+		return
+	}
+	data.ToJSON(&GenericError{Message: err.Error()}, rw)
+}
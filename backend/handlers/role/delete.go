@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route DELETE /projects/{projectID}/roles/{name}/ DeleteRole
+// Remove a custom role which no member has
+//
+// responses:
+//	204: noContent
+//  404: errorResponse
+//  409: errorResponse
+
+// DeleteRole handles DELETE requests and removes a custom role
+func (rh *Roles) DeleteRole(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	name, ok := vars["name"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "name not found"}}`)
+		return
+	}
+
+	err := data.DeleteRole(projectID, name)
+	switch err {
+	case nil:
+		rw.WriteHeader(http.StatusNoContent)
+	case data.ErrUnknownRole:
+		http.Error(rw, `{{"error": "role not found"}}`, http.StatusNotFound)
+	case data.ErrBuiltInRole, data.ErrRoleInUse:
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	default:
+		http.Error(rw, `{{"error": "role couldn't be deleted"}}`, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /projects/{projectID}/roles/ ListRoles
+// Return the built-in and custom roles of the project
+//
+// responses:
+//	200: rolesResponse
+
+// ListRoles handles GET requests and returns the roles of the project
+func (rh *Roles) ListRoles(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	roles, err := data.FindRolesOfProject(projectID)
+	if err != nil {
+		http.Error(rw, `{{"error": "roles not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(roles, rw)
+}
+
+// swagger:route GET /projects/{projectID}/roles/{name}/ GetRole
+// Return a role of the project
+//
+// responses:
+//	200: roleResponse
+//  404: errorResponse
+
+// GetRole handles GET requests and returns the role by name
+func (rh *Roles) GetRole(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	name, ok := vars["name"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "name not found"}}`)
+		return
+	}
+
+	role, err := data.FindRole(projectID, name)
+	if err == data.ErrUnknownRole {
+		http.Error(rw, `{{"error": "role not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "role not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(role, rw)
+}
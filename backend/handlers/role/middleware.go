@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"traceability/data"
+)
+
+// MiddlewareValidateRole validates the role in the request and calls next if ok
+func (rh *Roles) MiddlewareValidateRole(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		role := &data.Role{}
+
+		err := data.FromJSON(role, r.Body)
+		if err != nil {
+			rh.l.Println("[ERROR] deserializing role", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+
+		// validate the role
+		errs := rh.v.Validate(role)
+		if len(errs) != 0 {
+
+			rh.l.Println("[ERROR] validating role", errs)
+
+			// return the validation messages as an array
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// add the role to the context
+		ctx := context.WithValue(r.Context(), KeyRole{}, role)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	data "traceability/data"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gorilla/mux"
+)
+
+// swagger:route PATCH /projects/{projectID}/roles/{name}/ UpdateRole
+// Change the label or permissions of a custom role
+//
+// responses:
+//	200: roleResponse
+//  404: errorResponse
+//  409: errorResponse
+//  422: errorValidation
+
+// UpdateRole handles PATCH requests and updates a custom role
+func (rh *Roles) UpdateRole(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	name, ok := vars["name"]
+	jsonBody, err := ioutil.ReadAll(r.Body)
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "name not found"}}`)
+		return
+	}
+
+	role, err := data.FindRole(projectID, name)
+	if err != nil {
+		http.Error(rw, `{{"error": "role not found"}}`, http.StatusNotFound)
+		return
+	}
+	jsonRole, err := json.Marshal(role)
+	if err != nil {
+		http.Error(rw, `{{"error": "role not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	modifiedJSON, err := jsonpatch.MergePatch(jsonRole, jsonBody)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	modifiedRole := &data.Role{}
+	err = json.Unmarshal(modifiedJSON, modifiedRole)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	// identity of the role stays, members refer to its name
+	modifiedRole.ID, modifiedRole.ProjectID, modifiedRole.Name = role.ID, role.ProjectID, role.Name
+
+	errs := rh.v.Validate(modifiedRole)
+	if len(errs) != 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+		return
+	}
+
+	err = data.UpdateRole(*modifiedRole)
+	if err == data.ErrBuiltInRole {
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "role couldn't be updated"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(modifiedRole, rw)
+}
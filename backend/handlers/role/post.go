@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /projects/{projectID}/roles/ CreateRole
+// Define a new role in the project
+//
+// responses:
+//	200: roleResponse
+//  409: errorResponse
+//  422: errorValidation
+
+// CreateRole handles POST requests to define a new role
+func (rh *Roles) CreateRole(rw http.ResponseWriter, r *http.Request) {
+	role := r.Context().Value(KeyRole{}).(*data.Role)
+
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	role.ProjectID = projectID
+	added, err := data.AddRole(*role)
+	if err == data.ErrRoleExists {
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "role couldn't be added"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(added, rw)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"log"
+	"traceability/data"
+)
+
+// KeyRole is a key used for the role object in the context
+type KeyRole struct{}
+
+// Roles handler manages the custom roles of projects
+type Roles struct {
+	l *log.Logger
+	v *data.Validation
+}
+
+// NewRoles returns a new roles handler with the given logger
+func NewRoles(l *log.Logger, v *data.Validation) *Roles {
+	return &Roles{l, v}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	authentication "traceability/auth"
+	data "traceability/data"
+	"traceability/mail"
+)
+
+// VerifyEmailRequest carries the token of an email verification link
+// swagger:model
+type VerifyEmailRequest struct {
+	// token from the link in the email
+	//
+	// required: true
+	Token string `json:"token" validate:"required"`
+}
+
+// ForgotPasswordRequest asks for a password reset link
+// swagger:model
+type ForgotPasswordRequest struct {
+	// email of the user
+	//
+	// required: true
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password with the token of a reset link
+// swagger:model
+type ResetPasswordRequest struct {
+	// token from the link in the email
+	//
+	// required: true
+	Token string `json:"token" validate:"required"`
+
+	// the new password, it follows the rules of new passwords
+	//
+	// required: true
+	Password string `json:"password" validate:"required,password"`
+}
+
+// sendUserToken emails a verification or password reset link to the user
+func (u *Users) sendUserToken(user data.User, purpose data.UserTokenPurpose) error {
+	t, err := data.AddUserToken(user, purpose)
+	if err != nil {
+		return err
+	}
+	token, err := authentication.SignUserToken(t)
+	if err != nil {
+		return err
+	}
+
+	expires := t.ExpiresAt.Format("January 2, 2006 15:04 MST")
+	msg := mail.Message{To: []string{user.Email}}
+	if purpose == data.PurposeResetPassword {
+		msg.Subject = "Reset your password"
+		msg.Body = fmt.Sprintf(`Someone asked to reset the password of your account, ignore this email if it wasn't you.
+
+Open the link to choose a new password:
+%s/reset-password/%s
+
+The link works once and expires on %s.
+`, u.baseURL, token, expires)
+	} else {
+		msg.Subject = "Confirm your email"
+		msg.Body = fmt.Sprintf(`Hi %s,
+
+Open the link to confirm your email, you can only read projects until you do:
+%s/verify/%s
+
+The link works once and expires on %s.
+`, user.Name, u.baseURL, token, expires)
+	}
+	return u.m.Send(msg)
+}
+
+// decodeRequest reads and validates the json body of the request into req,
+// it answers the request and returns false if it isn't valid
+func (u *Users) decodeRequest(rw http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := data.FromJSON(req, r.Body); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return false
+	}
+	if errs := u.v.Validate(req); len(errs) != 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+		return false
+	}
+	return true
+}
+
+// writeUserTokenError answers the request with the status of an error of a verification or reset token
+func (u *Users) writeUserTokenError(rw http.ResponseWriter, err error) {
+	if err == data.ErrUnknownUserToken {
+		rw.WriteHeader(http.StatusGone)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	u.l.Println("[ERROR] using emailed token", err)
+	rw.WriteHeader(http.StatusInternalServerError)
+	io.WriteString(rw, `{{"error":"server is not working"}}`)
+}
+
+// swagger:route POST /verify users verifyEmail
+// Confirm the email of a user with the token of the verification link
+//
+// responses:
+//	204: noContent
+//  410: errorResponse
+//  422: errorValidation
+
+// VerifyEmail handles POST requests and confirms the email of the token
+func (u *Users) VerifyEmail(rw http.ResponseWriter, r *http.Request) {
+	req := &VerifyEmailRequest{}
+	if !u.decodeRequest(rw, r, req) {
+		return
+	}
+
+	id, err := authentication.ParseUserToken(req.Token, data.PurposeVerifyEmail)
+	if err == nil {
+		_, err = data.VerifyEmail(id)
+	}
+	if err != nil {
+		u.writeUserTokenError(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// swagger:route POST /verify/resend users resendVerification
+// Send a new email verification link to the logged in user
+//
+// responses:
+//	204: noContent
+//  409: errorResponse
+
+// ResendVerification handles POST requests and emails a new verification link
+func (u *Users) ResendVerification(rw http.ResponseWriter, r *http.Request) {
+	user, err := data.FindUserByID(data.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		http.Error(rw, `{{"error": "user not found"}}`, http.StatusNotFound)
+		return
+	}
+	if !user.Unverified {
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: "email is verified already"}, rw)
+		return
+	}
+
+	if err := u.sendUserToken(user, data.PurposeVerifyEmail); err != nil {
+		u.l.Println("[ERROR] sending email verification", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(rw, `{{"error":"server is not working"}}`)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// swagger:route POST /password/forgot users forgotPassword
+// Email a password reset link. The answer is the same whether or not a user
+// has the email so it can't be used to find accounts.
+//
+// responses:
+//	202: noContent
+//  422: errorValidation
+
+// ForgotPassword handles POST requests and emails a password reset link
+func (u *Users) ForgotPassword(rw http.ResponseWriter, r *http.Request) {
+	req := &ForgotPasswordRequest{}
+	if !u.decodeRequest(rw, r, req) {
+		return
+	}
+
+	if user, err := data.FindUserByEmail(req.Email); err == nil {
+		if err := u.sendUserToken(user, data.PurposeResetPassword); err != nil {
+			u.l.Println("[ERROR] sending password reset", err)
+		}
+	}
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// swagger:route POST /password/reset users resetPassword
+// Set a new password with the token of the reset link, the user is logged
+// out everywhere
+//
+// responses:
+//	204: noContent
+//  410: errorResponse
+//  422: errorValidation
+
+// ResetPassword handles POST requests and sets the password of the token
+func (u *Users) ResetPassword(rw http.ResponseWriter, r *http.Request) {
+	req := &ResetPasswordRequest{}
+	if !u.decodeRequest(rw, r, req) {
+		return
+	}
+
+	id, err := authentication.ParseUserToken(req.Token, data.PurposeResetPassword)
+	if err == nil {
+		_, err = data.ResetPassword(id, req.Password)
+	}
+	if err != nil {
+		u.writeUserTokenError(rw, err)
+		return
+	}
+	authentication.ClearCookies(rw)
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -10,17 +10,21 @@ import (
 )
 
 // swagger:route GET /users listUsers
-// Return a list of users from the database
+// Return the users sharing a project with the user
 // responses:
 // 200: usersResponse
 
-// ListAll handles GET requests and returns all current users
+// ListAll handles GET requests and returns the members of the user's projects
 func (u *Users) ListAll(rw http.ResponseWriter, r *http.Request) {
 	u.l.Println("[DEBUG] get all records")
 
-	users := data.GetAllUsers()
+	users, err := data.FindUsersSharingProjects(data.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		http.Error(rw, `{{"error": "users not found"}}`, http.StatusInternalServerError)
+		return
+	}
 
-	err := data.ToJSON(users, rw)
+	err = data.ToJSON(users, rw)
 	if err != nil {
 		// we should never be here but log the error just incase
 		u.l.Println("[ERROR] serializing user", err)
@@ -4,22 +4,27 @@ import (
 	"context"
 	"io"
 	"net/http"
-	"time"
+	"strings"
 	authentication "traceability/auth"
 	data "traceability/data"
 	db "traceability/database"
+	"traceability/ratelimit"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // swagger:route POST /login users LoginUser
-// Login the user
+// Login the user. Unknown emails and wrong passwords get the same answer;
+// after failed logins the account and the address wait longer and longer,
+// and are locked out for a while after too many. The wait of the account
+// only holds for the addresses which failed to log into it.
 //
 // responses:
 //	200: productResponse
+//  401: errorResponse
 //  422: errorValidation
+//  429: errorResponse
 //  501: errorResponse
 
 // LoginUser handles POST requests to login the user
@@ -27,28 +32,41 @@ func (p *Users) LoginUser(rw http.ResponseWriter, r *http.Request) {
 	auth := r.Context().Value(KeyAuth{}).(*data.Auth)
 	p.l.Printf("[DEBUG] Login user: %#v\n", auth.Email)
 
+	account := strings.ToLower(strings.TrimSpace(auth.Email))
+	address := ratelimit.ClientIP(r)
+	if wait := p.logins.Wait(account, address); wait > 0 {
+		ratelimit.WriteTooManyRequests(rw, wait)
+		return
+	}
+
 	filter := bson.D{primitive.E{Key: "email", Value: auth.Email}}
 	collection := db.DB.Collection(db.UserCollectionName)
 
 	var resultUser data.User
 	queryResult := collection.FindOne(context.TODO(), filter)
 
-	err := queryResult.Decode(&resultUser)
-	if err != nil {
-		rw.WriteHeader(http.StatusUnauthorized)
-		io.WriteString(rw, `{{"error":"invalid email"}}`)
-		return
+	found := queryResult.Decode(&resultUser) == nil
+	if !found {
+		// hash the password anyway, unknown emails take as long to answer as wrong passwords
+		data.HashAndSalt([]byte(auth.Password))
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(resultUser.Password), []byte(auth.Password))
-
-	if err != nil {
+	if !found || !data.ComparePassword(resultUser.Password, []byte(auth.Password)) {
+		p.logins.Fail(account, address)
 		rw.WriteHeader(http.StatusUnauthorized)
 		io.WriteString(rw, `{{"error":"invalid_credentials"}}`)
 		return
 	}
+	p.logins.Succeed(account)
 
-	accessToken, err := authentication.CreateToken(auth.Email)
+	// the password is known only now, hashes made with an older policy are replaced
+	if data.NeedsRehash(resultUser.Password) {
+		if err := data.UpdateUserPassword(resultUser.ID, auth.Password); err != nil {
+			p.l.Println("[ERROR] rehashing password", err)
+		}
+	}
+
+	err := authentication.Login(rw, r, &resultUser)
 
 	if err != nil {
 		rw.WriteHeader(http.StatusInternalServerError)
@@ -56,15 +74,7 @@ func (p *Users) LoginUser(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultUser.AccessToken = accessToken
-	expirationTime := time.Now().Add(1200 * time.Hour)
-
-	http.SetCookie(rw, &http.Cookie{
-		Name:    "accesstoken",
-		Value:   accessToken,
-		Expires: expirationTime,
-	})
-	data.FindUserAndUpdateAccessToken(resultUser)
-	err = data.ToJSON(resultUser, rw)
+	p.acceptInvitation(auth.Invitation, resultUser.ID)
+	err = data.ToJSON(resultUser.WithTokens(), rw)
 	return
 }
@@ -3,7 +3,6 @@ package handlers
 import (
 	"io"
 	"net/http"
-	"time"
 	authentication "traceability/auth"
 	data "traceability/data"
 )
@@ -18,9 +17,12 @@ import (
 
 // CreateUser handles POST requests to add new user
 func (u *Users) CreateUser(rw http.ResponseWriter, r *http.Request) {
-	user := r.Context().Value(KeyUser{}).(*data.User)
-	u.l.Printf("[DEBUG] Inserting user: %#v\n", user)
-	accessToken, err := authentication.CreateToken(user.Email)
+	user := r.Context().Value(KeyUser{}).(*data.Signup)
+	u.l.Printf("[DEBUG] Inserting user: %#v\n", user.Email)
+	resultUser, err := data.AddUser(data.User{Name: user.Name, Email: user.Email, Password: user.Password})
+	if err == nil {
+		err = authentication.Login(rw, r, resultUser)
+	}
 
 	if err != nil {
 		rw.WriteHeader(http.StatusInternalServerError)
@@ -28,15 +30,9 @@ func (u *Users) CreateUser(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user.AccessToken = accessToken
-	expirationTime := time.Now().Add(1200 * time.Hour)
-
-	http.SetCookie(rw, &http.Cookie{
-		Name:    "accesstoken",
-		Value:   accessToken,
-		Expires: expirationTime,
-	})
-
-	resultUser := data.AddUser(*user)
-	data.ToJSON(*resultUser, rw)
+	if err := u.sendUserToken(*resultUser, data.PurposeVerifyEmail); err != nil {
+		u.l.Println("[ERROR] sending email verification", err)
+	}
+	u.acceptInvitation(user.Invitation, resultUser.ID)
+	data.ToJSON(resultUser.WithTokens(), rw)
 }
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	authentication "traceability/auth"
+	data "traceability/data"
+)
+
+// RefreshRequest carries the refresh token of clients not using cookies
+// swagger:model
+type RefreshRequest struct {
+	// refresh token from the login or the last refresh
+	//
+	// required: false
+	RefreshToken string `json:"refreshToken"`
+}
+
+// swagger:route POST /refresh users refreshToken
+// Get a new access token with the refresh token from the cookie or the body,
+// the refresh token is replaced by a new one
+//
+// responses:
+//	200: userResponse
+//  401: errorResponse
+
+// RefreshToken handles POST requests and rotates the tokens of the session
+func (u *Users) RefreshToken(rw http.ResponseWriter, r *http.Request) {
+	token := ""
+	if cookie, err := r.Cookie(authentication.RefreshTokenCookie); err == nil {
+		token = cookie.Value
+	}
+	if token == "" {
+		req := &RefreshRequest{}
+		if err := data.FromJSON(req, r.Body); err == nil {
+			token = req.RefreshToken
+		}
+	}
+
+	user, err := authentication.Refresh(rw, token)
+	switch err {
+	case nil:
+		data.ToJSON(user.WithTokens(), rw)
+	case data.ErrUnknownRefreshToken, data.ErrRefreshTokenReused, data.ErrSessionEnded:
+		authentication.ClearCookies(rw)
+		rw.WriteHeader(http.StatusUnauthorized)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	default:
+		u.l.Println("[ERROR] refreshing token", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(rw, `{{"error":"server is not working"}}`)
+	}
+}
+
+// swagger:route POST /logout users logout
+// End the session of the access token, its tokens stop working
+//
+// responses:
+//	204: noContent
+
+// Logout handles POST requests and revokes the session of the request
+func (u *Users) Logout(rw http.ResponseWriter, r *http.Request) {
+	userID := data.GetUserIDFromContext(r.Context())
+	err := data.RevokeSession(data.GetSessionIDFromContext(r.Context()), userID)
+	if err != nil {
+		u.l.Println("[ERROR] revoking session", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(rw, `{{"error":"server is not working"}}`)
+		return
+	}
+
+	authentication.ClearCookies(rw)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// swagger:route POST /logout/all users logoutEverywhere
+// End all sessions of the user on every device
+//
+// responses:
+//	204: noContent
+
+// LogoutEverywhere handles POST requests and revokes all sessions of the user
+func (u *Users) LogoutEverywhere(rw http.ResponseWriter, r *http.Request) {
+	err := data.RevokeAllSessions(data.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		u.l.Println("[ERROR] revoking sessions", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(rw, `{{"error":"server is not working"}}`)
+		return
+	}
+
+	authentication.ClearCookies(rw)
+	rw.WriteHeader(http.StatusNoContent)
+}
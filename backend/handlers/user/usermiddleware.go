@@ -10,7 +10,7 @@ import (
 func (p *Users) MiddlewareValidateUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 
-		user := &data.User{}
+		user := &data.Signup{}
 
 		err := data.FromJSON(user, r.Body)
 		if err != nil {
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"traceability/data"
+	"traceability/mail"
+	"traceability/ratelimit"
 )
 
 // KeyUser is a key used for the User object in the context
@@ -16,11 +18,19 @@ type KeyAuth struct{}
 type Users struct {
 	l *log.Logger
 	v *data.Validation
+	m mail.Mailer
+
+	// baseURL prefixes the verification and password reset links in the emails
+	baseURL string
+
+	// logins slows down failing logins per account and address
+	logins *ratelimit.LoginGuard
 }
 
-// NewUsers returns a new users handler with the given logger
-func NewUsers(l *log.Logger, v *data.Validation) *Users {
-	return &Users{l, v}
+// NewUsers returns a new users handler sending the emails with the mailer,
+// their links start with baseURL. Failed logins wait as the guard tells.
+func NewUsers(l *log.Logger, v *data.Validation, m mail.Mailer, baseURL string, logins *ratelimit.LoginGuard) *Users {
+	return &Users{l, v, m, baseURL, logins}
 }
 
 // ErrInvalidProductPath is an error message when the user path is not valid
@@ -35,3 +45,15 @@ type GenericError struct {
 type ValidationError struct {
 	Messages []string `json:"messages"`
 }
+
+// acceptInvitation joins the user to the project of the invitation token
+// given on signup or login. A failure doesn't fail the signup or login, the
+// invitation can still be accepted on its own.
+func (u *Users) acceptInvitation(token string, userID string) {
+	if token == "" {
+		return
+	}
+	if _, err := data.AcceptInvitation(token, userID); err != nil {
+		u.l.Println("[ERROR] accepting invitation", err)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route DELETE /projects/{projectID}/viewkinds/{name}/ DeleteViewKind
+// Remove a custom view kind which is not used by any view
+//
+// responses:
+//	204: noContent
+//  404: errorResponse
+//  409: errorResponse
+
+// DeleteViewKind handles DELETE requests and removes a custom view kind
+func (vk *ViewKinds) DeleteViewKind(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	name, ok := vars["name"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "name not found"}}`)
+		return
+	}
+
+	err := data.DeleteViewKind(projectID, name)
+	switch err {
+	case nil:
+		rw.WriteHeader(http.StatusNoContent)
+	case data.ErrUnknownViewKind:
+		http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusNotFound)
+	case data.ErrBuiltInViewKind, data.ErrViewKindInUse:
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	default:
+		http.Error(rw, `{{"error": "view kind couldn't be deleted"}}`, http.StatusInternalServerError)
+	}
+}
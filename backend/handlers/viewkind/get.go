@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route GET /projects/{projectID}/viewkinds/ ListViewKinds
+// Return the built-in and custom view kinds of the project
+//
+// responses:
+//	200: viewKindsResponse
+
+// ListViewKinds handles GET requests and returns the view kinds of the project
+func (vk *ViewKinds) ListViewKinds(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	kinds, err := data.FindViewKindsOfProject(projectID)
+	if err != nil {
+		http.Error(rw, `{{"error": "view kinds not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(kinds, rw)
+}
+
+// swagger:route GET /projects/{projectID}/viewkinds/{name}/ GetViewKind
+// Return a view kind of the project
+//
+// responses:
+//	200: viewKindResponse
+//  404: errorResponse
+
+// GetViewKind handles GET requests and returns the view kind by name
+func (vk *ViewKinds) GetViewKind(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	name, ok := vars["name"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "name not found"}}`)
+		return
+	}
+
+	kind, err := data.FindViewKind(projectID, name)
+	if err == data.ErrUnknownViewKind {
+		http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(kind, rw)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"traceability/data"
+)
+
+// MiddlewareValidateViewKind validates the view kind in the request and calls next if ok
+func (vk *ViewKinds) MiddlewareValidateViewKind(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		viewKind := &data.ViewKindDefinition{}
+
+		err := data.FromJSON(viewKind, r.Body)
+		if err != nil {
+			vk.l.Println("[ERROR] deserializing view kind", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+
+		// validate the view kind
+		errs := vk.v.Validate(viewKind)
+		if len(errs) != 0 {
+
+			vk.l.Println("[ERROR] validating view kind", errs)
+
+			// return the validation messages as an array
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// add the view kind to the context
+		ctx := context.WithValue(r.Context(), KeyViewKind{}, viewKind)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	data "traceability/data"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gorilla/mux"
+)
+
+// swagger:route PATCH /projects/{projectID}/viewkinds/{name}/ UpdateViewKind
+// Change the label, icon, colour or allowed attributes of a custom view kind,
+// of built-in view kinds only the attribute schemas can be changed
+//
+// responses:
+//	200: viewKindResponse
+//  404: errorResponse
+//  409: errorResponse
+//  422: errorValidation
+
+// UpdateViewKind handles PATCH requests and updates a view kind
+func (vk *ViewKinds) UpdateViewKind(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	name, ok := vars["name"]
+	jsonBody, err := ioutil.ReadAll(r.Body)
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "name not found"}}`)
+		return
+	}
+
+	kind, err := data.FindViewKind(projectID, name)
+	if err != nil {
+		http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusNotFound)
+		return
+	}
+	jsonKind, err := json.Marshal(kind)
+	if err != nil {
+		http.Error(rw, `{{"error": "view kind not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	modifiedJSON, err := jsonpatch.MergePatch(jsonKind, jsonBody)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	modifiedKind := &data.ViewKindDefinition{}
+	err = json.Unmarshal(modifiedJSON, modifiedKind)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	// identity of the kind stays, views and components refer to its name
+	modifiedKind.ID, modifiedKind.ProjectID, modifiedKind.Name = kind.ID, kind.ProjectID, kind.Name
+
+	errs := vk.v.Validate(modifiedKind)
+	if len(errs) != 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+		return
+	}
+
+	err = data.UpdateViewKind(*modifiedKind)
+	if err == data.ErrBuiltInViewKind {
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "view kind couldn't be updated"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(modifiedKind, rw)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /projects/{projectID}/viewkinds/ CreateViewKind
+// Define a new view kind in the project
+//
+// responses:
+//	200: viewKindResponse
+//  409: errorResponse
+//  422: errorValidation
+
+// CreateViewKind handles POST requests to define a new view kind
+func (vk *ViewKinds) CreateViewKind(rw http.ResponseWriter, r *http.Request) {
+	viewKind := r.Context().Value(KeyViewKind{}).(*data.ViewKindDefinition)
+
+	vars := mux.Vars(r)
+	projectID, ok := vars["projectID"]
+
+	if !ok {
+		io.WriteString(rw, `{{"error": "id not found"}}`)
+		return
+	}
+
+	viewKind.ProjectID = projectID
+	added, err := data.AddViewKind(*viewKind)
+	if err == data.ErrViewKindExists {
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "view kind couldn't be added"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(added, rw)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"log"
+	"traceability/data"
+)
+
+// KeyViewKind is a key used for the view kind object in the context
+type KeyViewKind struct{}
+
+// ViewKinds handler manages the view kinds defined in projects
+type ViewKinds struct {
+	l *log.Logger
+	v *data.Validation
+}
+
+// NewViewKinds returns a new view kinds handler with the given logger
+func NewViewKinds(l *log.Logger, v *data.Validation) *ViewKinds {
+	return &ViewKinds{l, v}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route DELETE /projects/{projectID}/webhooks/{webhookID}/ DeleteWebhook
+// Remove a webhook and its delivery log
+//
+// responses:
+//	204: noContent
+//  404: errorResponse
+
+// DeleteWebhook handles DELETE requests and removes the webhook
+func (wh *Webhooks) DeleteWebhook(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	err := data.DeleteWebhook(vars["projectID"], vars["webhookID"])
+	switch err {
+	case nil:
+		rw.WriteHeader(http.StatusNoContent)
+	case data.ErrUnknownWebhook:
+		http.Error(rw, `{{"error": "webhook not found"}}`, http.StatusNotFound)
+	default:
+		http.Error(rw, `{{"error": "webhook couldn't be deleted"}}`, http.StatusInternalServerError)
+	}
+}
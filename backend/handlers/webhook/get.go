@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	data "traceability/data"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+// swagger:route GET /projects/{projectID}/webhooks/ ListWebhooks
+// Return the webhooks of the project without their secrets
+//
+// responses:
+//	200: webhooksResponse
+
+// ListWebhooks handles GET requests and returns the webhooks of the project
+func (wh *Webhooks) ListWebhooks(rw http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["projectID"]
+
+	hooks, err := data.FindWebhooksOfProject(projectID)
+	if err != nil {
+		http.Error(rw, `{{"error": "webhooks not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	data.ToJSON(hooks, rw)
+}
+
+// swagger:route GET /projects/{projectID}/webhooks/{webhookID}/ GetWebhook
+// Return a webhook of the project without its secret
+//
+// responses:
+//	200: webhookResponse
+//  404: errorResponse
+
+// GetWebhook handles GET requests and returns the webhook by ID
+func (wh *Webhooks) GetWebhook(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	hook, err := data.FindWebhookByID(vars["projectID"], vars["webhookID"])
+	if err == data.ErrUnknownWebhook {
+		http.Error(rw, `{{"error": "webhook not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "webhook not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	hook.Secret = ""
+
+	data.ToJSON(hook, rw)
+}
+
+// swagger:route GET /projects/{projectID}/webhooks/{webhookID}/deliveries/ ListWebhookDeliveries
+// Return the newest deliveries of a webhook with their attempts and response codes, "?limit=" up to 200
+//
+// responses:
+//	200: webhookDeliveriesResponse
+//  400: errorResponse
+//  404: errorResponse
+
+// ListDeliveries handles GET requests and returns the delivery log of the webhook
+func (wh *Webhooks) ListDeliveries(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	limit := int64(defaultDeliveriesLimit)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 || n > maxDeliveriesLimit {
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: "limit has to be between 1 and 200"}, rw)
+			return
+		}
+		limit = n
+	}
+
+	if _, err := data.FindWebhookByID(vars["projectID"], vars["webhookID"]); err != nil {
+		http.Error(rw, `{{"error": "webhook not found"}}`, http.StatusNotFound)
+		return
+	}
+	deliveries, err := data.FindWebhookDeliveries(vars["webhookID"], limit)
+	if err != nil {
+		http.Error(rw, `{{"error": "deliveries not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(deliveries, rw)
+}
+
+// swagger:route GET /projects/{projectID}/webhooks/{webhookID}/deliveries/{deliveryID}/ GetWebhookDelivery
+// Return a delivery of a webhook
+//
+// responses:
+//	200: webhookDeliveryResponse
+//  404: errorResponse
+
+// GetDelivery handles GET requests and returns the delivery by ID
+func (wh *Webhooks) GetDelivery(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if _, err := data.FindWebhookByID(vars["projectID"], vars["webhookID"]); err != nil {
+		http.Error(rw, `{{"error": "webhook not found"}}`, http.StatusNotFound)
+		return
+	}
+	delivery, err := data.FindWebhookDelivery(vars["webhookID"], vars["deliveryID"])
+	if err == data.ErrUnknownDelivery {
+		http.Error(rw, `{{"error": "delivery not found"}}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, `{{"error": "delivery not found"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(delivery, rw)
+}
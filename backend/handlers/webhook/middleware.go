@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"traceability/data"
+)
+
+// MiddlewareValidateWebhook validates the webhook in the request and calls next if ok
+func (wh *Webhooks) MiddlewareValidateWebhook(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+
+		hook := &data.Webhook{}
+
+		err := data.FromJSON(hook, r.Body)
+		if err != nil {
+			wh.l.Println("[ERROR] deserializing webhook", err)
+
+			rw.WriteHeader(http.StatusBadRequest)
+			data.ToJSON(&GenericError{Message: err.Error()}, rw)
+			return
+		}
+
+		// validate the webhook
+		errs := wh.v.Validate(hook)
+		if len(errs) != 0 {
+
+			wh.l.Println("[ERROR] validating webhook", errs)
+
+			// return the validation messages as an array
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+			return
+		}
+
+		// add the webhook to the context
+		ctx := context.WithValue(r.Context(), KeyWebhook{}, hook)
+		r = r.WithContext(ctx)
+
+		// Call the next handler, which can be another middleware in the chain, or the final handler.
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	data "traceability/data"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gorilla/mux"
+)
+
+// swagger:route PATCH /projects/{projectID}/webhooks/{webhookID}/ UpdateWebhook
+// Change the url, events, secret or active state of a webhook
+//
+// responses:
+//	200: webhookResponse
+//  404: errorResponse
+//  422: errorValidation
+
+// UpdateWebhook handles PATCH requests and updates the webhook
+func (wh *Webhooks) UpdateWebhook(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jsonBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+
+	hook, err := data.FindWebhookByID(vars["projectID"], vars["webhookID"])
+	if err != nil {
+		http.Error(rw, `{{"error": "webhook not found"}}`, http.StatusNotFound)
+		return
+	}
+	jsonHook, err := json.Marshal(hook)
+	if err != nil {
+		http.Error(rw, `{{"error": "webhook not found"}}`, http.StatusInternalServerError)
+		return
+	}
+	modifiedJSON, err := jsonpatch.MergePatch(jsonHook, jsonBody)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	modifiedHook := &data.Webhook{}
+	err = json.Unmarshal(modifiedJSON, modifiedHook)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+		return
+	}
+	modifiedHook.ID, modifiedHook.ProjectID, modifiedHook.CreatedAt = hook.ID, hook.ProjectID, hook.CreatedAt
+	if modifiedHook.Secret == "" {
+		modifiedHook.Secret = hook.Secret
+	}
+
+	errs := wh.v.Validate(modifiedHook)
+	if len(errs) != 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		data.ToJSON(&ValidationError{Messages: errs.Errors()}, rw)
+		return
+	}
+
+	if err := data.UpdateWebhook(*modifiedHook); err != nil {
+		http.Error(rw, `{{"error": "webhook couldn't be updated"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	modifiedHook.Secret = ""
+	data.ToJSON(modifiedHook, rw)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	data "traceability/data"
+	"traceability/webhook"
+
+	"github.com/gorilla/mux"
+)
+
+// swagger:route POST /projects/{projectID}/webhooks/ CreateWebhook
+// Register a webhook, the secret of the signatures is only returned here
+//
+// responses:
+//	200: webhookResponse
+//  422: errorValidation
+
+// CreateWebhook handles POST requests to add a new webhook
+func (wh *Webhooks) CreateWebhook(rw http.ResponseWriter, r *http.Request) {
+	hook := r.Context().Value(KeyWebhook{}).(*data.Webhook)
+
+	hook.ProjectID = mux.Vars(r)["projectID"]
+	if hook.Secret == "" {
+		hook.Secret = webhook.NewSecret()
+	}
+	added, err := data.AddWebhook(*hook)
+	if err != nil {
+		http.Error(rw, `{{"error": "webhook couldn't be added"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	data.ToJSON(added, rw)
+}
+
+// swagger:route POST /projects/{projectID}/webhooks/{webhookID}/deliveries/{deliveryID}/replay ReplayWebhookDelivery
+// Post a delivery again right away, the response contains the new attempt
+//
+// responses:
+//	200: webhookDeliveryResponse
+//  404: errorResponse
+//  409: errorResponse
+
+// ReplayDelivery handles POST requests to replay a delivery
+func (wh *Webhooks) ReplayDelivery(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	delivery, err := wh.d.Replay(vars["projectID"], vars["webhookID"], vars["deliveryID"])
+	switch err {
+	case nil:
+		data.ToJSON(delivery, rw)
+	case data.ErrUnknownWebhook:
+		http.Error(rw, `{{"error": "webhook not found"}}`, http.StatusNotFound)
+	case data.ErrUnknownDelivery:
+		http.Error(rw, `{{"error": "delivery not found"}}`, http.StatusNotFound)
+	case data.ErrWebhookInactive:
+		rw.WriteHeader(http.StatusConflict)
+		data.ToJSON(&GenericError{Message: err.Error()}, rw)
+	default:
+		wh.l.Println("[ERROR] replaying delivery", err)
+		http.Error(rw, `{{"error": "delivery couldn't be replayed"}}`, http.StatusInternalServerError)
+	}
+}
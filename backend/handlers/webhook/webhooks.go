@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"log"
+	"traceability/data"
+	"traceability/webhook"
+)
+
+// KeyWebhook is a key used for the webhook object in the context
+type KeyWebhook struct{}
+
+// Webhooks handler manages the webhooks of projects and their deliveries
+type Webhooks struct {
+	l *log.Logger
+	v *data.Validation
+	d *webhook.Dispatcher
+}
+
+// NewWebhooks returns a new webhooks handler replaying deliveries with the dispatcher
+func NewWebhooks(l *log.Logger, v *data.Validation, d *webhook.Dispatcher) *Webhooks {
+	return &Webhooks{l, v, d}
+}
+
+// GenericError is a generic error message returned by a server
+type GenericError struct {
+	Message string `json:"message"`
+}
+
+// ValidationError is a collection of validation error messages
+type ValidationError struct {
+	Messages []string `json:"messages"`
+}
@@ -0,0 +1,134 @@
+package interchange
+
+import (
+	"encoding/xml"
+	"io"
+
+	data "traceability/data"
+)
+
+const gexfNamespace = "http://www.gexf.net/1.2draft"
+
+// gexfWriter writes views and components as one node list, components point
+// to their view with the pid attribute of the GEXF hierarchy
+type gexfWriter struct {
+	*xmlStream
+	nodes []graphAttribute
+}
+
+func newGEXFWriter(w io.Writer, nodes []graphAttribute) *gexfWriter {
+	return &gexfWriter{newXMLStream(w), nodes}
+}
+
+func (g *gexfWriter) attvalues(attrs []graphAttribute, i interface{}) error {
+	if err := g.start("attvalues"); err != nil {
+		return err
+	}
+	for _, a := range attrs {
+		value := a.Value(i)
+		if value == "" {
+			continue
+		}
+		if err := g.empty("attvalue", "for", a.ID, "value", value); err != nil {
+			return err
+		}
+	}
+	return g.close("attvalues")
+}
+
+func (g *gexfWriter) attributes(class string, attrs []graphAttribute) error {
+	if err := g.start("attributes", "class", class); err != nil {
+		return err
+	}
+	for _, a := range attrs {
+		if err := g.empty("attribute", "id", a.ID, "title", a.ID, "type", a.Type); err != nil {
+			return err
+		}
+	}
+	return g.close("attributes")
+}
+
+func (g *gexfWriter) begin(p data.Project) error {
+	if _, err := io.WriteString(g.w, xml.Header); err != nil {
+		return err
+	}
+	if err := g.start("gexf", "xmlns", gexfNamespace, "version", "1.2"); err != nil {
+		return err
+	}
+	if err := g.start("meta"); err != nil {
+		return err
+	}
+	if err := g.text("creator", "traceability"); err != nil {
+		return err
+	}
+	if err := g.text("description", p.Name); err != nil {
+		return err
+	}
+	if err := g.close("meta"); err != nil {
+		return err
+	}
+	if err := g.start("graph", "defaultedgetype", "directed", "mode", "static"); err != nil {
+		return err
+	}
+	if err := g.attributes("node", g.nodes); err != nil {
+		return err
+	}
+	if err := g.attributes("edge", edgeAttributes); err != nil {
+		return err
+	}
+	return g.start("nodes")
+}
+
+func (g *gexfWriter) view(v data.ArchView) error {
+	if err := g.start("node", "id", v.ID, "label", v.Name); err != nil {
+		return err
+	}
+	if err := g.attvalues(g.nodes, v); err != nil {
+		return err
+	}
+	return g.close("node")
+}
+
+func (g *gexfWriter) component(c data.ArchViewComponent) error {
+	if err := g.start("node", "id", c.ID, "label", c.Desctription, "pid", c.ViewID); err != nil {
+		return err
+	}
+	if err := g.attvalues(g.nodes, c); err != nil {
+		return err
+	}
+	return g.close("node")
+}
+
+func (g *gexfWriter) endView(v data.ArchView) error {
+	return g.flush()
+}
+
+func (g *gexfWriter) beginLinks() error {
+	if err := g.close("nodes"); err != nil {
+		return err
+	}
+	return g.start("edges")
+}
+
+func (g *gexfWriter) link(l data.Link) error {
+	if err := g.start("edge", "id", l.ID, "source", l.From, "target", l.To, "label", l.Kind); err != nil {
+		return err
+	}
+	if err := g.attvalues(edgeAttributes, l); err != nil {
+		return err
+	}
+	return g.close("edge")
+}
+
+func (g *gexfWriter) end() error {
+	if err := g.close("edges"); err != nil {
+		return err
+	}
+	if err := g.close("graph"); err != nil {
+		return err
+	}
+	if err := g.close("gexf"); err != nil {
+		return err
+	}
+	return g.flush()
+}
@@ -0,0 +1,150 @@
+package interchange
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// GherkinFeature is a parsed .feature file
+type GherkinFeature struct {
+	Name        string
+	Description []string
+	Tags        []string
+	Line        int
+	Scenarios   []GherkinScenario
+}
+
+// GherkinScenario is a scenario or scenario outline of a feature
+type GherkinScenario struct {
+	Name  string
+	Rule  string
+	Tags  []string
+	Line  int
+	Steps []string
+}
+
+// ErrNoGherkinFeature is returned when a file has no Feature keyword
+var ErrNoGherkinFeature = errors.New("no feature found in gherkin file")
+
+var (
+	gherkinScenarioKeywords = []string{"Scenario Outline:", "Scenario Template:", "Scenario:", "Example:"}
+	gherkinStepKeywords     = []string{"Given ", "When ", "Then ", "And ", "But ", "* "}
+)
+
+// gherkinKeyword returns the text after the first of keywords line starts with
+func gherkinKeyword(line string, keywords ...string) (string, bool) {
+	for _, k := range keywords {
+		if strings.HasPrefix(line, k) {
+			return strings.TrimSpace(strings.TrimPrefix(line, k)), true
+		}
+	}
+	return "", false
+}
+
+// ParseGherkin reads the feature and its scenarios from a .feature file.
+// Backgrounds, example tables and doc strings are skipped as they do not
+// become components of their own.
+func ParseGherkin(r io.Reader) (*GherkinFeature, error) {
+	var (
+		feature  *GherkinFeature
+		scenario *GherkinScenario
+		tags     []string
+		rule     string
+		docQuote string
+		// inDescription is set while reading the free text below Feature
+		inDescription bool
+		// inBackground and inExamples skip steps and tables not owned by a scenario
+		inBackground bool
+		inExamples   bool
+	)
+
+	endScenario := func() {
+		if scenario != nil {
+			feature.Scenarios = append(feature.Scenarios, *scenario)
+			scenario = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if docQuote != "" {
+			if strings.HasPrefix(line, docQuote) {
+				docQuote = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(line, `"""`) || strings.HasPrefix(line, "```") {
+			docQuote = line[:3]
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "@") {
+			tags = append(tags, strings.Fields(line)...)
+			continue
+		}
+
+		if name, ok := gherkinKeyword(line, "Feature:"); ok {
+			if feature != nil {
+				return nil, errors.New("gherkin file has more than one feature")
+			}
+			feature = &GherkinFeature{Name: name, Tags: tags, Line: n}
+			tags = nil
+			inDescription = true
+			continue
+		}
+		if feature == nil {
+			continue
+		}
+
+		if name, ok := gherkinKeyword(line, "Rule:"); ok {
+			endScenario()
+			rule = name
+			tags = nil
+			inDescription, inBackground, inExamples = false, false, false
+			continue
+		}
+		if _, ok := gherkinKeyword(line, "Background:"); ok {
+			endScenario()
+			inDescription, inBackground, inExamples = false, true, false
+			continue
+		}
+		if name, ok := gherkinKeyword(line, gherkinScenarioKeywords...); ok {
+			endScenario()
+			scenario = &GherkinScenario{Name: name, Rule: rule, Tags: tags, Line: n}
+			tags = nil
+			inDescription, inBackground, inExamples = false, false, false
+			continue
+		}
+		if _, ok := gherkinKeyword(line, "Examples:", "Scenarios:"); ok {
+			tags = nil
+			inExamples = true
+			continue
+		}
+
+		switch {
+		case inDescription:
+			feature.Description = append(feature.Description, line)
+		case inBackground, inExamples, scenario == nil:
+		case strings.HasPrefix(line, "|"):
+		default:
+			if _, ok := gherkinKeyword(line, gherkinStepKeywords...); ok {
+				scenario.Steps = append(scenario.Steps, line)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if feature == nil {
+		return nil, ErrNoGherkinFeature
+	}
+	endScenario()
+
+	return feature, nil
+}
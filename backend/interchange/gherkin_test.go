@@ -0,0 +1,122 @@
+package interchange
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testFeature = `# language: en
+@web @checkout
+Feature: Checkout
+  As a customer,
+  I want to pay
+
+  Background:
+    Given a cart
+
+  @smoke
+  Scenario: Pay by card
+    Given a cart with items
+    When I pay by card
+      """
+      Scenario: not a scenario
+      """
+    Then the order is placed
+    | a | b |
+
+  Rule: Refunds
+    Scenario Outline: Refund <amount>
+      Given an order of <amount>
+      But not shipped
+      * refund
+      Examples:
+        | amount |
+        | 10 |
+      @extra
+      Examples:
+        | amount |
+    Example: Partial refund
+      When I refund half
+`
+
+func TestParseGherkin(t *testing.T) {
+	feature, err := ParseGherkin(strings.NewReader(testFeature))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &GherkinFeature{
+		Name:        "Checkout",
+		Description: []string{"As a customer,", "I want to pay"},
+		Tags:        []string{"@web", "@checkout"},
+		Line:        3,
+		Scenarios: []GherkinScenario{
+			{
+				Name:  "Pay by card",
+				Tags:  []string{"@smoke"},
+				Line:  11,
+				Steps: []string{"Given a cart with items", "When I pay by card", "Then the order is placed"},
+			},
+			{
+				Name:  "Refund <amount>",
+				Rule:  "Refunds",
+				Line:  21,
+				Steps: []string{"Given an order of <amount>", "But not shipped", "* refund"},
+			},
+			{
+				Name:  "Partial refund",
+				Rule:  "Refunds",
+				Line:  31,
+				Steps: []string{"When I refund half"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(feature, want) {
+		t.Errorf("feature = %#v, want %#v", feature, want)
+	}
+}
+
+func TestParseGherkinErrors(t *testing.T) {
+	if _, err := ParseGherkin(strings.NewReader("Scenario: orphan\n  Given nothing\n")); err != ErrNoGherkinFeature {
+		t.Errorf("err = %v, want ErrNoGherkinFeature", err)
+	}
+	if _, err := ParseGherkin(strings.NewReader("\"\"\"\nFeature: quoted\n\"\"\"\n")); err != ErrNoGherkinFeature {
+		t.Errorf("err = %v for a feature in a doc string, want ErrNoGherkinFeature", err)
+	}
+	if _, err := ParseGherkin(strings.NewReader("Feature: a\nFeature: b\n")); err == nil {
+		t.Error("a file with two features was parsed")
+	}
+}
+
+func TestGherkinExternalID(t *testing.T) {
+	tests := []struct {
+		scenario *GherkinScenario
+		want     string
+	}{
+		{nil, "gherkin:features/checkout.feature"},
+		{&GherkinScenario{Name: "Pay"}, "gherkin:features/checkout.feature#Pay"},
+		{&GherkinScenario{Name: "Pay", Rule: "Cards"}, "gherkin:features/checkout.feature#Cards/Pay"},
+	}
+	for _, tt := range tests {
+		if got := gherkinExternalID("features/checkout.feature", tt.scenario); got != tt.want {
+			t.Errorf("gherkinExternalID(%v) = %q, want %q", tt.scenario, got, tt.want)
+		}
+	}
+}
+
+func TestGherkinUserKind(t *testing.T) {
+	tests := []struct {
+		description []string
+		want        string
+	}{
+		{nil, ""},
+		{[]string{"Some text", "As an Admin,", "I want logs"}, "Admin"},
+		{[]string{"as a customer."}, "customer"},
+		{[]string{"Asa customer"}, ""},
+	}
+	for _, tt := range tests {
+		if got := gherkinUserKind(tt.description); got != tt.want {
+			t.Errorf("gherkinUserKind(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package interchange
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	data "traceability/data"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// gherkinVerifies is the link kind from an acceptance criterion to its user story
+const gherkinVerifies = "verifies"
+
+// GherkinImportReport lists what a feature file import changed in the project
+// swagger:model
+type GherkinImportReport struct {
+	// id of the user story component of the feature
+	Feature string `json:"feature"`
+
+	// ids of the components created for new scenarios
+	Created []string `json:"created"`
+
+	// ids of the components updated for known scenarios
+	Updated []string `json:"updated"`
+
+	// ids of the components whose scenario was removed from the file
+	Stale []string `json:"stale"`
+
+	// scenarios which could not be linked to the feature
+	Skipped []ImportSkipped `json:"skipped"`
+}
+
+// gherkinExternalID identifies a feature by its file and a scenario by its
+// file, rule and name, so that moving lines around keeps the components
+func gherkinExternalID(path string, scenario *GherkinScenario) string {
+	id := "gherkin:" + path
+	if scenario == nil {
+		return id
+	}
+	id += "#"
+	if scenario.Rule != "" {
+		id += scenario.Rule + "/"
+	}
+	return id + scenario.Name
+}
+
+// gherkinUserKind returns the role of a feature narrative like "As a developer"
+func gherkinUserKind(description []string) string {
+	for _, line := range description {
+		lower := strings.ToLower(line)
+		for _, prefix := range []string{"as an ", "as a "} {
+			if strings.HasPrefix(lower, prefix) {
+				return strings.TrimRight(strings.TrimSpace(line[len(prefix):]), ",.")
+			}
+		}
+	}
+	return ""
+}
+
+// ImportGherkin reads the feature file at path into the user story view of
+// the project. The feature becomes a user story and every scenario a child
+// acceptance criterion linked to it with a "verifies" link. Repeated imports
+// of the same path update the components and mark removed scenarios stale.
+func ImportGherkin(r io.Reader, path string, projectID string) (*GherkinImportReport, error) {
+	feature, err := ParseGherkin(r)
+	if err != nil {
+		return nil, &ImportError{err}
+	}
+	project, err := data.FindProjectByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GherkinImportReport{
+		Created: []string{},
+		Updated: []string{},
+		Stale:   []string{},
+		Skipped: []ImportSkipped{},
+	}
+
+	featureComponent, _, err := upsertGherkinComponent(data.ArchViewComponent{
+		Kind:         data.UserStory,
+		UserKind:     gherkinUserKind(feature.Description),
+		Desctription: feature.Name,
+		ViewID:       project.UserStoriesID,
+		ProjectID:    projectID,
+		ExternalID:   gherkinExternalID(path, nil),
+		Metadata: gherkinMetadata(path, feature.Line, feature.Tags, map[string]string{
+			"description": strings.Join(feature.Description, "\n"),
+		}),
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Feature = featureComponent.ID
+
+	imported := map[string]bool{}
+	for i := range feature.Scenarios {
+		s := &feature.Scenarios[i]
+		externalID := gherkinExternalID(path, s)
+		// scenarios with the same name are told apart by their position
+		for n := 2; imported[externalID]; n++ {
+			externalID = gherkinExternalID(path, s) + " (" + strconv.Itoa(n) + ")"
+		}
+		imported[externalID] = true
+
+		c, created, err := upsertGherkinComponent(data.ArchViewComponent{
+			Kind:         data.UserStory,
+			UserKind:     featureComponent.UserKind,
+			Desctription: s.Name,
+			ViewID:       project.UserStoriesID,
+			ProjectID:    projectID,
+			ExternalID:   externalID,
+			ParentID:     featureComponent.ID,
+			Metadata: gherkinMetadata(path, s.Line, s.Tags, map[string]string{
+				"rule":  s.Rule,
+				"steps": strings.Join(s.Steps, "\n"),
+			}),
+		})
+		if err != nil {
+			return report, err
+		}
+		if created {
+			report.Created = append(report.Created, c.ID)
+		} else {
+			report.Updated = append(report.Updated, c.ID)
+		}
+
+		linkID := externalID + ":" + gherkinVerifies
+		l, err := data.FindLinkByExternalID(projectID, linkID)
+		switch err {
+		case nil:
+			if l.From != c.ID || l.To != featureComponent.ID {
+				l.From, l.To = c.ID, featureComponent.ID
+				err = data.UpdateLink(l)
+			}
+		case mongo.ErrNoDocuments:
+			_, err = data.AddLink(data.Link{
+				From:       c.ID,
+				To:         featureComponent.ID,
+				Kind:       gherkinVerifies,
+				ProjectID:  projectID,
+				ExternalID: linkID,
+			})
+		}
+		if err == data.ErrLinkComponent {
+			// the scenario or the feature was deleted while importing
+			report.Skipped = append(report.Skipped, ImportSkipped{
+				Identifier: externalID,
+				Reason:     err.Error(),
+			})
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+
+	previous, err := data.FindArchViewComponentsByExternalIDPrefix(projectID, gherkinExternalID(path, nil)+"#")
+	if err != nil {
+		return report, err
+	}
+	for _, c := range previous {
+		if imported[c.ExternalID] || c.Stale {
+			continue
+		}
+		c.Stale = true
+		if err := data.UpdateArchViewComponent(c); err != nil {
+			return report, err
+		}
+		report.Stale = append(report.Stale, c.ID)
+	}
+
+	return report, nil
+}
+
+// upsertGherkinComponent creates c or updates the component imported with the same external id
+func upsertGherkinComponent(c data.ArchViewComponent) (data.ArchViewComponent, bool, error) {
+	existing, err := data.FindArchViewComponentByExternalID(c.ProjectID, c.ExternalID)
+	switch err {
+	case nil:
+		existing.Desctription = c.Desctription
+		existing.UserKind = c.UserKind
+		existing.ParentID = c.ParentID
+		existing.Metadata = c.Metadata
+		existing.Stale = false
+		err := data.UpdateArchViewComponent(existing)
+		return existing, false, err
+	case mongo.ErrNoDocuments:
+		created, err := data.AddArchViewComponent(c)
+		return created, true, err
+	default:
+		return existing, false, err
+	}
+}
+
+// gherkinMetadata keeps the source location and the given non empty values
+func gherkinMetadata(path string, line int, tags []string, values map[string]string) map[string]string {
+	m := map[string]string{
+		"file": path,
+		"line": strconv.Itoa(line),
+	}
+	if len(tags) > 0 {
+		m["tags"] = strings.Join(tags, " ")
+	}
+	for k, v := range values {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	return m
+}
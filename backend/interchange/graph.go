@@ -0,0 +1,279 @@
+package interchange
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	data "traceability/data"
+)
+
+// GraphFormat is a file format the project graph can be exported as
+type GraphFormat string
+
+const (
+	// GraphML is the GraphML format, http://graphml.graphdrawing.org
+	GraphML GraphFormat = "graphml"
+	// GEXF is the Gephi exchange format, https://gephi.org/gexf
+	GEXF GraphFormat = "gexf"
+)
+
+// ErrUnknownGraphFormat is returned when the requested export format is not supported
+var ErrUnknownGraphFormat = fmt.Errorf("unknown graph format, expected graphml or gexf")
+
+// ContentType returns the mime type of the format
+func (f GraphFormat) ContentType() string {
+	switch f {
+	case GEXF:
+		return "application/gexf+xml"
+	default:
+		return "application/graphml+xml"
+	}
+}
+
+// graphAttribute describes one attribute exported on nodes or edges
+type graphAttribute struct {
+	ID    string
+	Type  string
+	Value func(interface{}) string
+}
+
+// node types so that views and components can be told apart in graph tools
+const (
+	nodeTypeView      = "view"
+	nodeTypeComponent = "component"
+)
+
+// nodeAttributes are exported for views and components, empty values are skipped
+var nodeAttributes = []graphAttribute{
+	{ID: "type", Type: "string", Value: func(i interface{}) string {
+		if _, ok := i.(data.ArchView); ok {
+			return nodeTypeView
+		}
+		return nodeTypeComponent
+	}},
+	{ID: "name", Type: "string", Value: func(i interface{}) string {
+		if v, ok := i.(data.ArchView); ok {
+			return v.Name
+		}
+		return ""
+	}},
+	{ID: "kind", Type: "string", Value: func(i interface{}) string {
+		switch e := i.(type) {
+		case data.ArchView:
+			return e.Kind
+		case data.ArchViewComponent:
+			return string(e.Kind)
+		}
+		return ""
+	}},
+	{ID: "description", Type: "string", Value: func(i interface{}) string {
+		switch e := i.(type) {
+		case data.ArchView:
+			return e.Desctription
+		case data.ArchViewComponent:
+			return e.Desctription
+		}
+		return ""
+	}},
+	{ID: "viewID", Type: "string", Value: func(i interface{}) string {
+		if c, ok := i.(data.ArchViewComponent); ok {
+			return c.ViewID
+		}
+		return ""
+	}},
+	{ID: "userKind", Type: "string", Value: func(i interface{}) string {
+		if c, ok := i.(data.ArchViewComponent); ok {
+			return c.UserKind
+		}
+		return ""
+	}},
+	{ID: "userKinds", Type: "string", Value: func(i interface{}) string {
+		if v, ok := i.(data.ArchView); ok {
+			return joinList(v.UserKinds)
+		}
+		return ""
+	}},
+	{ID: "level", Type: "int", Value: func(i interface{}) string {
+		if c, ok := i.(data.ArchViewComponent); ok {
+			return strconv.Itoa(c.Level)
+		}
+		return ""
+	}},
+	{ID: "functions", Type: "string", Value: func(i interface{}) string {
+		if c, ok := i.(data.ArchViewComponent); ok {
+			return joinList(c.FunctionList)
+		}
+		return ""
+	}},
+	{ID: "variables", Type: "string", Value: func(i interface{}) string {
+		if c, ok := i.(data.ArchViewComponent); ok {
+			return joinList(c.VarList)
+		}
+		return ""
+	}},
+}
+
+// kindAttributes export the display hints of the view kind of views and
+// components, so that graph tools can style the 4+1 views apart
+func kindAttributes(kinds []data.ViewKindDefinition) []graphAttribute {
+	byName := map[string]data.ViewKindDefinition{}
+	for _, k := range kinds {
+		byName[k.Name] = k
+	}
+	kindOf := func(i interface{}) data.ViewKindDefinition {
+		switch e := i.(type) {
+		case data.ArchView:
+			return byName[e.Kind]
+		case data.ArchViewComponent:
+			return byName[string(e.Kind)]
+		}
+		return data.ViewKindDefinition{}
+	}
+	return []graphAttribute{
+		{ID: "kindLabel", Type: "string", Value: func(i interface{}) string {
+			return kindOf(i).Label
+		}},
+		{ID: "icon", Type: "string", Value: func(i interface{}) string {
+			return kindOf(i).Icon
+		}},
+		{ID: "color", Type: "string", Value: func(i interface{}) string {
+			return kindOf(i).Color
+		}},
+	}
+}
+
+// edgeAttributes are exported for links
+var edgeAttributes = []graphAttribute{
+	{ID: "kind", Type: "string", Value: func(i interface{}) string {
+		return i.(data.Link).Kind
+	}},
+	{ID: "inView", Type: "boolean", Value: func(i interface{}) string {
+		return strconv.FormatBool(i.(data.Link).InView)
+	}},
+}
+
+// listSeparator joins list attributes as graph tools only know scalar values
+const listSeparator = ";"
+
+func joinList(l []string) string {
+	return strings.Join(l, listSeparator)
+}
+
+// graphWriter is implemented by the format specific writers
+type graphWriter interface {
+	begin(p data.Project) error
+	view(v data.ArchView) error
+	component(c data.ArchViewComponent) error
+	endView(v data.ArchView) error
+	beginLinks() error
+	link(l data.Link) error
+	end() error
+}
+
+// ExportGraph streams the whole project graph to w in the given format.
+// Views and their components are read one view at a time and links are
+// read with a cursor, so the project is never held in memory.
+func ExportGraph(w io.Writer, format GraphFormat, project data.Project) error {
+	if format != GraphML && format != GEXF {
+		return ErrUnknownGraphFormat
+	}
+
+	projectID := project.ID
+	kinds, err := data.FindViewKindsOfProject(projectID)
+	if err != nil {
+		return err
+	}
+	nodes := append(nodeAttributes[:len(nodeAttributes):len(nodeAttributes)], kindAttributes(kinds)...)
+
+	var gw graphWriter
+	if format == GEXF {
+		gw = newGEXFWriter(w, nodes)
+	} else {
+		gw = newGraphMLWriter(w, nodes)
+	}
+
+	if err := gw.begin(project); err != nil {
+		return err
+	}
+
+	err = data.WalkArchViewsOfProject(projectID, func(v data.ArchView) error {
+		if err := gw.view(v); err != nil {
+			return err
+		}
+		err := data.WalkArchViewComponentsOfView(v.ID, gw.component)
+		if err != nil {
+			return err
+		}
+		return gw.endView(v)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := gw.beginLinks(); err != nil {
+		return err
+	}
+	if err := data.WalkProjectLinks(projectID, gw.link); err != nil {
+		return err
+	}
+
+	return gw.end()
+}
+
+// xmlStream writes xml token by token so that documents can be streamed
+type xmlStream struct {
+	w   io.Writer
+	enc *xml.Encoder
+}
+
+func newXMLStream(w io.Writer) *xmlStream {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &xmlStream{w: w, enc: enc}
+}
+
+// start opens the element name with attrs given as key, value pairs
+func (s *xmlStream) start(name string, attrs ...string) error {
+	el := xml.StartElement{Name: xml.Name{Local: name}}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		el.Attr = append(el.Attr, xml.Attr{Name: xml.Name{Local: attrs[i]}, Value: attrs[i+1]})
+	}
+	return s.enc.EncodeToken(el)
+}
+
+func (s *xmlStream) close(name string) error {
+	return s.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+// empty writes an element without content
+func (s *xmlStream) empty(name string, attrs ...string) error {
+	if err := s.start(name, attrs...); err != nil {
+		return err
+	}
+	return s.close(name)
+}
+
+// text writes an element with character data
+func (s *xmlStream) text(name string, value string, attrs ...string) error {
+	if err := s.start(name, attrs...); err != nil {
+		return err
+	}
+	if err := s.enc.EncodeToken(xml.CharData(value)); err != nil {
+		return err
+	}
+	return s.close(name)
+}
+
+// flush sends the buffered xml to the writer and flushes it if it supports it, e.g. http.Flusher
+func (s *xmlStream) flush() error {
+	if err := s.enc.Flush(); err != nil {
+		return err
+	}
+	if f, ok := s.w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package interchange
+
+import (
+	"encoding/xml"
+	"io"
+
+	data "traceability/data"
+)
+
+const graphMLNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+// graphMLWriter writes views as nodes with nested graphs holding their components
+type graphMLWriter struct {
+	*xmlStream
+	nodes []graphAttribute
+}
+
+func newGraphMLWriter(w io.Writer, nodes []graphAttribute) *graphMLWriter {
+	return &graphMLWriter{newXMLStream(w), nodes}
+}
+
+func (g *graphMLWriter) data(attrs []graphAttribute, prefix string, i interface{}) error {
+	for _, a := range attrs {
+		value := a.Value(i)
+		if value == "" {
+			continue
+		}
+		if err := g.text("data", value, "key", prefix+a.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *graphMLWriter) begin(p data.Project) error {
+	if _, err := io.WriteString(g.w, xml.Header); err != nil {
+		return err
+	}
+	if err := g.start("graphml", "xmlns", graphMLNamespace); err != nil {
+		return err
+	}
+	keys := []struct {
+		prefix string
+		target string
+		attrs  []graphAttribute
+	}{
+		{"n_", "node", g.nodes},
+		{"e_", "edge", edgeAttributes},
+	}
+	for _, k := range keys {
+		for _, a := range k.attrs {
+			err := g.empty("key", "id", k.prefix+a.ID, "for", k.target, "attr.name", a.ID, "attr.type", a.Type)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return g.start("graph", "id", p.ID, "edgedefault", "directed")
+}
+
+func (g *graphMLWriter) view(v data.ArchView) error {
+	if err := g.start("node", "id", v.ID); err != nil {
+		return err
+	}
+	if err := g.data(g.nodes, "n_", v); err != nil {
+		return err
+	}
+	return g.start("graph", "id", v.ID+":", "edgedefault", "directed")
+}
+
+func (g *graphMLWriter) component(c data.ArchViewComponent) error {
+	if err := g.start("node", "id", c.ID); err != nil {
+		return err
+	}
+	if err := g.data(g.nodes, "n_", c); err != nil {
+		return err
+	}
+	return g.close("node")
+}
+
+func (g *graphMLWriter) endView(v data.ArchView) error {
+	if err := g.close("graph"); err != nil {
+		return err
+	}
+	if err := g.close("node"); err != nil {
+		return err
+	}
+	return g.flush()
+}
+
+// beginLinks is a no-op, edges live in the top level graph next to the views
+func (g *graphMLWriter) beginLinks() error {
+	return nil
+}
+
+func (g *graphMLWriter) link(l data.Link) error {
+	if err := g.start("edge", "id", l.ID, "source", l.From, "target", l.To); err != nil {
+		return err
+	}
+	if err := g.data(edgeAttributes, "e_", l); err != nil {
+		return err
+	}
+	return g.close("edge")
+}
+
+func (g *graphMLWriter) end() error {
+	if err := g.close("graph"); err != nil {
+		return err
+	}
+	if err := g.close("graphml"); err != nil {
+		return err
+	}
+	return g.flush()
+}
@@ -0,0 +1,359 @@
+package interchange
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	data "traceability/data"
+)
+
+const reqIFNamespace = "http://www.omg.org/spec/ReqIF/20110401/reqif.xsd"
+
+// ReqIF attribute names which map to fields of a component, other
+// attributes are kept in the component metadata
+const (
+	reqIFTextAttribute     = "ReqIF.Text"
+	reqIFNameAttribute     = "ReqIF.Name"
+	reqIFChapterAttribute  = "ReqIF.ChapterName"
+	reqIFUserKindAttribute = "Traceability.UserKind"
+	reqIFKindAttribute     = "Traceability.Kind"
+)
+
+// reqIFIDPrefix makes component and link ids valid xml ids, which must not start with a digit
+const reqIFIDPrefix = "_"
+
+// reqIFDocument is the subset of the ReqIF 1.0 schema used for interchange
+type reqIFDocument struct {
+	XMLName xml.Name     `xml:"REQ-IF"`
+	Xmlns   string       `xml:"xmlns,attr,omitempty"`
+	Header  reqIFHeader  `xml:"THE-HEADER>REQ-IF-HEADER"`
+	Content reqIFContent `xml:"CORE-CONTENT>REQ-IF-CONTENT"`
+}
+
+type reqIFHeader struct {
+	Identifier   string `xml:"IDENTIFIER,attr"`
+	CreationTime string `xml:"CREATION-TIME"`
+	ReqIFToolID  string `xml:"REQ-IF-TOOL-ID"`
+	ReqIFVersion string `xml:"REQ-IF-VERSION"`
+	SourceToolID string `xml:"SOURCE-TOOL-ID"`
+	Title        string `xml:"TITLE"`
+}
+
+type reqIFContent struct {
+	Datatypes      reqIFDatatypes       `xml:"DATATYPES"`
+	SpecTypes      reqIFSpecTypes       `xml:"SPEC-TYPES"`
+	SpecObjects    []reqIFSpecObject    `xml:"SPEC-OBJECTS>SPEC-OBJECT"`
+	SpecRelations  []reqIFSpecRelation  `xml:"SPEC-RELATIONS>SPEC-RELATION"`
+	Specifications []reqIFSpecification `xml:"SPECIFICATIONS>SPECIFICATION"`
+}
+
+// reqIFIdentifiable holds the attributes shared by all ReqIF elements with identity
+type reqIFIdentifiable struct {
+	Identifier string `xml:"IDENTIFIER,attr"`
+	LongName   string `xml:"LONG-NAME,attr,omitempty"`
+	LastChange string `xml:"LAST-CHANGE,attr"`
+}
+
+// encoding/xml only allows ",any" on direct children, so lists of
+// differently named elements are wrapped in their parent element
+
+type reqIFDatatypes struct {
+	Items []reqIFDatatype `xml:",any"`
+}
+
+type reqIFSpecTypes struct {
+	Items []reqIFSpecType `xml:",any"`
+}
+
+type reqIFAttributeDefinitions struct {
+	Items []reqIFAttributeDefinition `xml:",any"`
+}
+
+type reqIFAttributeValues struct {
+	Items []reqIFAttributeValue `xml:",any"`
+}
+
+// reqIFDatatype is any DATATYPE-DEFINITION-*, only enumerations have values
+type reqIFDatatype struct {
+	XMLName xml.Name
+	reqIFIdentifiable
+	MaxLength  string           `xml:"MAX-LENGTH,attr,omitempty"`
+	EnumValues *reqIFEnumValues `xml:"SPECIFIED-VALUES,omitempty"`
+}
+
+type reqIFEnumValues struct {
+	Items []reqIFEnumValue `xml:"ENUM-VALUE"`
+}
+
+type reqIFEnumValue struct {
+	reqIFIdentifiable
+}
+
+// reqIFSpecType is any of SPEC-OBJECT-TYPE, SPEC-RELATION-TYPE and SPECIFICATION-TYPE
+type reqIFSpecType struct {
+	XMLName xml.Name
+	reqIFIdentifiable
+	Attributes *reqIFAttributeDefinitions `xml:"SPEC-ATTRIBUTES,omitempty"`
+}
+
+// reqIFAttributeDefinition is any ATTRIBUTE-DEFINITION-*
+type reqIFAttributeDefinition struct {
+	XMLName xml.Name
+	reqIFIdentifiable
+	Type reqIFRefs `xml:"TYPE"`
+}
+
+// reqIFRefs holds references of any kind, e.g. DATATYPE-DEFINITION-STRING-REF
+type reqIFRefs struct {
+	Refs []reqIFRef `xml:",any"`
+}
+
+type reqIFRef struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// first returns the first referenced identifier
+func (r reqIFRefs) first() string {
+	if len(r.Refs) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(r.Refs[0].Value)
+}
+
+func newReqIFRefs(name string, id string) reqIFRefs {
+	return reqIFRefs{Refs: []reqIFRef{{XMLName: xml.Name{Local: name}, Value: id}}}
+}
+
+// reqIFAttributeValue is any ATTRIBUTE-VALUE-*
+type reqIFAttributeValue struct {
+	XMLName    xml.Name
+	TheValue   string         `xml:"THE-VALUE,attr,omitempty"`
+	Definition reqIFRefs      `xml:"DEFINITION"`
+	EnumValues *reqIFEnumRefs `xml:"VALUES,omitempty"`
+	XHTML      *reqIFXHTML    `xml:"THE-VALUE,omitempty"`
+}
+
+type reqIFEnumRefs struct {
+	Refs []string `xml:"ENUM-VALUE-REF"`
+}
+
+type reqIFXHTML struct {
+	Inner string `xml:",innerxml"`
+}
+
+type reqIFSpecObject struct {
+	reqIFIdentifiable
+	Type   reqIFRefs            `xml:"TYPE"`
+	Values reqIFAttributeValues `xml:"VALUES"`
+}
+
+type reqIFSpecRelation struct {
+	reqIFIdentifiable
+	Type   reqIFRefs            `xml:"TYPE"`
+	Values reqIFAttributeValues `xml:"VALUES"`
+	Source reqIFRefs            `xml:"SOURCE"`
+	Target reqIFRefs            `xml:"TARGET"`
+}
+
+type reqIFSpecification struct {
+	reqIFIdentifiable
+	Type     reqIFRefs            `xml:"TYPE"`
+	Children []reqIFSpecHierarchy `xml:"CHILDREN>SPEC-HIERARCHY"`
+}
+
+type reqIFSpecHierarchy struct {
+	reqIFIdentifiable
+	Object   reqIFRefs            `xml:"OBJECT"`
+	Children []reqIFSpecHierarchy `xml:"CHILDREN>SPEC-HIERARCHY,omitempty"`
+}
+
+// reqIFExportIDs are the identifiers of the fixed definitions written on export
+const (
+	reqIFStringType        = "traceability-string"
+	reqIFComponentType     = "traceability-component"
+	reqIFViewType          = "traceability-view"
+	reqIFAttributeIDPrefix = "traceability-attribute-"
+	reqIFRelationIDPrefix  = "traceability-relation-"
+)
+
+// reqIFIdentifier returns the identifier a component or link is exported with,
+// imported elements keep their original identifier so re-imports match them
+func reqIFIdentifier(id string, externalID string) string {
+	if externalID != "" {
+		return externalID
+	}
+	return reqIFIDPrefix + id
+}
+
+// ExportReqIF writes all components and links of the project as a ReqIF document.
+// Every view becomes a specification and every link kind a relation type.
+func ExportReqIF(w io.Writer, projectID string) error {
+	project, err := data.FindProjectByID(projectID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	identity := func(id string, name string) reqIFIdentifiable {
+		return reqIFIdentifiable{Identifier: id, LongName: name, LastChange: now}
+	}
+
+	doc := reqIFDocument{
+		Xmlns: reqIFNamespace,
+		Header: reqIFHeader{
+			Identifier:   reqIFIDPrefix + project.ID,
+			CreationTime: now,
+			ReqIFToolID:  "traceability",
+			ReqIFVersion: "1.0",
+			SourceToolID: "traceability",
+			Title:        project.Name,
+		},
+	}
+	content := &doc.Content
+	content.Datatypes.Items = []reqIFDatatype{{
+		XMLName:           xml.Name{Local: "DATATYPE-DEFINITION-STRING"},
+		reqIFIdentifiable: identity(reqIFStringType, "String"),
+		MaxLength:         "32000",
+	}}
+
+	// every component field and metadata key becomes a string attribute
+	attributeNames := map[string]bool{
+		reqIFTextAttribute:     true,
+		reqIFKindAttribute:     true,
+		reqIFUserKindAttribute: true,
+	}
+	componentIDs := map[string]string{}
+
+	views, err := data.FindArchViewsOfProject(projectID)
+	if err != nil {
+		return err
+	}
+	for _, v := range views {
+		spec := reqIFSpecification{
+			reqIFIdentifiable: identity(reqIFIDPrefix+v.ID, v.Name),
+			Type:              newReqIFRefs("SPECIFICATION-TYPE-REF", reqIFViewType),
+		}
+		err := data.WalkArchViewComponentsOfView(v.ID, func(c data.ArchViewComponent) error {
+			identifier := reqIFIdentifier(c.ID, c.ExternalID)
+			componentIDs[c.ID] = identifier
+
+			values := map[string]string{
+				reqIFTextAttribute:     c.Desctription,
+				reqIFKindAttribute:     string(c.Kind),
+				reqIFUserKindAttribute: c.UserKind,
+			}
+			for k, v := range c.Metadata {
+				attributeNames[k] = true
+				values[k] = v
+			}
+			content.SpecObjects = append(content.SpecObjects, reqIFSpecObject{
+				reqIFIdentifiable: identity(identifier, c.Desctription),
+				Type:              newReqIFRefs("SPEC-OBJECT-TYPE-REF", reqIFComponentType),
+				Values:            reqIFStringValues(values),
+			})
+			spec.Children = append(spec.Children, reqIFSpecHierarchy{
+				reqIFIdentifiable: identity(reqIFIDPrefix+"h"+c.ID, ""),
+				Object:            newReqIFRefs("SPEC-OBJECT-REF", identifier),
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		content.Specifications = append(content.Specifications, spec)
+	}
+
+	var names []string
+	for name := range attributeNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	attributes := &reqIFAttributeDefinitions{}
+	for _, name := range names {
+		attributes.Items = append(attributes.Items, reqIFAttributeDefinition{
+			XMLName:           xml.Name{Local: "ATTRIBUTE-DEFINITION-STRING"},
+			reqIFIdentifiable: identity(reqIFAttributeIDPrefix+reqIFNCName(name), name),
+			Type:              newReqIFRefs("DATATYPE-DEFINITION-STRING-REF", reqIFStringType),
+		})
+	}
+	content.SpecTypes.Items = []reqIFSpecType{
+		{
+			XMLName:           xml.Name{Local: "SPEC-OBJECT-TYPE"},
+			reqIFIdentifiable: identity(reqIFComponentType, "Component"),
+			Attributes:        attributes,
+		},
+		{
+			XMLName:           xml.Name{Local: "SPECIFICATION-TYPE"},
+			reqIFIdentifiable: identity(reqIFViewType, "View"),
+		},
+	}
+
+	relationTypes := map[string]bool{}
+	err = data.WalkProjectLinks(projectID, func(l data.Link) error {
+		source, okSource := componentIDs[l.From]
+		target, okTarget := componentIDs[l.To]
+		if !okSource || !okTarget {
+			return nil
+		}
+		relationType := reqIFRelationIDPrefix + reqIFNCName(l.Kind)
+		if !relationTypes[relationType] {
+			relationTypes[relationType] = true
+			content.SpecTypes.Items = append(content.SpecTypes.Items, reqIFSpecType{
+				XMLName:           xml.Name{Local: "SPEC-RELATION-TYPE"},
+				reqIFIdentifiable: identity(relationType, l.Kind),
+			})
+		}
+		content.SpecRelations = append(content.SpecRelations, reqIFSpecRelation{
+			reqIFIdentifiable: identity(reqIFIdentifier(l.ID, l.ExternalID), l.Kind),
+			Type:              newReqIFRefs("SPEC-RELATION-TYPE-REF", relationType),
+			Source:            newReqIFRefs("SPEC-OBJECT-REF", source),
+			Target:            newReqIFRefs("SPEC-OBJECT-REF", target),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
+
+// reqIFStringValues converts the non empty values to string attribute values
+func reqIFStringValues(values map[string]string) reqIFAttributeValues {
+	var names []string
+	for name, value := range values {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var result reqIFAttributeValues
+	for _, name := range names {
+		result.Items = append(result.Items, reqIFAttributeValue{
+			XMLName:    xml.Name{Local: "ATTRIBUTE-VALUE-STRING"},
+			TheValue:   values[name],
+			Definition: newReqIFRefs("ATTRIBUTE-DEFINITION-STRING-REF", reqIFAttributeIDPrefix+reqIFNCName(name)),
+		})
+	}
+	return result
+}
+
+// reqIFNCName replaces characters which are not allowed in xml ids
+func reqIFNCName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
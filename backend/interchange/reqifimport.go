@@ -0,0 +1,357 @@
+package interchange
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	data "traceability/data"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReqIFImportReport lists what a ReqIF import changed in the project
+// swagger:model
+type ReqIFImportReport struct {
+	// ids of the components created from new spec objects
+	Created []string `json:"created"`
+
+	// ids of the components updated from already imported spec objects
+	Updated []string `json:"updated"`
+
+	// ids of the links created from new spec relations
+	LinksCreated []string `json:"linksCreated"`
+
+	// ids of the links updated from already imported spec relations
+	LinksUpdated []string `json:"linksUpdated"`
+
+	// elements of the file that could not be imported
+	Skipped []ImportSkipped `json:"skipped"`
+}
+
+// ImportSkipped is an element of an imported file which was not imported
+type ImportSkipped struct {
+	// identifier of the element in the file
+	Identifier string `json:"identifier"`
+
+	// why the element was skipped
+	Reason string `json:"reason"`
+}
+
+// ImportError is returned when an imported file can't be read, the other
+// errors of the imports are errors of the database
+type ImportError struct {
+	Err error
+}
+
+func (e *ImportError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error reading the file
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// descriptionAttributes are tried in order to find the description of a spec object
+var descriptionAttributes = []string{reqIFTextAttribute, reqIFNameAttribute, reqIFChapterAttribute}
+
+// ImportReqIF reads a ReqIF document into the user story view of the project.
+// Spec objects and relations which were imported or exported before are
+// matched by their identifier and updated instead of duplicated.
+func ImportReqIF(r io.Reader, projectID string) (*ReqIFImportReport, error) {
+	objects, relations, err := readReqIF(r)
+	if err != nil {
+		return nil, &ImportError{err}
+	}
+
+	project, err := data.FindProjectByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReqIFImportReport{
+		Created:      []string{},
+		Updated:      []string{},
+		LinksCreated: []string{},
+		LinksUpdated: []string{},
+		Skipped:      []ImportSkipped{},
+	}
+	componentIDs := map[string]string{}
+
+	for _, so := range objects {
+		c, err := findReqIFComponent(projectID, so.Identifier)
+		if err == nil && c.Kind != data.UserStory {
+			// exported components of other views are linked to, but not changed
+			componentIDs[so.Identifier] = c.ID
+			report.Skipped = append(report.Skipped, ImportSkipped{
+				Identifier: so.Identifier,
+				Reason:     "only user stories are updated by ReqIF imports",
+			})
+			continue
+		}
+		switch err {
+		case nil:
+			c.Desctription = so.Description
+			if so.UserKind != "" {
+				c.UserKind = so.UserKind
+			}
+			c.Metadata = so.Metadata
+			if err := data.UpdateArchViewComponent(c); err != nil {
+				return report, err
+			}
+			report.Updated = append(report.Updated, c.ID)
+		case mongo.ErrNoDocuments:
+			c, err = data.AddArchViewComponent(data.ArchViewComponent{
+				Kind:         data.UserStory,
+				UserKind:     so.UserKind,
+				Desctription: so.Description,
+				ViewID:       project.UserStoriesID,
+				ProjectID:    projectID,
+				ExternalID:   so.Identifier,
+				Metadata:     so.Metadata,
+			})
+			if err != nil {
+				return report, err
+			}
+			report.Created = append(report.Created, c.ID)
+		default:
+			return report, err
+		}
+		componentIDs[so.Identifier] = c.ID
+	}
+
+	resolve := func(identifier string) (string, bool) {
+		if id, ok := componentIDs[identifier]; ok {
+			return id, true
+		}
+		c, err := findReqIFComponent(projectID, identifier)
+		if err != nil {
+			return "", false
+		}
+		componentIDs[identifier] = c.ID
+		return c.ID, true
+	}
+
+	for _, sr := range relations {
+		from, okFrom := resolve(sr.Source)
+		to, okTo := resolve(sr.Target)
+		if !okFrom || !okTo {
+			report.Skipped = append(report.Skipped, ImportSkipped{
+				Identifier: sr.Identifier,
+				Reason:     "source or target spec object not found",
+			})
+			continue
+		}
+		l, err := findReqIFLink(projectID, sr.Identifier)
+		created := err == mongo.ErrNoDocuments
+		switch err {
+		case nil:
+			l.From, l.To, l.Kind = from, to, sr.Kind
+			err = data.UpdateLink(l)
+		case mongo.ErrNoDocuments:
+			l, err = data.AddLink(data.Link{
+				From:       from,
+				To:         to,
+				Kind:       sr.Kind,
+				ProjectID:  projectID,
+				ExternalID: sr.Identifier,
+			})
+		}
+		if err == data.ErrLinkComponent {
+			// a component was deleted while importing
+			report.Skipped = append(report.Skipped, ImportSkipped{
+				Identifier: sr.Identifier,
+				Reason:     err.Error(),
+			})
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		if created {
+			report.LinksCreated = append(report.LinksCreated, l.ID)
+		} else {
+			report.LinksUpdated = append(report.LinksUpdated, l.ID)
+		}
+	}
+
+	return report, nil
+}
+
+// reqIFObject is a spec object read from a ReqIF document
+type reqIFObject struct {
+	Identifier  string
+	Description string
+	UserKind    string
+	Metadata    map[string]string
+}
+
+// reqIFRelation is a spec relation read from a ReqIF document
+type reqIFRelation struct {
+	Identifier string
+	Source     string
+	Target     string
+	Kind       string
+}
+
+// readReqIF decodes a ReqIF document into its spec objects and relations.
+// Attributes are named by their definitions, the description is the first
+// of the descriptionAttributes, the other attributes become the metadata.
+// Relations are of the kind named by their type or their own name, "relates"
+// if neither has a name.
+func readReqIF(r io.Reader) ([]reqIFObject, []reqIFRelation, error) {
+	var doc reqIFDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+
+	content := doc.Content
+	enumNames := map[string]string{}
+	for _, dt := range content.Datatypes.Items {
+		if dt.EnumValues == nil {
+			continue
+		}
+		for _, ev := range dt.EnumValues.Items {
+			enumNames[ev.Identifier] = ev.LongName
+		}
+	}
+	typeNames := map[string]string{}
+	attributeNames := map[string]string{}
+	for _, st := range content.SpecTypes.Items {
+		typeNames[st.Identifier] = st.LongName
+		if st.Attributes == nil {
+			continue
+		}
+		for _, ad := range st.Attributes.Items {
+			attributeNames[ad.Identifier] = ad.LongName
+		}
+	}
+	values := func(avs reqIFAttributeValues) map[string]string {
+		result := map[string]string{}
+		for _, av := range avs.Items {
+			definition := av.Definition.first()
+			name, ok := attributeNames[definition]
+			if !ok || name == "" {
+				name = definition
+			}
+			result[name] = reqIFValueString(av, enumNames)
+		}
+		return result
+	}
+
+	var objects []reqIFObject
+	for _, so := range content.SpecObjects {
+		attrs := values(so.Values)
+
+		description := ""
+		for _, name := range descriptionAttributes {
+			if attrs[name] != "" {
+				description = attrs[name]
+				delete(attrs, name)
+				break
+			}
+		}
+		if description == "" {
+			description = so.LongName
+		}
+		if description == "" {
+			description = so.Identifier
+		}
+		userKind := attrs[reqIFUserKindAttribute]
+		delete(attrs, reqIFUserKindAttribute)
+		delete(attrs, reqIFKindAttribute)
+		if len(attrs) == 0 {
+			attrs = nil
+		}
+		objects = append(objects, reqIFObject{
+			Identifier:  so.Identifier,
+			Description: description,
+			UserKind:    userKind,
+			Metadata:    attrs,
+		})
+	}
+
+	var relations []reqIFRelation
+	for _, sr := range content.SpecRelations {
+		kind := typeNames[sr.Type.first()]
+		if kind == "" {
+			kind = sr.LongName
+		}
+		if kind == "" {
+			kind = "relates"
+		}
+		relations = append(relations, reqIFRelation{
+			Identifier: sr.Identifier,
+			Source:     sr.Source.first(),
+			Target:     sr.Target.first(),
+			Kind:       kind,
+		})
+	}
+	return objects, relations, nil
+}
+
+// findReqIFComponent finds the component a spec object was imported to or
+// exported from, exported ones may be components of any view
+func findReqIFComponent(projectID string, identifier string) (data.ArchViewComponent, error) {
+	c, err := data.FindArchViewComponentByExternalID(projectID, identifier)
+	if err != mongo.ErrNoDocuments || !strings.HasPrefix(identifier, reqIFIDPrefix) {
+		return c, err
+	}
+	c, err = data.FindArchViewComponentByID(strings.TrimPrefix(identifier, reqIFIDPrefix))
+	if err == nil && c.ProjectID != projectID {
+		return c, mongo.ErrNoDocuments
+	}
+	return c, err
+}
+
+// findReqIFLink finds the link a spec relation was imported to or exported from
+func findReqIFLink(projectID string, identifier string) (data.Link, error) {
+	l, err := data.FindLinkByExternalID(projectID, identifier)
+	if err != mongo.ErrNoDocuments || !strings.HasPrefix(identifier, reqIFIDPrefix) {
+		return l, err
+	}
+	l, err = data.FindLinkByID(strings.TrimPrefix(identifier, reqIFIDPrefix))
+	if err == nil && l.ProjectID != projectID {
+		return l, mongo.ErrNoDocuments
+	}
+	return l, err
+}
+
+// reqIFValueString converts an attribute value of any type to a string,
+// enumerations are joined by their names and xhtml is reduced to its text
+func reqIFValueString(av reqIFAttributeValue, enumNames map[string]string) string {
+	switch {
+	case av.EnumValues != nil:
+		var names []string
+		for _, ref := range av.EnumValues.Refs {
+			name := enumNames[strings.TrimSpace(ref)]
+			if name == "" {
+				name = strings.TrimSpace(ref)
+			}
+			names = append(names, name)
+		}
+		return strings.Join(names, ", ")
+	case av.XHTML != nil:
+		return xhtmlText(av.XHTML.Inner)
+	default:
+		return av.TheValue
+	}
+}
+
+// xhtmlText returns the character data of an xhtml fragment with collapsed whitespace
+func xhtmlText(fragment string) string {
+	dec := xml.NewDecoder(strings.NewReader(fragment))
+	var b strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if cd, ok := tok.(xml.CharData); ok {
+			b.Write(cd)
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,153 @@
+package interchange
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testReqIF = `<?xml version="1.0" encoding="UTF-8"?>
+<REQ-IF xmlns="http://www.omg.org/spec/ReqIF/20110401/reqif.xsd">
+  <THE-HEADER><REQ-IF-HEADER IDENTIFIER="h"><TITLE>Shop</TITLE></REQ-IF-HEADER></THE-HEADER>
+  <CORE-CONTENT><REQ-IF-CONTENT>
+    <DATATYPES>
+      <DATATYPE-DEFINITION-STRING IDENTIFIER="dt-string" LONG-NAME="String" MAX-LENGTH="255"/>
+      <DATATYPE-DEFINITION-XHTML IDENTIFIER="dt-xhtml" LONG-NAME="XHTML"/>
+      <DATATYPE-DEFINITION-ENUMERATION IDENTIFIER="dt-prio" LONG-NAME="Priority">
+        <SPECIFIED-VALUES>
+          <ENUM-VALUE IDENTIFIER="prio-high" LONG-NAME="High"/>
+          <ENUM-VALUE IDENTIFIER="prio-low" LONG-NAME="Low"/>
+        </SPECIFIED-VALUES>
+      </DATATYPE-DEFINITION-ENUMERATION>
+    </DATATYPES>
+    <SPEC-TYPES>
+      <SPEC-OBJECT-TYPE IDENTIFIER="st-req" LONG-NAME="Requirement">
+        <SPEC-ATTRIBUTES>
+          <ATTRIBUTE-DEFINITION-XHTML IDENTIFIER="ad-text" LONG-NAME="ReqIF.Text">
+            <TYPE><DATATYPE-DEFINITION-XHTML-REF>dt-xhtml</DATATYPE-DEFINITION-XHTML-REF></TYPE>
+          </ATTRIBUTE-DEFINITION-XHTML>
+          <ATTRIBUTE-DEFINITION-STRING IDENTIFIER="ad-name" LONG-NAME="ReqIF.Name">
+            <TYPE><DATATYPE-DEFINITION-STRING-REF>dt-string</DATATYPE-DEFINITION-STRING-REF></TYPE>
+          </ATTRIBUTE-DEFINITION-STRING>
+          <ATTRIBUTE-DEFINITION-STRING IDENTIFIER="ad-user" LONG-NAME="Traceability.UserKind">
+            <TYPE><DATATYPE-DEFINITION-STRING-REF>dt-string</DATATYPE-DEFINITION-STRING-REF></TYPE>
+          </ATTRIBUTE-DEFINITION-STRING>
+          <ATTRIBUTE-DEFINITION-ENUMERATION IDENTIFIER="ad-prio" LONG-NAME="Priority">
+            <TYPE><DATATYPE-DEFINITION-ENUMERATION-REF>dt-prio</DATATYPE-DEFINITION-ENUMERATION-REF></TYPE>
+          </ATTRIBUTE-DEFINITION-ENUMERATION>
+        </SPEC-ATTRIBUTES>
+      </SPEC-OBJECT-TYPE>
+      <SPEC-RELATION-TYPE IDENTIFIER="rt-refines" LONG-NAME="refines"/>
+      <SPEC-RELATION-TYPE IDENTIFIER="rt-unnamed"/>
+    </SPEC-TYPES>
+    <SPEC-OBJECTS>
+      <SPEC-OBJECT IDENTIFIER="REQ-1" LONG-NAME="Checkout">
+        <TYPE><SPEC-OBJECT-TYPE-REF>st-req</SPEC-OBJECT-TYPE-REF></TYPE>
+        <VALUES>
+          <ATTRIBUTE-VALUE-XHTML>
+            <DEFINITION><ATTRIBUTE-DEFINITION-XHTML-REF>ad-text</ATTRIBUTE-DEFINITION-XHTML-REF></DEFINITION>
+            <THE-VALUE><xhtml:div xmlns:xhtml="http://www.w3.org/1999/xhtml">Pay   with
+              <xhtml:b>card</xhtml:b></xhtml:div></THE-VALUE>
+          </ATTRIBUTE-VALUE-XHTML>
+          <ATTRIBUTE-VALUE-STRING THE-VALUE="Checkout name">
+            <DEFINITION><ATTRIBUTE-DEFINITION-STRING-REF>ad-name</ATTRIBUTE-DEFINITION-STRING-REF></DEFINITION>
+          </ATTRIBUTE-VALUE-STRING>
+          <ATTRIBUTE-VALUE-STRING THE-VALUE="customer">
+            <DEFINITION><ATTRIBUTE-DEFINITION-STRING-REF>ad-user</ATTRIBUTE-DEFINITION-STRING-REF></DEFINITION>
+          </ATTRIBUTE-VALUE-STRING>
+          <ATTRIBUTE-VALUE-ENUMERATION>
+            <DEFINITION><ATTRIBUTE-DEFINITION-ENUMERATION-REF>ad-prio</ATTRIBUTE-DEFINITION-ENUMERATION-REF></DEFINITION>
+            <VALUES><ENUM-VALUE-REF>prio-high</ENUM-VALUE-REF><ENUM-VALUE-REF>prio-unknown</ENUM-VALUE-REF></VALUES>
+          </ATTRIBUTE-VALUE-ENUMERATION>
+        </VALUES>
+      </SPEC-OBJECT>
+      <SPEC-OBJECT IDENTIFIER="REQ-2" LONG-NAME="Invoice">
+        <TYPE><SPEC-OBJECT-TYPE-REF>st-req</SPEC-OBJECT-TYPE-REF></TYPE>
+        <VALUES>
+          <ATTRIBUTE-VALUE-STRING THE-VALUE="x">
+            <DEFINITION><ATTRIBUTE-DEFINITION-STRING-REF>ad-undefined</ATTRIBUTE-DEFINITION-STRING-REF></DEFINITION>
+          </ATTRIBUTE-VALUE-STRING>
+        </VALUES>
+      </SPEC-OBJECT>
+      <SPEC-OBJECT IDENTIFIER="REQ-3"/>
+    </SPEC-OBJECTS>
+    <SPEC-RELATIONS>
+      <SPEC-RELATION IDENTIFIER="REL-1">
+        <TYPE><SPEC-RELATION-TYPE-REF>rt-refines</SPEC-RELATION-TYPE-REF></TYPE>
+        <SOURCE><SPEC-OBJECT-REF> REQ-2 </SPEC-OBJECT-REF></SOURCE>
+        <TARGET><SPEC-OBJECT-REF>REQ-1</SPEC-OBJECT-REF></TARGET>
+      </SPEC-RELATION>
+      <SPEC-RELATION IDENTIFIER="REL-2" LONG-NAME="depends">
+        <TYPE><SPEC-RELATION-TYPE-REF>rt-unnamed</SPEC-RELATION-TYPE-REF></TYPE>
+        <SOURCE><SPEC-OBJECT-REF>REQ-3</SPEC-OBJECT-REF></SOURCE>
+        <TARGET><SPEC-OBJECT-REF>REQ-1</SPEC-OBJECT-REF></TARGET>
+      </SPEC-RELATION>
+      <SPEC-RELATION IDENTIFIER="REL-3">
+        <SOURCE><SPEC-OBJECT-REF>REQ-3</SPEC-OBJECT-REF></SOURCE>
+      </SPEC-RELATION>
+    </SPEC-RELATIONS>
+  </REQ-IF-CONTENT></CORE-CONTENT>
+</REQ-IF>`
+
+func TestReadReqIF(t *testing.T) {
+	objects, relations, err := readReqIF(strings.NewReader(testReqIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantObjects := []reqIFObject{
+		{
+			Identifier:  "REQ-1",
+			Description: "Pay with card",
+			UserKind:    "customer",
+			Metadata:    map[string]string{"ReqIF.Name": "Checkout name", "Priority": "High, prio-unknown"},
+		},
+		{
+			Identifier:  "REQ-2",
+			Description: "Invoice",
+			Metadata:    map[string]string{"ad-undefined": "x"},
+		},
+		{
+			Identifier:  "REQ-3",
+			Description: "REQ-3",
+		},
+	}
+	if !reflect.DeepEqual(objects, wantObjects) {
+		t.Errorf("objects = %#v, want %#v", objects, wantObjects)
+	}
+
+	wantRelations := []reqIFRelation{
+		{Identifier: "REL-1", Source: "REQ-2", Target: "REQ-1", Kind: "refines"},
+		{Identifier: "REL-2", Source: "REQ-3", Target: "REQ-1", Kind: "depends"},
+		{Identifier: "REL-3", Source: "REQ-3", Kind: "relates"},
+	}
+	if !reflect.DeepEqual(relations, wantRelations) {
+		t.Errorf("relations = %#v, want %#v", relations, wantRelations)
+	}
+}
+
+func TestReadReqIFInvalid(t *testing.T) {
+	for _, doc := range []string{"", "<REQ-IF>", "not xml"} {
+		if _, _, err := readReqIF(strings.NewReader(doc)); err == nil {
+			t.Errorf("readReqIF(%q) succeeded", doc)
+		}
+	}
+}
+
+func TestXHTMLText(t *testing.T) {
+	tests := []struct {
+		fragment string
+		want     string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"<div>a<br/>b</div>", "a b"},
+		{"<p>  spaced \n\t out  </p><p>second</p>", "spaced out second"},
+		{"<div>unclosed", "unclosed"},
+	}
+	for _, tt := range tests {
+		if got := xhtmlText(tt.fragment); got != tt.want {
+			t.Errorf("xhtmlText(%q) = %q, want %q", tt.fragment, got, tt.want)
+		}
+	}
+}
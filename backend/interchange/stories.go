@@ -0,0 +1,199 @@
+package interchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	data "traceability/data"
+)
+
+// storyPattern matches "As a <role>, I want <goal> [so that <benefit>]."
+var storyPattern = regexp.MustCompile(`(?i)^as an? ([^,]+?),\s*(i want\s+(.+?)(?:,?\s+so that\s+(.+?))?)\.?$`)
+
+// maxStoryLine is the longest line of a story file read, longer ones fail the import
+const maxStoryLine = 1 << 20
+
+// story placeholders are written as "-" in the template, e.g. "I want - so that -."
+const storyPlaceholder = "-"
+
+// StoryImportReport lists the outcome of every non blank line of an imported story file
+// swagger:model
+type StoryImportReport struct {
+	// stories added as components
+	Created []StoryImportEntry `json:"created"`
+
+	// placeholder and duplicate stories
+	Skipped []StoryImportEntry `json:"skipped"`
+
+	// lines not following the "As a ..., I want ... so that ..." template
+	Unparseable []StoryImportEntry `json:"unparseable"`
+}
+
+// StoryImportEntry is one line of an imported story file
+type StoryImportEntry struct {
+	// line number in the file, starting at 1
+	Line int `json:"line"`
+
+	// text of the line
+	Text string `json:"text"`
+
+	// id of the created component
+	ID string `json:"id,omitempty"`
+
+	// actor of the story
+	UserKind string `json:"userKind,omitempty"`
+
+	// why the line was skipped
+	Reason string `json:"reason,omitempty"`
+}
+
+// skip reasons of story lines
+const (
+	storyReasonPlaceholder = "placeholder"
+	storyReasonDuplicate   = "duplicate"
+)
+
+// ParseUserStory splits a story line into its role and the description
+// the clients show after "As a <role>, ". placeholder is true for
+// template lines like "As a developer, I want - so that -."
+func ParseUserStory(line string) (role string, description string, placeholder bool, ok bool) {
+	m := storyPattern.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", "", false, false
+	}
+	role = strings.TrimSpace(m[1])
+	description = strings.TrimSpace(m[2])
+	if !strings.HasSuffix(description, ".") {
+		description += "."
+	}
+	goal, benefit := strings.TrimSpace(m[3]), strings.TrimSpace(m[4])
+	placeholder = goal == storyPlaceholder || benefit == storyPlaceholder
+	return role, description, placeholder, true
+}
+
+// storyKey identifies a story regardless of case and spacing
+func storyKey(role string, description string) string {
+	return strings.ToLower(role) + "\x00" + strings.ToLower(strings.Join(strings.Fields(description), " "))
+}
+
+// ImportUserStories adds one component to the user story view of the project
+// for every story line of r. Roles which are new to the view are added to
+// its user kinds, placeholder lines and stories already in the view are skipped.
+func ImportUserStories(r io.Reader, projectID string) (*StoryImportReport, error) {
+	project, err := data.FindProjectByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	view, err := data.FindArchViewByID(project.UserStoriesID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := data.FindArchViewComponentsByViewID(view.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, c := range existing {
+		seen[storyKey(c.UserKind, c.Desctription)] = true
+	}
+	roles := map[string]bool{}
+	for _, k := range view.UserKinds {
+		roles[strings.ToLower(k)] = true
+	}
+
+	report := &StoryImportReport{
+		Created:     []StoryImportEntry{},
+		Skipped:     []StoryImportEntry{},
+		Unparseable: []StoryImportEntry{},
+	}
+	newRoles := false
+
+	err = readStoryLines(r, func(n int, text string) error {
+		entry := StoryImportEntry{Line: n, Text: text}
+
+		role, description, placeholder, ok := ParseUserStory(text)
+		if !ok {
+			report.Unparseable = append(report.Unparseable, entry)
+			return nil
+		}
+		entry.UserKind = role
+		if placeholder {
+			entry.Reason = storyReasonPlaceholder
+			report.Skipped = append(report.Skipped, entry)
+			return nil
+		}
+		key := storyKey(role, description)
+		if seen[key] {
+			entry.Reason = storyReasonDuplicate
+			report.Skipped = append(report.Skipped, entry)
+			return nil
+		}
+		seen[key] = true
+
+		c, err := data.AddArchViewComponent(data.ArchViewComponent{
+			Kind:         data.UserStory,
+			UserKind:     role,
+			Desctription: description,
+			ViewID:       view.ID,
+			ProjectID:    projectID,
+		})
+		if err != nil {
+			return err
+		}
+		entry.ID = c.ID
+		report.Created = append(report.Created, entry)
+
+		if !roles[strings.ToLower(role)] {
+			roles[strings.ToLower(role)] = true
+			view.UserKinds = append(view.UserKinds, role)
+			newRoles = true
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if newRoles {
+		// the view is read again as AddArchViewComponent pushed the new components to it
+		updated, err := data.FindArchViewByID(view.ID)
+		if err != nil {
+			return report, err
+		}
+		updated.UserKinds = view.UserKinds
+		if err := data.UpdateArchView(updated); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// readStoryLines calls fn with the number and the trimmed text of every
+// non-empty line, a line longer than maxStoryLine fails the import
+func readStoryLines(r io.Reader, fn func(n int, text string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStoryLine)
+	n := 0
+	for scanner.Scan() {
+		n++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if err := fn(n, text); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			err = fmt.Errorf("line %d is longer than %d bytes, the stories before it were imported", n+1, maxStoryLine)
+		}
+		return &ImportError{err}
+	}
+	return nil
+}
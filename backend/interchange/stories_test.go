@@ -0,0 +1,121 @@
+package interchange
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseUserStory(t *testing.T) {
+	tests := []struct {
+		line        string
+		role        string
+		description string
+		placeholder bool
+		ok          bool
+	}{
+		{
+			line:        "As a developer, I want to scan my code so that the view is current.",
+			role:        "developer",
+			description: "I want to scan my code so that the view is current.",
+			ok:          true,
+		},
+		{
+			line:        "  as an Admin,   I want audit logs  ",
+			role:        "Admin",
+			description: "I want audit logs.",
+			ok:          true,
+		},
+		{
+			line:        "As a customer, I want to pay, so that I get my order",
+			role:        "customer",
+			description: "I want to pay, so that I get my order.",
+			ok:          true,
+		},
+		{
+			line:        "As a tester, I want - so that -.",
+			role:        "tester",
+			description: "I want - so that -.",
+			placeholder: true,
+			ok:          true,
+		},
+		{
+			line:        "As a tester, I want reports so that -.",
+			role:        "tester",
+			description: "I want reports so that -.",
+			placeholder: true,
+			ok:          true,
+		},
+		{line: ""},
+		{line: "I want to pay."},
+		{line: "As a customer I want to pay."},
+		{line: "As a customer, I need to pay."},
+		{line: "Asa customer, I want to pay."},
+	}
+	for _, tt := range tests {
+		role, description, placeholder, ok := ParseUserStory(tt.line)
+		if role != tt.role || description != tt.description || placeholder != tt.placeholder || ok != tt.ok {
+			t.Errorf("ParseUserStory(%q) = %q, %q, %v, %v, want %q, %q, %v, %v", tt.line,
+				role, description, placeholder, ok, tt.role, tt.description, tt.placeholder, tt.ok)
+		}
+	}
+}
+
+func TestStoryKey(t *testing.T) {
+	a := storyKey("Developer", "I want  to scan\tmy code.")
+	b := storyKey("developer", "i want to scan my code.")
+	if a != b {
+		t.Errorf("storyKey differs by case and spacing: %q, %q", a, b)
+	}
+	if storyKey("dev", "eloper I want") == storyKey("developer", "I want") {
+		t.Error("storyKey doesn't separate the role from the description")
+	}
+}
+
+func TestReadStoryLines(t *testing.T) {
+	var lines []string
+	var numbers []int
+	err := readStoryLines(strings.NewReader("first\n\n  \t\n  second  \r\nthird"), func(n int, text string) error {
+		numbers = append(numbers, n)
+		lines = append(lines, text)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(numbers, []int{1, 4, 5}) || !reflect.DeepEqual(lines, []string{"first", "second", "third"}) {
+		t.Errorf("lines = %v %q, want [1 4 5] [first second third]", numbers, lines)
+	}
+}
+
+func TestReadStoryLinesTooLong(t *testing.T) {
+	doc := "first\n" + strings.Repeat("x", maxStoryLine+1) + "\nthird\n"
+	var lines []string
+	err := readStoryLines(strings.NewReader(doc), func(n int, text string) error {
+		lines = append(lines, text)
+		return nil
+	})
+	var importErr *ImportError
+	if !errors.As(err, &importErr) {
+		t.Fatalf("err = %v, want an ImportError", err)
+	}
+	if !strings.Contains(err.Error(), "line 2 ") {
+		t.Errorf("err = %q doesn't name line 2", err)
+	}
+	if !reflect.DeepEqual(lines, []string{"first"}) {
+		t.Errorf("lines = %q, want [first]", lines)
+	}
+}
+
+func TestReadStoryLinesStops(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := readStoryLines(strings.NewReader("a\nb\n"), func(n int, text string) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("err = %v after %d calls, want stop after 1", err, calls)
+	}
+}
@@ -0,0 +1,91 @@
+package live
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// writeTimeout limits the time a write to a client may block
+const writeTimeout = 10 * time.Second
+
+// EventStream is a server-sent events response for clients without websockets
+type EventStream struct {
+	conn net.Conn
+	w    *bufio.Writer
+
+	mu sync.Mutex
+}
+
+// StartEventStream takes over the connection of the response and writes the
+// headers of an event stream, headers set on rw before are kept
+func StartEventStream(rw http.ResponseWriter) (*EventStream, error) {
+	header := rw.Header().Clone()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "close")
+
+	conn, brw, err := hijack(rw)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(brw, "HTTP/1.1 200 OK\r\n")
+	header.Write(brw)
+	fmt.Fprint(brw, "\r\n")
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &EventStream{conn: conn, w: brw.Writer}, nil
+}
+
+// Send writes an event with a single line of data, e.g. compact json
+func (s *EventStream) Send(event string, data []byte) error {
+	return s.write(fmt.Sprintf("event: %s\ndata: %s\n\n", event, data))
+}
+
+// KeepAlive writes a comment which keeps proxies from closing idle connections
+func (s *EventStream) KeepAlive() error {
+	return s.write(": keep-alive\n\n")
+}
+
+func (s *EventStream) write(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := io.WriteString(s.w, text); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// Wait blocks until the client closes the connection
+func (s *EventStream) Wait() {
+	io.Copy(ioutil.Discard, s.conn)
+}
+
+// Close closes the connection
+func (s *EventStream) Close() error {
+	return s.conn.Close()
+}
+
+// hijack takes over the connection of the response and lifts the deadlines
+// of the server, live connections stay open for long
+func hijack(rw http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rw.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection can't be taken over")
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, brw, nil
+}
@@ -0,0 +1,222 @@
+package live
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	data "traceability/data"
+
+	guuid "github.com/google/uuid"
+)
+
+// Message types pushed to clients
+const (
+	// MessageHello is the first message of a connection and names its client id
+	MessageHello = "hello"
+	// MessageEvent carries a change of a view, component or link
+	MessageEvent = "event"
+	// MessagePresence carries who is viewing which view of the project
+	MessagePresence = "presence"
+)
+
+// clientBuffer is the number of messages queued for a slow client before
+// further messages are dropped
+const clientBuffer = 64
+
+// ErrUnknownClient is returned when a connection of the user is not found in the project
+var ErrUnknownClient = errors.New("live connection not found")
+
+// Message is pushed to the clients of a project
+// swagger:model
+type Message struct {
+	// "hello", "event" or "presence"
+	Type string `json:"type"`
+
+	// id of the connection, sent with hello
+	Client string `json:"client,omitempty"`
+
+	// the change, sent with event
+	Event *data.Event `json:"event,omitempty"`
+
+	// the viewers of the project, sent with presence
+	Presence []Presence `json:"presence,omitempty"`
+}
+
+// Presence tells that a user is viewing a view of the project
+// swagger:model
+type Presence struct {
+	// id of the connection
+	Client string `json:"client"`
+
+	// id of the user
+	UserID string `json:"userID"`
+
+	// id of the viewed view, empty if the client didn't name one yet
+	ViewID string `json:"viewID,omitempty"`
+
+	// time the user started viewing it
+	Since time.Time `json:"since"`
+}
+
+// Client is a live connection of a user to a project
+type Client struct {
+	ID        string
+	UserID    string
+	ProjectID string
+
+	// Send delivers the messages for the client
+	Send chan Message
+
+	// closed is closed when the hub disconnects the client
+	closed chan struct{}
+
+	viewID string
+	since  time.Time
+}
+
+// Closed is closed when the hub disconnected the client, e.g. as its user
+// left the project
+func (c *Client) Closed() <-chan struct{} {
+	return c.closed
+}
+
+// Hub fans the changes of projects out to their live connections and keeps
+// track of who is viewing which view
+type Hub struct {
+	mu       sync.Mutex
+	projects map[string]map[string]*Client
+}
+
+// NewHub returns a hub without connections
+func NewHub() *Hub {
+	return &Hub{projects: map[string]map[string]*Client{}}
+}
+
+// HandleEvent pushes changes of views, components and links to the clients
+// of the project and disconnects members who were removed from it
+func (h *Hub) HandleEvent(e data.Event) error {
+	if e.Type == data.EventMemberRemoved {
+		h.Disconnect(e.ProjectID, e.TargetID)
+		return nil
+	}
+	switch e.TargetKind {
+	case "view", "component", "link":
+		h.broadcast(e.ProjectID, Message{Type: MessageEvent, Event: &e})
+	}
+	return nil
+}
+
+// Join registers a new connection of the user to the project and queues its hello message
+func (h *Hub) Join(projectID string, userID string) *Client {
+	c := &Client{
+		ID:        guuid.New().String(),
+		UserID:    userID,
+		ProjectID: projectID,
+		Send:      make(chan Message, clientBuffer),
+		closed:    make(chan struct{}),
+		since:     time.Now(),
+	}
+	c.Send <- Message{Type: MessageHello, Client: c.ID}
+
+	h.mu.Lock()
+	clients, ok := h.projects[projectID]
+	if !ok {
+		clients = map[string]*Client{}
+		h.projects[projectID] = clients
+	}
+	clients[c.ID] = c
+	h.mu.Unlock()
+
+	h.broadcastPresence(projectID)
+	return c
+}
+
+// Leave removes the connection and tells the others that the user left
+func (h *Hub) Leave(c *Client) {
+	h.mu.Lock()
+	clients := h.projects[c.ProjectID]
+	delete(clients, c.ID)
+	if len(clients) == 0 {
+		delete(h.projects, c.ProjectID)
+	}
+	h.mu.Unlock()
+
+	h.broadcastPresence(c.ProjectID)
+}
+
+// Disconnect closes the connections of the user to the project
+func (h *Hub) Disconnect(projectID string, userID string) {
+	h.mu.Lock()
+	clients := h.projects[projectID]
+	n := len(clients)
+	for id, c := range clients {
+		if c.UserID == userID {
+			delete(clients, id)
+			close(c.closed)
+		}
+	}
+	if len(clients) == 0 {
+		delete(h.projects, projectID)
+	}
+	disconnected := len(clients) != n
+	h.mu.Unlock()
+
+	if disconnected {
+		h.broadcastPresence(projectID)
+	}
+}
+
+// SetView records that the user's connection views the view, empty if none
+func (h *Hub) SetView(projectID string, clientID string, userID string, viewID string) error {
+	h.mu.Lock()
+	c, ok := h.projects[projectID][clientID]
+	if !ok || c.UserID != userID {
+		h.mu.Unlock()
+		return ErrUnknownClient
+	}
+	changed := c.viewID != viewID
+	if changed {
+		c.viewID, c.since = viewID, time.Now()
+	}
+	h.mu.Unlock()
+
+	if changed {
+		h.broadcastPresence(projectID)
+	}
+	return nil
+}
+
+// Presence returns the connections of the project with their views, oldest first
+func (h *Hub) Presence(projectID string) []Presence {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := []Presence{}
+	for _, c := range h.projects[projectID] {
+		result = append(result, Presence{Client: c.ID, UserID: c.UserID, ViewID: c.viewID, Since: c.since})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Since.Before(result[j].Since)
+	})
+	return result
+}
+
+func (h *Hub) broadcastPresence(projectID string) {
+	h.broadcast(projectID, Message{Type: MessagePresence, Presence: h.Presence(projectID)})
+}
+
+// broadcast queues the message for every client of the project, clients
+// which don't keep up miss it rather than blocking the change
+func (h *Hub) broadcast(projectID string, m Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, c := range h.projects[projectID] {
+		select {
+		case c.Send <- m:
+		default:
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package mail sends the emails of the server, e.g. project invitations,
+// through SMTP or into an outbox directory
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email
+type Message struct {
+	// addresses of the recipients
+	To []string
+
+	// subject line
+	Subject string
+
+	// plain text body
+	Body string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(m Message) error
+}
+
+// Bytes returns the message as RFC 5322 email from the sender
+func (m Message) Bytes(from string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	for _, line := range strings.Split(m.Body, "\n") {
+		b.WriteString(strings.TrimSuffix(line, "\r"))
+		b.WriteString("\r\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	guuid "github.com/google/uuid"
+)
+
+// OutboxMailer writes emails as .eml files into a directory instead of
+// sending them, for development and tests without a mail server. The emails
+// carry invitation and login tokens, only the server's user can read them.
+type OutboxMailer struct {
+	dir  string
+	from string
+}
+
+// NewOutboxMailer returns a mailer writing into dir, it is created if missing
+func NewOutboxMailer(dir string, from string) *OutboxMailer {
+	return &OutboxMailer{dir: dir, from: from}
+}
+
+// Send writes the message into a new file of the outbox
+func (o *OutboxMailer) Send(m Message) error {
+	if err := os.MkdirAll(o.dir, 0700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), guuid.New().String())
+	return ioutil.WriteFile(filepath.Join(o.dir, name), m.Bytes(o.from), 0600)
+}
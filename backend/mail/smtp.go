@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"net"
+	"net/smtp"
+)
+
+// SMTPMailer sends emails through an SMTP server, e.g. a local stand-in like
+// cmd/mailsink during development
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer returns a mailer sending from the address through the SMTP
+// server at addr ("host:port"). Without username it doesn't authenticate.
+func NewSMTPMailer(addr string, from string, username string, password string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{addr: addr, from: from, auth: auth}
+}
+
+// Send sends the message, it uses STARTTLS if the server supports it
+func (s *SMTPMailer) Send(m Message) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, m.To, m.Bytes(s.from))
+}
@@ -7,22 +7,40 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"time"
 
 	auth "traceability/auth"
+	"traceability/codescan"
 	"traceability/data"
 	"traceability/database"
+	accessTokenHandlers "traceability/handlers/accesstoken"
 	archViewHandlers "traceability/handlers/archview"
 
 	componentHandlers "traceability/handlers/archviewcomponents"
+	changeHandlers "traceability/handlers/change"
+	scanHandlers "traceability/handlers/codescan"
+	commentHandlers "traceability/handlers/comment"
+	interchangeHandlers "traceability/handlers/interchange"
+	invitationHandlers "traceability/handlers/invitation"
+	labelHandlers "traceability/handlers/label"
 	linkHandlers "traceability/handlers/link"
+	liveHandlers "traceability/handlers/live"
+	notificationHandlers "traceability/handlers/notification"
+	oauthHandlers "traceability/handlers/oauth"
 	projectHandlers "traceability/handlers/project"
+	roleHandlers "traceability/handlers/role"
 	userHandlers "traceability/handlers/user"
+	viewKindHandlers "traceability/handlers/viewkind"
+	webhookHandlers "traceability/handlers/webhook"
+	"traceability/live"
+	"traceability/mail"
+	"traceability/oauth"
+	"traceability/ratelimit"
+	"traceability/webhook"
 
 	"github.com/gorilla/mux"
-
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const address = ":8080"
@@ -32,24 +50,92 @@ const (
 	dbName = "traceability"
 )
 
+// defaults of the settings read from the environment
+const (
+	defaultBaseURL  = "http://localhost:8080"
+	defaultMailFrom = "traceability@localhost"
+	defaultOutbox   = "outbox"
+	defaultEnv      = "production"
+	defaultOAuth    = "oauth.json"
+)
+
 func main() {
 
 	connectDB()
+	configureHashing()
+	configureRateLimits()
+
+	auth.AppKey = os.Getenv("APP_KEY")
+	if auth.AppKey == "" {
+		log.Fatal("HTTP server unable to start, expected an APP_KEY for JWT auth")
+	}
+	// git scans through the api only read repositories below this directory
+	codescan.RepositoryRoot = os.Getenv("GIT_REPOSITORY_ROOT")
 
 	sm := mux.NewRouter()
+	sm.Use(apiLimiter.Middleware)
 	l := log.New(os.Stdout, "traceability-api", log.LstdFlags)
 	v := data.NewValidation()
-	uh := userHandlers.NewUsers(l, v)
 	ph := projectHandlers.NewProjects(l, v)
 	ah := archViewHandlers.NewArchViews(l, v)
 	ch := componentHandlers.NewArchViewComponents(l, v)
 	lh := linkHandlers.NewLinks(l, v)
+	ih := interchangeHandlers.NewInterchange(l, v)
+	sh := scanHandlers.NewScans(l, v)
+	vh := viewKindHandlers.NewViewKinds(l, v)
+	lbh := labelHandlers.NewLabels(l, v)
+	cmh := commentHandlers.NewComments(l, v)
+	nh := notificationHandlers.NewNotifications(l, v)
+	if networks := os.Getenv("WEBHOOK_ALLOWED_NETWORKS"); networks != "" {
+		allowed, err := ratelimit.ParseNetworks(networks)
+		if err != nil {
+			log.Fatal("WEBHOOK_ALLOWED_NETWORKS: ", err)
+		}
+		webhook.AllowedNetworks = allowed
+	}
+	wd := webhook.NewDispatcher(l)
+	data.OnEvent(wd.HandleEvent)
+	go func() {
+		if err := wd.Resume(); err != nil {
+			l.Println("[ERROR] resuming webhook deliveries", err)
+		}
+	}()
+	wh := webhookHandlers.NewWebhooks(l, v, wd)
+	hub := live.NewHub()
+	data.OnEvent(hub.HandleEvent)
+	lvh := liveHandlers.NewLive(l, v, hub)
+	chh := changeHandlers.NewChanges(l, v)
+	providers, err := oauth.LoadProviders(getenv("OAUTH_PROVIDERS", defaultOAuth))
+	if err != nil {
+		l.Fatal("loading login providers: ", err)
+	}
+	baseURL := getenv("APP_BASE_URL", defaultBaseURL)
+	auth.AllowedOrigins = strings.Split(getenv("CORS_ORIGINS", baseURL), ",")
+	oh := oauthHandlers.NewOAuth(l, v, providers, baseURL, strings.Split(getenv("OAUTH_REDIRECTS", baseURL), ","))
+	mailer := newMailer(l)
+	uh := userHandlers.NewUsers(l, v, mailer, baseURL, loginGuard)
+	invh := invitationHandlers.NewInvitations(l, v, mailer, baseURL)
+	rh := roleHandlers.NewRoles(l, v)
+	ath := accessTokenHandlers.NewAccessTokens(l, v)
 	sm.StrictSlash(true)
 	setUserEndpoints(sm, uh)
+	setOAuthEndpoints(sm, oh)
+	setAccessTokenEndpoints(sm, ath)
 	setProjectEndpoints(sm, ph)
+	setInvitationEndpoints(sm, invh)
+	setRoleEndpoints(sm, rh)
 	setArchViewEndpoints(sm, ah)
+	setViewKindEndpoints(sm, vh)
 	setArchViewComponentEndpoints(sm, ch)
 	setLinksEndpoints(sm, lh)
+	setLabelEndpoints(sm, lbh)
+	setCommentEndpoints(sm, cmh)
+	setNotificationEndpoints(sm, nh)
+	setWebhookEndpoints(sm, wh)
+	setLiveEndpoints(sm, lvh)
+	setChangeEndpoints(sm, chh)
+	setInterchangeEndpoints(sm, ih)
+	setScanEndpoints(sm, sh)
 
 	s := http.Server{
 		Addr:         address,           // configure the bind address
@@ -84,49 +170,224 @@ func main() {
 		fmt.Println("cancel != nil")
 	}
 	s.Shutdown(ctx)
+	// the notifications of the last changes are queued still
+	if !data.FlushNotifications(10 * time.Second) {
+		log.Println("[WARN] notifications lost at shutdown")
+	}
 }
 
 func connectDB() {
 	dbURI := fmt.Sprintf("mongodb://%s:%d", dbHost, dbPort)
-	clientOptions := options.Client().ApplyURI(dbURI)
 
 	// Connect to MongoDB
-	client, err := mongo.Connect(context.TODO(), clientOptions)
-
+	err := database.Connect(dbURI, dbName)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Check the connection
-	err = client.Ping(context.TODO(), nil)
+	fmt.Println("Connected to MongoDB!")
+}
+
+// configureHashing sets the password hashing policy from PASSWORD_HASH
+// ("bcrypt" or "argon2id"), PASSWORD_BCRYPT_COST and PASSWORD_ARGON2_TIME,
+// PASSWORD_ARGON2_MEMORY (KiB) and PASSWORD_ARGON2_THREADS
+func configureHashing() {
+	policy := data.Hashing
+	policy.Algorithm = getenv("PASSWORD_HASH", policy.Algorithm)
+	policy.BcryptCost = getenvInt("PASSWORD_BCRYPT_COST", policy.BcryptCost)
+	policy.Argon2Time = uint32(getenvUint("PASSWORD_ARGON2_TIME", uint64(policy.Argon2Time), 32))
+	policy.Argon2Memory = uint32(getenvUint("PASSWORD_ARGON2_MEMORY", uint64(policy.Argon2Memory), 32))
+	policy.Argon2Threads = uint8(getenvUint("PASSWORD_ARGON2_THREADS", uint64(policy.Argon2Threads), 8))
+	if err := policy.Validate(); err != nil {
+		log.Fatal("password hashing: ", err)
+	}
+	data.Hashing = policy
+}
+
+// getenvInt returns the environment variable as a number or the fallback if it is empty
+func getenvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("%s must be a number, not %q", key, value)
+	}
+	return n
+}
+
+// getenvUint returns the environment variable as an unsigned number of the
+// given bit size or the fallback if it is empty
+func getenvUint(key string, fallback uint64, bits int) uint64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.ParseUint(value, 10, bits)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("%s must be a number from 0 to %d, not %q", key, uint64(1)<<bits-1, value)
 	}
-	db := client.Database(dbName)
-	database.DBCon = client
-	database.DB = db
+	return n
+}
 
-	fmt.Println("Connected to MongoDB!")
+// getenv returns the environment variable or the fallback if it is empty
+func getenv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// newMailer returns a mailer sending through the SMTP server in MAIL_SMTP_ADDR
+// or, without one, writing the emails into the MAIL_OUTBOX directory
+func newMailer(l *log.Logger) mail.Mailer {
+	from := getenv("MAIL_FROM", defaultMailFrom)
+	if addr := os.Getenv("MAIL_SMTP_ADDR"); addr != "" {
+		l.Println("sending emails through", addr)
+		return mail.NewSMTPMailer(addr, from, os.Getenv("MAIL_SMTP_USER"), os.Getenv("MAIL_SMTP_PASSWORD"))
+	}
+	outbox := getenv("MAIL_OUTBOX", defaultOutbox)
+	if getenv("APP_ENV", defaultEnv) != "development" {
+		l.Println("[WARN] MAIL_SMTP_ADDR is not set, emails with invitation and login tokens are written into", outbox, "instead of sent")
+	} else {
+		l.Println("writing emails into", outbox)
+	}
+	return mail.NewOutboxMailer(outbox, from)
+}
+
+// rate limits of the route groups, per client address and minute, set by configureRateLimits
+var (
+	// apiLimiter limits every request
+	apiLimiter *ratelimit.Limiter
+	// authLimiter limits logins, signups and the emailing endpoints
+	authLimiter *ratelimit.Limiter
+)
+
+// configureRateLimits sets the requests per minute and client address of all
+// routes from RATE_LIMIT_API and of the login, signup and emailing routes
+// from RATE_LIMIT_AUTH. With TRUST_PROXY=true the client address is taken
+// from X-Forwarded-For behind the proxies in TRUSTED_PROXIES (CIDRs).
+func configureRateLimits() {
+	apiLimit, authLimit := getenvInt("RATE_LIMIT_API", 600), getenvInt("RATE_LIMIT_AUTH", 20)
+	if apiLimit == 0 || authLimit == 0 {
+		log.Fatal("RATE_LIMIT_API and RATE_LIMIT_AUTH must allow at least one request")
+	}
+	apiLimiter = ratelimit.NewLimiter(apiLimit, time.Minute)
+	authLimiter = ratelimit.NewLimiter(authLimit, time.Minute)
+
+	ratelimit.TrustForwardedFor = os.Getenv("TRUST_PROXY") == "true"
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		networks, err := ratelimit.ParseNetworks(proxies)
+		if err != nil {
+			log.Fatal("TRUSTED_PROXIES: ", err)
+		}
+		ratelimit.TrustedProxies = networks
+	}
+}
+
+// loginGuard slows down failed logins: every account and address may fail a
+// few times, then waits double as long after every failure and is locked out
+// after too many
+var loginGuard = &ratelimit.LoginGuard{
+	Accounts: ratelimit.NewBackoff(ratelimit.BackoffPolicy{
+		Free:         3,
+		Delay:        time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 10,
+		Lockout:      15 * time.Minute,
+		Forget:       time.Hour,
+	}),
+	Addresses: ratelimit.NewBackoff(ratelimit.BackoffPolicy{
+		Free:         10,
+		Delay:        time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 50,
+		Lockout:      time.Hour,
+		Forget:       time.Hour,
+	}),
 }
 
 func setUserEndpoints(sm *mux.Router, uh *userHandlers.Users) {
 	getUserList := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
 	getUserList.HandleFunc("/users/", uh.ListAll)
+	getUserList.Use(auth.CORS)
+	getUserList.Use(auth.Middleware)
+	getUserList.Use(auth.RejectProjectAccessTokens)
 
 	getUser := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
 	getUser.HandleFunc("/users/{id}/", uh.GetUser)
 	getUser.Use(auth.CORS)
 	getUser.Use(auth.Middleware)
+	getUser.Use(auth.RejectProjectAccessTokens)
 
 	postUs := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
 	postUs.HandleFunc("/users", uh.CreateUser)
 	postUs.Use(auth.CORS)
+	postUs.Use(authLimiter.Middleware)
 	postUs.Use(uh.MiddlewareValidateUser)
 
 	loginUser := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
 	loginUser.HandleFunc("/login", uh.LoginUser)
 	loginUser.Use(auth.CORS)
+	loginUser.Use(authLimiter.Middleware)
 	loginUser.Use(uh.MiddlewareValidateAuth)
+
+	refreshToken := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	refreshToken.HandleFunc("/refresh", uh.RefreshToken)
+	refreshToken.Use(auth.CORS)
+
+	postEmailToken := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postEmailToken.HandleFunc("/verify", uh.VerifyEmail)
+	postEmailToken.HandleFunc("/password/forgot", uh.ForgotPassword)
+	postEmailToken.HandleFunc("/password/reset", uh.ResetPassword)
+	postEmailToken.Use(auth.CORS)
+	postEmailToken.Use(authLimiter.Middleware)
+
+	resendVerification := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	resendVerification.HandleFunc("/verify/resend", uh.ResendVerification)
+	resendVerification.Use(auth.CORS)
+	resendVerification.Use(authLimiter.Middleware)
+	resendVerification.Use(auth.Middleware)
+	resendVerification.Use(auth.RejectAccessTokens)
+
+	logout := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	logout.HandleFunc("/logout", uh.Logout)
+	logout.HandleFunc("/logout/all", uh.LogoutEverywhere)
+	logout.Use(auth.CORS)
+	logout.Use(auth.Middleware)
+	logout.Use(auth.RejectAccessTokens)
+}
+
+func setOAuthEndpoints(sm *mux.Router, oh *oauthHandlers.OAuth) {
+	getOAuth := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getOAuth.HandleFunc("/auth/providers", oh.ListProviders)
+	getOAuth.HandleFunc("/auth/{provider}/login", oh.Login)
+	getOAuth.HandleFunc("/auth/{provider}/callback", oh.Callback)
+	getOAuth.Use(auth.CORS)
+	getOAuth.Use(authLimiter.Middleware)
+}
+
+func setAccessTokenEndpoints(sm *mux.Router, ath *accessTokenHandlers.AccessTokens) {
+	getTokens := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getTokens.HandleFunc("/tokens/", ath.ListAccessTokens)
+	getTokens.Use(auth.CORS)
+	getTokens.Use(auth.Middleware)
+	getTokens.Use(auth.RejectAccessTokens)
+
+	postToken := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postToken.HandleFunc("/tokens/", ath.CreateAccessToken)
+	postToken.Use(auth.CORS)
+	postToken.Use(auth.Middleware)
+	postToken.Use(auth.RejectAccessTokens)
+	postToken.Use(auth.RequireVerified)
+	postToken.Use(ath.MiddlewareValidateAccessToken)
+
+	deleteToken := sm.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteToken.HandleFunc("/tokens/{tokenID}/", ath.RevokeAccessToken)
+	deleteToken.Use(auth.CORS)
+	deleteToken.Use(auth.Middleware)
+	deleteToken.Use(auth.RejectAccessTokens)
 }
 
 func setProjectEndpoints(sm *mux.Router, ph *projectHandlers.Projects) {
@@ -135,16 +396,20 @@ func setProjectEndpoints(sm *mux.Router, ph *projectHandlers.Projects) {
 	getProj.Use(auth.CORS)
 	getProj.Use(auth.Middleware)
 	getProj.Use(auth.ProjectAuthMiddleware)
+	getProj.Use(auth.Require(data.PermProjectRead))
 
 	getProjList := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
 	getProjList.HandleFunc("/users/{userID}/projects/", ph.ListAll)
 	getProjList.Use(auth.CORS)
 	getProjList.Use(auth.Middleware)
+	getProjList.Use(auth.RejectProjectAccessTokens)
 
 	postProj := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
 	postProj.HandleFunc("/projects", ph.CreateProject)
 	postProj.Use(auth.CORS)
 	postProj.Use(auth.Middleware)
+	postProj.Use(auth.RejectProjectAccessTokens)
+	postProj.Use(auth.RequireVerified)
 	postProj.Use(ph.MiddlewareValidateProject)
 
 	patchProj := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
@@ -152,6 +417,110 @@ func setProjectEndpoints(sm *mux.Router, ph *projectHandlers.Projects) {
 	patchProj.Use(auth.CORS)
 	patchProj.Use(auth.Middleware)
 	patchProj.Use(auth.ProjectAuthMiddleware)
+	patchProj.Use(auth.Require(data.PermProjectWrite))
+
+	getMembers := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getMembers.HandleFunc("/projects/{projectID}/members/", ph.ListMembers)
+	getMembers.Use(auth.CORS)
+	getMembers.Use(auth.Middleware)
+	getMembers.Use(auth.ProjectAuthMiddleware)
+	getMembers.Use(auth.Require(data.PermProjectRead))
+
+	postMember := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postMember.HandleFunc("/projects/{projectID}/members/", ph.AddMember)
+	postMember.Use(auth.CORS)
+	postMember.Use(auth.Middleware)
+	postMember.Use(auth.ProjectAuthMiddleware)
+	postMember.Use(auth.Require(data.PermMemberManage))
+	postMember.Use(ph.MiddlewareValidateMember)
+
+	transferOwnership := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	transferOwnership.HandleFunc("/projects/{projectID}/members/{userID}/transfer", ph.TransferOwnership)
+	transferOwnership.Use(auth.CORS)
+	transferOwnership.Use(auth.Middleware)
+	transferOwnership.Use(auth.ProjectAuthMiddleware)
+	transferOwnership.Use(auth.Require(data.PermMemberManage))
+
+	patchMember := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
+	patchMember.HandleFunc("/projects/{projectID}/members/{userID}/", ph.UpdateMember)
+	patchMember.Use(auth.CORS)
+	patchMember.Use(auth.Middleware)
+	patchMember.Use(auth.ProjectAuthMiddleware)
+	patchMember.Use(auth.Require(data.PermMemberManage))
+	patchMember.Use(ph.MiddlewareValidateMember)
+
+	deleteMember := sm.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteMember.HandleFunc("/projects/{projectID}/members/{userID}/", ph.RemoveMember)
+	deleteMember.Use(auth.CORS)
+	deleteMember.Use(auth.Middleware)
+	deleteMember.Use(auth.ProjectAuthMiddleware)
+	deleteMember.Use(auth.Require(data.PermMemberManage))
+}
+
+func setInvitationEndpoints(sm *mux.Router, ih *invitationHandlers.Invitations) {
+	getInvitations := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getInvitations.HandleFunc("/projects/{projectID}/invitations/", ih.ListInvitations)
+	getInvitations.Use(auth.CORS)
+	getInvitations.Use(auth.Middleware)
+	getInvitations.Use(auth.ProjectAuthMiddleware)
+	getInvitations.Use(auth.Require(data.PermMemberManage))
+
+	postInvitation := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postInvitation.HandleFunc("/projects/{projectID}/invitations/", ih.CreateInvitation)
+	postInvitation.Use(auth.CORS)
+	postInvitation.Use(auth.Middleware)
+	postInvitation.Use(auth.ProjectAuthMiddleware)
+	postInvitation.Use(auth.Require(data.PermMemberManage))
+	postInvitation.Use(ih.MiddlewareValidateInvitation)
+
+	deleteInvitation := sm.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteInvitation.HandleFunc("/projects/{projectID}/invitations/{invitationID}/", ih.RevokeInvitation)
+	deleteInvitation.Use(auth.CORS)
+	deleteInvitation.Use(auth.Middleware)
+	deleteInvitation.Use(auth.ProjectAuthMiddleware)
+	deleteInvitation.Use(auth.Require(data.PermMemberManage))
+
+	getInvitation := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getInvitation.HandleFunc("/invitations/{token}", ih.GetInvitation)
+	getInvitation.Use(auth.CORS)
+
+	acceptInvitation := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	acceptInvitation.HandleFunc("/invitations/{token}/accept", ih.AcceptInvitation)
+	acceptInvitation.Use(auth.CORS)
+	acceptInvitation.Use(auth.Middleware)
+	acceptInvitation.Use(auth.RejectProjectAccessTokens)
+}
+
+func setRoleEndpoints(sm *mux.Router, rh *roleHandlers.Roles) {
+	getRole := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getRole.HandleFunc("/projects/{projectID}/roles/", rh.ListRoles)
+	getRole.HandleFunc("/projects/{projectID}/roles/{name}/", rh.GetRole)
+	getRole.Use(auth.CORS)
+	getRole.Use(auth.Middleware)
+	getRole.Use(auth.ProjectAuthMiddleware)
+	getRole.Use(auth.Require(data.PermProjectRead))
+
+	postRole := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postRole.HandleFunc("/projects/{projectID}/roles/", rh.CreateRole)
+	postRole.Use(auth.CORS)
+	postRole.Use(auth.Middleware)
+	postRole.Use(auth.ProjectAuthMiddleware)
+	postRole.Use(auth.Require(data.PermRoleManage))
+	postRole.Use(rh.MiddlewareValidateRole)
+
+	patchRole := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
+	patchRole.HandleFunc("/projects/{projectID}/roles/{name}/", rh.UpdateRole)
+	patchRole.Use(auth.CORS)
+	patchRole.Use(auth.Middleware)
+	patchRole.Use(auth.ProjectAuthMiddleware)
+	patchRole.Use(auth.Require(data.PermRoleManage))
+
+	deleteRole := sm.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteRole.HandleFunc("/projects/{projectID}/roles/{name}/", rh.DeleteRole)
+	deleteRole.Use(auth.CORS)
+	deleteRole.Use(auth.Middleware)
+	deleteRole.Use(auth.ProjectAuthMiddleware)
+	deleteRole.Use(auth.Require(data.PermRoleManage))
 }
 
 func setArchViewEndpoints(sm *mux.Router, ah *archViewHandlers.ArchViews) {
@@ -160,18 +529,21 @@ func setArchViewEndpoints(sm *mux.Router, ah *archViewHandlers.ArchViews) {
 	getArchView.Use(auth.CORS)
 	getArchView.Use(auth.Middleware)
 	getArchView.Use(auth.ProjectAuthMiddleware)
+	getArchView.Use(auth.Require(data.PermProjectRead))
 
 	listArchView := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
 	listArchView.HandleFunc("/projects/{projectID}/views/", ah.ListArchViews)
 	listArchView.Use(auth.CORS)
 	listArchView.Use(auth.Middleware)
 	listArchView.Use(auth.ProjectAuthMiddleware)
+	listArchView.Use(auth.Require(data.PermProjectRead))
 
 	postArchView := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
 	postArchView.HandleFunc("/projects/{projectID}/views/", ah.CreateArchView)
 	postArchView.Use(auth.CORS)
 	postArchView.Use(auth.Middleware)
 	postArchView.Use(auth.ProjectAuthMiddleware)
+	postArchView.Use(auth.Require(data.PermViewWrite))
 	postArchView.Use(ah.MiddlewareValidateArchView)
 
 	patchArchView := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
@@ -179,6 +551,45 @@ func setArchViewEndpoints(sm *mux.Router, ah *archViewHandlers.ArchViews) {
 	patchArchView.Use(auth.CORS)
 	patchArchView.Use(auth.Middleware)
 	patchArchView.Use(auth.ProjectAuthMiddleware)
+	patchArchView.Use(auth.Require(data.PermViewWrite))
+}
+
+func setViewKindEndpoints(sm *mux.Router, vh *viewKindHandlers.ViewKinds) {
+	getViewKind := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getViewKind.HandleFunc("/projects/{projectID}/viewkinds/{name}/", vh.GetViewKind)
+	getViewKind.Use(auth.CORS)
+	getViewKind.Use(auth.Middleware)
+	getViewKind.Use(auth.ProjectAuthMiddleware)
+	getViewKind.Use(auth.Require(data.PermProjectRead))
+
+	listViewKinds := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	listViewKinds.HandleFunc("/projects/{projectID}/viewkinds/", vh.ListViewKinds)
+	listViewKinds.Use(auth.CORS)
+	listViewKinds.Use(auth.Middleware)
+	listViewKinds.Use(auth.ProjectAuthMiddleware)
+	listViewKinds.Use(auth.Require(data.PermProjectRead))
+
+	postViewKind := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postViewKind.HandleFunc("/projects/{projectID}/viewkinds/", vh.CreateViewKind)
+	postViewKind.Use(auth.CORS)
+	postViewKind.Use(auth.Middleware)
+	postViewKind.Use(auth.ProjectAuthMiddleware)
+	postViewKind.Use(auth.Require(data.PermViewKindManage))
+	postViewKind.Use(vh.MiddlewareValidateViewKind)
+
+	patchViewKind := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
+	patchViewKind.HandleFunc("/projects/{projectID}/viewkinds/{name}/", vh.UpdateViewKind)
+	patchViewKind.Use(auth.CORS)
+	patchViewKind.Use(auth.Middleware)
+	patchViewKind.Use(auth.ProjectAuthMiddleware)
+	patchViewKind.Use(auth.Require(data.PermViewKindManage))
+
+	deleteViewKind := sm.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteViewKind.HandleFunc("/projects/{projectID}/viewkinds/{name}/", vh.DeleteViewKind)
+	deleteViewKind.Use(auth.CORS)
+	deleteViewKind.Use(auth.Middleware)
+	deleteViewKind.Use(auth.ProjectAuthMiddleware)
+	deleteViewKind.Use(auth.Require(data.PermViewKindManage))
 }
 
 func setArchViewComponentEndpoints(sm *mux.Router, ch *componentHandlers.ArchViewComponents) {
@@ -187,12 +598,14 @@ func setArchViewComponentEndpoints(sm *mux.Router, ch *componentHandlers.ArchVie
 	getComp.Use(auth.CORS)
 	getComp.Use(auth.Middleware)
 	getComp.Use(auth.ProjectAuthMiddleware)
+	getComp.Use(auth.Require(data.PermProjectRead))
 
 	postComponent := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
 	postComponent.HandleFunc("/projects/{projectID}/views/{viewID}/components/", ch.AddArchViewComponent)
 	postComponent.Use(auth.CORS)
 	postComponent.Use(auth.Middleware)
 	postComponent.Use(auth.ProjectAuthMiddleware)
+	postComponent.Use(auth.Require(data.PermComponentWrite))
 	postComponent.Use(ch.MiddlewareValidateArchViewComponent)
 
 	listComponents := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
@@ -200,18 +613,28 @@ func setArchViewComponentEndpoints(sm *mux.Router, ch *componentHandlers.ArchVie
 	listComponents.Use(auth.CORS)
 	listComponents.Use(auth.Middleware)
 	listComponents.Use(auth.ProjectAuthMiddleware)
+	listComponents.Use(auth.Require(data.PermProjectRead))
 
 	listAllComponents := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
 	listAllComponents.HandleFunc("/projects/{projectID}/components/", ch.ListAllComponents)
 	listAllComponents.Use(auth.CORS)
 	listAllComponents.Use(auth.Middleware)
 	listAllComponents.Use(auth.ProjectAuthMiddleware)
+	listAllComponents.Use(auth.Require(data.PermProjectRead))
+
+	listCommits := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	listCommits.HandleFunc("/projects/{projectID}/components/{componentID}/commits", ch.ListCommits)
+	listCommits.Use(auth.CORS)
+	listCommits.Use(auth.Middleware)
+	listCommits.Use(auth.ProjectAuthMiddleware)
+	listCommits.Use(auth.Require(data.PermProjectRead))
 
 	patchComponent := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
 	patchComponent.HandleFunc("/projects/{projectID}/views/{viewID}/components/{id}/", ch.UpdateArchViewComponent)
 	patchComponent.Use(auth.CORS)
 	patchComponent.Use(auth.Middleware)
 	patchComponent.Use(auth.ProjectAuthMiddleware)
+	patchComponent.Use(auth.Require(data.PermComponentWrite))
 }
 
 func setLinksEndpoints(sm *mux.Router, lh *linkHandlers.Links) {
@@ -220,23 +643,27 @@ func setLinksEndpoints(sm *mux.Router, lh *linkHandlers.Links) {
 	getLinkByID.Use(auth.CORS)
 	getLinkByID.Use(auth.Middleware)
 	getLinkByID.Use(auth.ProjectAuthMiddleware)
+	getLinkByID.Use(auth.Require(data.PermProjectRead))
 
 	getAllLinks := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
 	getAllLinks.HandleFunc("/links/", lh.ListAll)
 	getAllLinks.Use(auth.CORS)
 	getAllLinks.Use(auth.Middleware)
+	getAllLinks.Use(auth.RejectProjectAccessTokens)
 
 	getLinksOfProject := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
 	getLinksOfProject.HandleFunc("/projects/{projectID}/links/", lh.GetProjectLinks)
 	getLinksOfProject.Use(auth.CORS)
 	getLinksOfProject.Use(auth.Middleware)
 	getLinksOfProject.Use(auth.ProjectAuthMiddleware)
+	getLinksOfProject.Use(auth.Require(data.PermProjectRead))
 
 	postLink := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
 	postLink.HandleFunc("/projects/{projectID}/links/", lh.AddLink)
 	postLink.Use(auth.CORS)
 	postLink.Use(auth.Middleware)
 	postLink.Use(auth.ProjectAuthMiddleware)
+	postLink.Use(auth.Require(data.PermLinkWrite))
 	postLink.Use(lh.MiddlewareValidateLink)
 
 	getLinksOfComponent := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
@@ -244,4 +671,289 @@ func setLinksEndpoints(sm *mux.Router, lh *linkHandlers.Links) {
 	getLinksOfComponent.Use(auth.CORS)
 	getLinksOfComponent.Use(auth.Middleware)
 	getLinksOfComponent.Use(auth.ProjectAuthMiddleware)
+	getLinksOfComponent.Use(auth.Require(data.PermProjectRead))
+
+	deleteLink := sm.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteLink.HandleFunc("/projects/{projectID}/links/{linkID}/", lh.DeleteLink)
+	deleteLink.Use(auth.CORS)
+	deleteLink.Use(auth.Middleware)
+	deleteLink.Use(auth.ProjectAuthMiddleware)
+	deleteLink.Use(auth.Require(data.PermLinkDelete))
+}
+
+func setLabelEndpoints(sm *mux.Router, lh *labelHandlers.Labels) {
+	getLabel := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getLabel.HandleFunc("/projects/{projectID}/labels/{labelID}/", lh.GetLabel)
+	getLabel.Use(auth.CORS)
+	getLabel.Use(auth.Middleware)
+	getLabel.Use(auth.ProjectAuthMiddleware)
+	getLabel.Use(auth.Require(data.PermProjectRead))
+
+	listLabels := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	listLabels.HandleFunc("/projects/{projectID}/labels/", lh.ListLabels)
+	listLabels.Use(auth.CORS)
+	listLabels.Use(auth.Middleware)
+	listLabels.Use(auth.ProjectAuthMiddleware)
+	listLabels.Use(auth.Require(data.PermProjectRead))
+
+	postLabel := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postLabel.HandleFunc("/projects/{projectID}/labels/", lh.CreateLabel)
+	postLabel.Use(auth.CORS)
+	postLabel.Use(auth.Middleware)
+	postLabel.Use(auth.ProjectAuthMiddleware)
+	postLabel.Use(auth.Require(data.PermLabelWrite))
+	postLabel.Use(lh.MiddlewareValidateLabel)
+
+	bulkLabel := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	bulkLabel.HandleFunc("/projects/{projectID}/labels/bulk", lh.BulkLabel)
+	bulkLabel.Use(auth.CORS)
+	bulkLabel.Use(auth.Middleware)
+	bulkLabel.Use(auth.ProjectAuthMiddleware)
+	bulkLabel.Use(auth.Require(data.PermLabelWrite))
+
+	patchLabel := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
+	patchLabel.HandleFunc("/projects/{projectID}/labels/{labelID}/", lh.UpdateLabel)
+	patchLabel.Use(auth.CORS)
+	patchLabel.Use(auth.Middleware)
+	patchLabel.Use(auth.ProjectAuthMiddleware)
+	patchLabel.Use(auth.Require(data.PermLabelWrite))
+
+	deleteLabel := sm.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteLabel.HandleFunc("/projects/{projectID}/labels/{labelID}/", lh.DeleteLabel)
+	deleteLabel.Use(auth.CORS)
+	deleteLabel.Use(auth.Middleware)
+	deleteLabel.Use(auth.ProjectAuthMiddleware)
+	deleteLabel.Use(auth.Require(data.PermLabelWrite))
+}
+
+func setCommentEndpoints(sm *mux.Router, ch *commentHandlers.Comments) {
+	const comments = "/projects/{projectID}/{target:components|links|views}/{id}/comments/"
+
+	getComment := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getComment.HandleFunc(comments+"{commentID}/", ch.GetComment)
+	getComment.Use(auth.CORS)
+	getComment.Use(auth.Middleware)
+	getComment.Use(auth.ProjectAuthMiddleware)
+	getComment.Use(auth.Require(data.PermProjectRead))
+	getComment.Use(ch.MiddlewareCommentTarget)
+
+	listComments := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	listComments.HandleFunc(comments, ch.ListComments)
+	listComments.Use(auth.CORS)
+	listComments.Use(auth.Middleware)
+	listComments.Use(auth.ProjectAuthMiddleware)
+	listComments.Use(auth.Require(data.PermProjectRead))
+	listComments.Use(ch.MiddlewareCommentTarget)
+
+	postComment := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postComment.HandleFunc(comments, ch.CreateComment)
+	postComment.Use(auth.CORS)
+	postComment.Use(auth.Middleware)
+	postComment.Use(auth.ProjectAuthMiddleware)
+	postComment.Use(auth.Require(data.PermCommentWrite))
+	postComment.Use(ch.MiddlewareCommentTarget)
+	postComment.Use(ch.MiddlewareValidateComment)
+
+	resolveThread := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	resolveThread.HandleFunc(comments+"{commentID}/resolve", ch.ResolveThread)
+	resolveThread.HandleFunc(comments+"{commentID}/reopen", ch.ReopenThread)
+	resolveThread.Use(auth.CORS)
+	resolveThread.Use(auth.Middleware)
+	resolveThread.Use(auth.ProjectAuthMiddleware)
+	resolveThread.Use(auth.Require(data.PermCommentResolve))
+	resolveThread.Use(ch.MiddlewareCommentTarget)
+
+	patchComment := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
+	patchComment.HandleFunc(comments+"{commentID}/", ch.UpdateComment)
+	patchComment.Use(auth.CORS)
+	patchComment.Use(auth.Middleware)
+	patchComment.Use(auth.ProjectAuthMiddleware)
+	patchComment.Use(auth.Require(data.PermCommentWrite))
+	patchComment.Use(ch.MiddlewareCommentTarget)
+	patchComment.Use(ch.MiddlewareValidateComment)
+}
+
+func setNotificationEndpoints(sm *mux.Router, nh *notificationHandlers.Notifications) {
+	getNotifications := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getNotifications.HandleFunc("/notifications/", nh.ListNotifications)
+	getNotifications.HandleFunc("/notifications/preferences", nh.GetPreferences)
+	getNotifications.HandleFunc("/watches/", nh.ListWatches)
+	getNotifications.Use(auth.CORS)
+	getNotifications.Use(auth.Middleware)
+	getNotifications.Use(auth.RejectProjectAccessTokens)
+
+	readNotifications := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	readNotifications.HandleFunc("/notifications/read", nh.MarkAllRead)
+	readNotifications.HandleFunc("/notifications/{notificationID}/read", nh.MarkRead)
+	readNotifications.HandleFunc("/notifications/{notificationID}/unread", nh.MarkUnread)
+	readNotifications.Use(auth.CORS)
+	readNotifications.Use(auth.Middleware)
+	readNotifications.Use(auth.RejectProjectAccessTokens)
+
+	patchPreferences := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
+	patchPreferences.HandleFunc("/notifications/preferences", nh.UpdatePreferences)
+	patchPreferences.Use(auth.CORS)
+	patchPreferences.Use(auth.Middleware)
+	patchPreferences.Use(auth.RejectProjectAccessTokens)
+
+	postWatch := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postWatch.HandleFunc("/watches/", nh.CreateWatch)
+	postWatch.Use(auth.CORS)
+	postWatch.Use(auth.Middleware)
+	postWatch.Use(auth.RejectProjectAccessTokens)
+	postWatch.Use(nh.MiddlewareValidateWatch)
+
+	deleteWatch := sm.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteWatch.HandleFunc("/watches/{watchID}/", nh.DeleteWatch)
+	deleteWatch.Use(auth.CORS)
+	deleteWatch.Use(auth.Middleware)
+	deleteWatch.Use(auth.RejectProjectAccessTokens)
+}
+
+func setWebhookEndpoints(sm *mux.Router, wh *webhookHandlers.Webhooks) {
+	getWebhook := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getWebhook.HandleFunc("/projects/{projectID}/webhooks/", wh.ListWebhooks)
+	getWebhook.HandleFunc("/projects/{projectID}/webhooks/{webhookID}/", wh.GetWebhook)
+	getWebhook.HandleFunc("/projects/{projectID}/webhooks/{webhookID}/deliveries/", wh.ListDeliveries)
+	getWebhook.HandleFunc("/projects/{projectID}/webhooks/{webhookID}/deliveries/{deliveryID}/", wh.GetDelivery)
+	getWebhook.Use(auth.CORS)
+	getWebhook.Use(auth.Middleware)
+	getWebhook.Use(auth.ProjectAuthMiddleware)
+	getWebhook.Use(auth.Require(data.PermWebhookManage))
+
+	postWebhook := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postWebhook.HandleFunc("/projects/{projectID}/webhooks/", wh.CreateWebhook)
+	postWebhook.Use(auth.CORS)
+	postWebhook.Use(auth.Middleware)
+	postWebhook.Use(auth.ProjectAuthMiddleware)
+	postWebhook.Use(auth.Require(data.PermWebhookManage))
+	postWebhook.Use(wh.MiddlewareValidateWebhook)
+
+	replayDelivery := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	replayDelivery.HandleFunc("/projects/{projectID}/webhooks/{webhookID}/deliveries/{deliveryID}/replay", wh.ReplayDelivery)
+	replayDelivery.Use(auth.CORS)
+	replayDelivery.Use(auth.Middleware)
+	replayDelivery.Use(auth.ProjectAuthMiddleware)
+	replayDelivery.Use(auth.Require(data.PermWebhookManage))
+
+	patchWebhook := sm.Methods(http.MethodPatch, http.MethodOptions).Subrouter()
+	patchWebhook.HandleFunc("/projects/{projectID}/webhooks/{webhookID}/", wh.UpdateWebhook)
+	patchWebhook.Use(auth.CORS)
+	patchWebhook.Use(auth.Middleware)
+	patchWebhook.Use(auth.ProjectAuthMiddleware)
+	patchWebhook.Use(auth.Require(data.PermWebhookManage))
+
+	deleteWebhook := sm.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteWebhook.HandleFunc("/projects/{projectID}/webhooks/{webhookID}/", wh.DeleteWebhook)
+	deleteWebhook.Use(auth.CORS)
+	deleteWebhook.Use(auth.Middleware)
+	deleteWebhook.Use(auth.ProjectAuthMiddleware)
+	deleteWebhook.Use(auth.Require(data.PermWebhookManage))
+}
+
+func setLiveEndpoints(sm *mux.Router, lh *liveHandlers.Live) {
+	stream := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	stream.HandleFunc("/projects/{projectID}/live", lh.Stream)
+	stream.Use(auth.CORS)
+	stream.Use(auth.StreamMiddleware)
+	stream.Use(auth.ProjectAuthMiddleware)
+	stream.Use(auth.Require(data.PermProjectRead))
+
+	getPresence := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getPresence.HandleFunc("/projects/{projectID}/presence", lh.GetPresence)
+	getPresence.Use(auth.CORS)
+	getPresence.Use(auth.Middleware)
+	getPresence.Use(auth.ProjectAuthMiddleware)
+	getPresence.Use(auth.Require(data.PermProjectRead))
+
+	postPresence := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postPresence.HandleFunc("/projects/{projectID}/presence", lh.UpdatePresence)
+	postPresence.Use(auth.CORS)
+	postPresence.Use(auth.Middleware)
+	postPresence.Use(auth.ProjectAuthMiddleware)
+	postPresence.Use(auth.Require(data.PermProjectRead))
+}
+
+func setChangeEndpoints(sm *mux.Router, ch *changeHandlers.Changes) {
+	getChanges := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getChanges.HandleFunc("/projects/{projectID}/changes", ch.ListChanges)
+	getChanges.Use(auth.CORS)
+	getChanges.Use(auth.Middleware)
+	getChanges.Use(auth.ProjectAuthMiddleware)
+	getChanges.Use(auth.Require(data.PermProjectRead))
+}
+
+func setInterchangeEndpoints(sm *mux.Router, ih *interchangeHandlers.Interchange) {
+	getGraph := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getGraph.HandleFunc("/projects/{projectID}/graph", ih.ExportGraph)
+	getGraph.Use(auth.CORS)
+	getGraph.Use(auth.Middleware)
+	getGraph.Use(auth.ProjectAuthMiddleware)
+	getGraph.Use(auth.Require(data.PermProjectRead))
+
+	getReqIF := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getReqIF.HandleFunc("/projects/{projectID}/reqif", ih.ExportReqIF)
+	getReqIF.Use(auth.CORS)
+	getReqIF.Use(auth.Middleware)
+	getReqIF.Use(auth.ProjectAuthMiddleware)
+	getReqIF.Use(auth.Require(data.PermProjectRead))
+
+	postReqIF := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postReqIF.HandleFunc("/projects/{projectID}/reqif", ih.ImportReqIF)
+	postReqIF.Use(auth.CORS)
+	postReqIF.Use(auth.Middleware)
+	postReqIF.Use(auth.ProjectAuthMiddleware)
+	postReqIF.Use(auth.Require(data.PermImport))
+
+	postStories := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postStories.HandleFunc("/projects/{projectID}/stories/import", ih.ImportUserStories)
+	postStories.Use(auth.CORS)
+	postStories.Use(auth.Middleware)
+	postStories.Use(auth.ProjectAuthMiddleware)
+	postStories.Use(auth.Require(data.PermImport))
+
+	postFeature := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postFeature.HandleFunc("/projects/{projectID}/features/import", ih.ImportGherkin)
+	postFeature.Use(auth.CORS)
+	postFeature.Use(auth.Middleware)
+	postFeature.Use(auth.ProjectAuthMiddleware)
+	postFeature.Use(auth.Require(data.PermImport))
+}
+
+func setScanEndpoints(sm *mux.Router, sh *scanHandlers.Scans) {
+	postGoScan := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postGoScan.HandleFunc("/projects/{projectID}/scans/go", sh.ScanGoModule)
+	postGoScan.Use(auth.CORS)
+	postGoScan.Use(auth.Middleware)
+	postGoScan.Use(auth.ProjectAuthMiddleware)
+	postGoScan.Use(auth.Require(data.PermImport))
+
+	postTraceScan := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postTraceScan.HandleFunc("/projects/{projectID}/scans/trace", sh.ScanTraceAnnotations)
+	postTraceScan.Use(auth.CORS)
+	postTraceScan.Use(auth.Middleware)
+	postTraceScan.Use(auth.ProjectAuthMiddleware)
+	postTraceScan.Use(auth.Require(data.PermImport))
+
+	postGitScan := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postGitScan.HandleFunc("/projects/{projectID}/scans/git", sh.ScanGitHistory)
+	postGitScan.Use(auth.CORS)
+	postGitScan.Use(auth.Middleware)
+	postGitScan.Use(auth.ProjectAuthMiddleware)
+	postGitScan.Use(auth.Require(data.PermImport))
+
+	postDrift := sm.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	postDrift.HandleFunc("/projects/{projectID}/drift", sh.DetectDrift)
+	postDrift.HandleFunc("/projects/{projectID}/drift/{driftID}/apply", sh.ApplyDrift)
+	postDrift.Use(auth.CORS)
+	postDrift.Use(auth.Middleware)
+	postDrift.Use(auth.ProjectAuthMiddleware)
+	postDrift.Use(auth.Require(data.PermImport))
+
+	getDrift := sm.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getDrift.HandleFunc("/projects/{projectID}/drift/{driftID}", sh.GetDrift)
+	getDrift.Use(auth.CORS)
+	getDrift.Use(auth.Middleware)
+	getDrift.Use(auth.ProjectAuthMiddleware)
+	getDrift.Use(auth.Require(data.PermProjectRead))
 }
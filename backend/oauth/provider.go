@@ -0,0 +1,307 @@
+// Package oauth implements the OAuth2 authorization code flow with PKCE for
+// logging in with OpenID Connect providers and GitHub
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Provider kinds
+const (
+	// KindOIDC is an OpenID Connect provider, its endpoints can be discovered from the issuer
+	KindOIDC = "oidc"
+	// KindGitHub is GitHub or GitHub Enterprise, it isn't an OpenID Connect provider
+	KindGitHub = "github"
+)
+
+var (
+	// ErrUnknownProvider is returned when a provider isn't configured
+	ErrUnknownProvider = errors.New("login provider not configured")
+	// ErrNoEmail is returned when a provider doesn't tell the email of the user
+	ErrNoEmail = errors.New("provider returned no email")
+)
+
+// Config configures a login provider
+type Config struct {
+	// name in the login urls, e.g. "github" or "company"
+	Name string `json:"name"`
+
+	// display name, e.g. "GitHub"
+	Label string `json:"label"`
+
+	// "oidc" or "github"
+	Kind string `json:"kind"`
+
+	// issuer url of OpenID Connect providers, the endpoints not set are
+	// discovered from its /.well-known/openid-configuration
+	Issuer string `json:"issuer,omitempty"`
+
+	// endpoints of the provider
+	AuthURL     string `json:"authURL,omitempty"`
+	TokenURL    string `json:"tokenURL,omitempty"`
+	UserInfoURL string `json:"userInfoURL,omitempty"`
+
+	// url of the verified emails of GitHub users, defaults to api.github.com
+	EmailsURL string `json:"emailsURL,omitempty"`
+
+	// credentials of the registered application
+	ClientID     string `json:"clientID"`
+	ClientSecret string `json:"clientSecret"`
+
+	// requested scopes, defaults to "openid email profile" and "read:user user:email" on GitHub
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// Identity is the user as told by a provider
+type Identity struct {
+	// id of the user at the provider
+	Subject string
+
+	// email of the user
+	Email string
+
+	// set when the provider verified the email
+	EmailVerified bool
+
+	// display name of the user
+	Name string
+}
+
+// Provider is a configured login provider
+type Provider struct {
+	Config
+	client *http.Client
+}
+
+// LoadProviders reads the provider configs from a json file, a missing
+// file configures no providers
+func LoadProviders(path string) (map[string]*Provider, error) {
+	providers := map[string]*Provider{}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return providers, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []Config
+	if err := json.Unmarshal(b, &configs); err != nil {
+		return nil, err
+	}
+	for _, c := range configs {
+		p, err := NewProvider(c)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", c.Name, err)
+		}
+		providers[c.Name] = p
+	}
+	return providers, nil
+}
+
+// NewProvider fills in the defaults of the config and discovers the
+// endpoints of OpenID Connect providers
+func NewProvider(c Config) (*Provider, error) {
+	p := &Provider{Config: c, client: &http.Client{Timeout: 10 * time.Second}}
+	switch c.Kind {
+	case KindGitHub:
+		if p.AuthURL == "" {
+			p.AuthURL = "https://github.com/login/oauth/authorize"
+		}
+		if p.TokenURL == "" {
+			p.TokenURL = "https://github.com/login/oauth/access_token"
+		}
+		if p.UserInfoURL == "" {
+			p.UserInfoURL = "https://api.github.com/user"
+		}
+		if p.EmailsURL == "" {
+			p.EmailsURL = "https://api.github.com/user/emails"
+		}
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{"read:user", "user:email"}
+		}
+	case KindOIDC:
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{"openid", "email", "profile"}
+		}
+		if p.AuthURL == "" || p.TokenURL == "" || p.UserInfoURL == "" {
+			if err := p.discover(); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown kind %q", c.Kind)
+	}
+	if p.Label == "" {
+		p.Label = p.Name
+	}
+	return p, nil
+}
+
+// discover sets the endpoints not configured from the discovery document of the issuer
+func (p *Provider) discover() error {
+	var doc struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	wellKnown := strings.TrimSuffix(p.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(wellKnown, "", &doc); err != nil {
+		return err
+	}
+	if p.AuthURL == "" {
+		p.AuthURL = doc.AuthorizationEndpoint
+	}
+	if p.TokenURL == "" {
+		p.TokenURL = doc.TokenEndpoint
+	}
+	if p.UserInfoURL == "" {
+		p.UserInfoURL = doc.UserinfoEndpoint
+	}
+	return nil
+}
+
+// AuthCodeURL returns the url of the provider the user logs in at, it
+// redirects back to redirectURI with the code and the state
+func (p *Provider) AuthCodeURL(state string, challenge string, redirectURI string) string {
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.AuthURL + sep + q.Encode()
+}
+
+// Exchange trades the code of the callback for an access token of the provider
+func (p *Provider) Exchange(code string, verifier string, redirectURI string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := p.do(req, &token); err != nil {
+		return "", err
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("%s: %s", token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("provider returned no access token")
+	}
+	return token.AccessToken, nil
+}
+
+// Identity returns the user the access token belongs to
+func (p *Provider) Identity(accessToken string) (Identity, error) {
+	if p.Kind == KindGitHub {
+		return p.gitHubIdentity(accessToken)
+	}
+
+	var info struct {
+		Subject       string      `json:"sub"`
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+		Name          string      `json:"name"`
+	}
+	if err := p.getJSON(p.UserInfoURL, accessToken, &info); err != nil {
+		return Identity{}, err
+	}
+	if info.Email == "" {
+		return Identity{}, ErrNoEmail
+	}
+	// some providers send the flag as string
+	verified := info.EmailVerified == true || info.EmailVerified == "true"
+	return Identity{Subject: info.Subject, Email: info.Email, EmailVerified: verified, Name: info.Name}, nil
+}
+
+// gitHubIdentity reads the user and its primary verified email from the GitHub api
+func (p *Provider) gitHubIdentity(accessToken string) (Identity, error) {
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := p.getJSON(p.UserInfoURL, accessToken, &user); err != nil {
+		return Identity{}, err
+	}
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(p.EmailsURL, accessToken, &emails); err != nil {
+		return Identity{}, err
+	}
+
+	id := Identity{Subject: fmt.Sprint(user.ID), Name: user.Name}
+	if id.Name == "" {
+		id.Name = user.Login
+	}
+	for _, e := range emails {
+		if e.Primary {
+			id.Email, id.EmailVerified = e.Email, e.Verified
+		}
+	}
+	if id.Email == "" {
+		return id, ErrNoEmail
+	}
+	return id, nil
+}
+
+func (p *Provider) getJSON(u string, accessToken string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return p.do(req, v)
+}
+
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, b)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,195 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// BackoffPolicy tells how long a key waits after failures
+type BackoffPolicy struct {
+	// failures allowed without waiting
+	Free int
+
+	// wait after the first failure beyond Free, it doubles with every further failure
+	Delay time.Duration
+
+	// longest wait before the lockout
+	MaxDelay time.Duration
+
+	// failures after which the key is locked out
+	LockoutAfter int
+
+	// how long a lockout lasts
+	Lockout time.Duration
+
+	// failures are forgotten this long after the last one
+	Forget time.Duration
+}
+
+// Backoff makes keys wait exponentially longer after every failure and locks
+// them out for a while after too many
+type Backoff struct {
+	policy BackoffPolicy
+
+	mu       sync.Mutex
+	failures map[string]*failures
+	swept    time.Time
+}
+
+type failures struct {
+	count int
+	last  time.Time
+	until time.Time
+}
+
+// NewBackoff returns a backoff with the policy
+func NewBackoff(policy BackoffPolicy) *Backoff {
+	return &Backoff{policy: policy, failures: map[string]*failures{}, swept: time.Now()}
+}
+
+// Wait returns how long the key has to wait before it may try again
+func (b *Backoff) Wait(key string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.sweep(now)
+	if f, ok := b.failures[key]; ok && now.Before(f.until) {
+		return f.until.Sub(now)
+	}
+	return 0
+}
+
+// Fail records a failure of the key and returns how long it has to wait now
+func (b *Backoff) Fail(key string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.sweep(now)
+	f, ok := b.failures[key]
+	if !ok {
+		f = &failures{}
+		b.failures[key] = f
+	}
+	if f.count >= b.policy.LockoutAfter && !now.Before(f.until) {
+		// the lockout is over, the next failures wait again before another one
+		f.count = b.policy.Free
+	}
+	f.count++
+	f.last = now
+
+	switch {
+	case f.count >= b.policy.LockoutAfter:
+		f.until = now.Add(b.policy.Lockout)
+	case f.count > b.policy.Free:
+		delay := b.policy.MaxDelay
+		if shift := uint(f.count - b.policy.Free - 1); shift < 30 {
+			if d := b.policy.Delay << shift; d < delay {
+				delay = d
+			}
+		}
+		f.until = now.Add(delay)
+	}
+	if !now.Before(f.until) {
+		return 0
+	}
+	return f.until.Sub(now)
+}
+
+// Reset forgets the failures of the key
+func (b *Backoff) Reset(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, key)
+}
+
+// sweep forgets the failures which are over and older than Forget
+func (b *Backoff) sweep(now time.Time) {
+	if now.Sub(b.swept) < b.policy.Forget {
+		return
+	}
+	for key, f := range b.failures {
+		if now.Sub(f.last) >= b.policy.Forget && !now.Before(f.until) {
+			delete(b.failures, key)
+		}
+	}
+	b.swept = now
+}
+
+// LoginGuard slows down guessing passwords of an account and guessing from
+// one address. Accounts are keyed by the email tried, whether or not a user
+// has it, so lockouts don't tell which accounts exist.
+//
+// The wait of an account only applies to the addresses which failed to log
+// into it, so others can't lock its user out by failing on purpose. An
+// attacker with many addresses gets a few guesses per address at most,
+// Addresses limits them across accounts.
+type LoginGuard struct {
+	Accounts  *Backoff
+	Addresses *Backoff
+
+	mu sync.Mutex
+	// failedFrom holds the time of the last failure per address per account
+	failedFrom map[string]map[string]time.Time
+	swept      time.Time
+}
+
+// failedFromAddress reports whether the address failed to log into the account lately
+func (g *LoginGuard) failedFromAddress(account string, address string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	last, ok := g.failedFrom[account][address]
+	return ok && time.Since(last) < g.Accounts.policy.Forget
+}
+
+// Wait returns how long a login of the account from the address has to wait
+func (g *LoginGuard) Wait(account string, address string) time.Duration {
+	wait := g.Addresses.Wait(address)
+	if g.failedFromAddress(account, address) {
+		if w := g.Accounts.Wait(account); w > wait {
+			wait = w
+		}
+	}
+	return wait
+}
+
+// Fail records a failed login of the account from the address
+func (g *LoginGuard) Fail(account string, address string) {
+	g.Accounts.Fail(account)
+	g.Addresses.Fail(address)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.failedFrom == nil {
+		g.failedFrom = map[string]map[string]time.Time{}
+	}
+	now := time.Now()
+	if now.Sub(g.swept) >= g.Accounts.policy.Forget {
+		for a, addresses := range g.failedFrom {
+			for addr, last := range addresses {
+				if now.Sub(last) >= g.Accounts.policy.Forget {
+					delete(addresses, addr)
+				}
+			}
+			if len(addresses) == 0 {
+				delete(g.failedFrom, a)
+			}
+		}
+		g.swept = now
+	}
+	if g.failedFrom[account] == nil {
+		g.failedFrom[account] = map[string]time.Time{}
+	}
+	g.failedFrom[account][address] = now
+}
+
+// Succeed forgets the failed logins of the account. Failures of the address
+// are kept, logging into an own account doesn't allow more guesses at others.
+func (g *LoginGuard) Succeed(account string) {
+	g.Accounts.Reset(account)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.failedFrom, account)
+}
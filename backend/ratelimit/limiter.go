@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TrustForwardedFor makes ClientIP use the X-Forwarded-For header, only set
+// it behind a proxy which sets the header
+var TrustForwardedFor = false
+
+// TrustedProxies are the networks of the proxies in front of the server,
+// their addresses in X-Forwarded-For are skipped
+var TrustedProxies = MustParseNetworks("127.0.0.0/8,::1/128,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7")
+
+// ParseNetworks parses a comma separated list of CIDR networks
+func ParseNetworks(list string) ([]*net.IPNet, error) {
+	result := []*net.IPNet{}
+	for _, cidr := range strings.Split(list, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+// MustParseNetworks is ParseNetworks which panics on invalid networks
+func MustParseNetworks(list string) []*net.IPNet {
+	result, err := ParseNetworks(list)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// trustedProxy reports whether the address is in one of the TrustedProxies
+func trustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, n := range TrustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client of the request. Behind trusted
+// proxies it is the right-most X-Forwarded-For entry which is no trusted
+// proxy, entries left of it are set by the client and can't be trusted.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !TrustForwardedFor || !trustedProxy(host) {
+		return host
+	}
+
+	var entries []string
+	for _, forwarded := range r.Header.Values("X-Forwarded-For") {
+		entries = append(entries, strings.Split(forwarded, ",")...)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(entries[i])
+		if address != "" && !trustedProxy(address) {
+			return address
+		}
+	}
+	return host
+}
+
+// Limiter allows each key limit requests per window. Unused requests
+// accumulate up to limit so short bursts are allowed.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// NewLimiter returns a limiter allowing limit requests per window for every key
+func NewLimiter(limit int, window time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: window, buckets: map[string]*bucket{}, swept: time.Now()}
+}
+
+// Allow takes a request of the key, if it isn't allowed it returns how long
+// to wait until it is
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	rate := float64(l.limit) / l.window.Seconds()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit), at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.limit), b.tokens+now.Sub(b.at).Seconds()*rate)
+	b.at = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// sweep forgets the keys which had no request for a window, their buckets are full again
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.window {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.at) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// Middleware answers 429 with a Retry-After header to clients making more
+// requests than the limiter allows, clients are told apart by their address
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		if ok, wait := l.Allow(ClientIP(r)); !ok {
+			WriteTooManyRequests(rw, wait)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// WriteTooManyRequests answers 429 telling the client to retry after wait
+func WriteTooManyRequests(rw http.ResponseWriter, wait time.Duration) {
+	rw.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+	http.Error(rw, `{{"error": "429 too many requests"}}`, http.StatusTooManyRequests)
+}
@@ -0,0 +1,239 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	data "traceability/data"
+)
+
+// maxErrorBody is the number of bytes of a failed response kept in the delivery log
+const maxErrorBody = 512
+
+// Payload is the json body posted to webhooks
+// swagger:model
+type Payload struct {
+	// type of the event
+	Event data.EventType `json:"event"`
+
+	// id of the project
+	ProjectID string `json:"projectID"`
+
+	// "project", "view", "component", "link", "comment" or "member"
+	TargetKind string `json:"targetKind"`
+
+	// id of the changed thing
+	TargetID string `json:"targetID"`
+
+	// id of the user making the change
+	Actor string `json:"actor,omitempty"`
+
+	// time of the change
+	Time time.Time `json:"time"`
+
+	// the created or updated thing
+	Data interface{} `json:"data"`
+
+	// the thing before an update
+	Before interface{} `json:"before,omitempty"`
+}
+
+// Dispatcher posts events to the webhooks of their project and retries
+// failed deliveries with exponential backoff
+type Dispatcher struct {
+	l      *log.Logger
+	client *http.Client
+
+	// MaxAttempts is the number of requests made before a delivery fails
+	MaxAttempts int
+
+	// Backoff is the delay of the first retry, it doubles with every further retry
+	Backoff time.Duration
+}
+
+// NewDispatcher returns a dispatcher making up to 6 attempts, the first retry
+// after 30 seconds, to public addresses and the AllowedNetworks only
+func NewDispatcher(l *log.Logger) *Dispatcher {
+	return &Dispatcher{
+		l:           l,
+		client:      newClient(10 * time.Second),
+		MaxAttempts: 6,
+		Backoff:     30 * time.Second,
+	}
+}
+
+// HandleEvent stores a delivery of the event for every active webhook of the
+// project subscribed to its type and starts the first attempts
+func (d *Dispatcher) HandleEvent(e data.Event) error {
+	hooks, err := data.FindWebhooksForEvent(e.ProjectID, e.Type)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(Payload{
+		Event:      e.Type,
+		ProjectID:  e.ProjectID,
+		TargetKind: e.TargetKind,
+		TargetID:   e.TargetID,
+		Actor:      e.Actor,
+		Time:       e.Time,
+		Data:       e.After,
+		Before:     e.Before,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		delivery, err := data.AddWebhookDelivery(data.WebhookDelivery{
+			WebhookID: hook.ID,
+			ProjectID: hook.ProjectID,
+			Event:     e.Type,
+			Payload:   string(payload),
+			CreatedAt: e.Time,
+		})
+		if err != nil {
+			return err
+		}
+		d.schedule(delivery, 0)
+	}
+	return nil
+}
+
+// Resume schedules the pending deliveries, e.g. the retries of a previous run of the server
+func (d *Dispatcher) Resume() error {
+	deliveries, err := data.FindPendingWebhookDeliveries()
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		var delay time.Duration
+		if delivery.NextAttempt != nil {
+			delay = time.Until(*delivery.NextAttempt)
+		}
+		d.schedule(delivery, delay)
+	}
+	return nil
+}
+
+// Replay posts the delivery again right away and returns it with the new
+// attempt. A replay is a single attempt, it isn't retried. Deliveries of
+// deactivated webhooks aren't replayed.
+func (d *Dispatcher) Replay(projectID string, webhookID string, deliveryID string) (data.WebhookDelivery, error) {
+	hook, err := data.FindWebhookByID(projectID, webhookID)
+	if err != nil {
+		return data.WebhookDelivery{}, err
+	}
+	if !hook.Active {
+		return data.WebhookDelivery{}, data.ErrWebhookInactive
+	}
+	delivery, err := data.FindWebhookDelivery(webhookID, deliveryID)
+	if err != nil {
+		return delivery, err
+	}
+
+	if d.post(hook, &delivery) {
+		delivery.Status = data.DeliverySucceeded
+	} else {
+		delivery.Status = data.DeliveryFailed
+	}
+	delivery.NextAttempt = nil
+	return delivery, data.UpdateWebhookDelivery(delivery)
+}
+
+func (d *Dispatcher) schedule(delivery data.WebhookDelivery, delay time.Duration) {
+	if delay < 0 {
+		delay = 0
+	}
+	time.AfterFunc(delay, func() {
+		if err := d.attempt(delivery.ProjectID, delivery.WebhookID, delivery.ID); err != nil {
+			d.l.Println("[ERROR] delivering webhook", delivery.ID, err)
+		}
+	})
+}
+
+// attempt makes the next request of a pending delivery and schedules a retry
+// if it fails, deliveries of deactivated webhooks fail without a request
+func (d *Dispatcher) attempt(projectID string, webhookID string, deliveryID string) error {
+	hook, err := data.FindWebhookByID(projectID, webhookID)
+	if err == data.ErrUnknownWebhook {
+		// the webhook was deleted with its deliveries
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	delivery, err := data.FindWebhookDelivery(webhookID, deliveryID)
+	if err != nil {
+		return err
+	}
+	if delivery.Status != data.DeliveryPending {
+		return nil
+	}
+
+	delivery.NextAttempt = nil
+	switch {
+	case !hook.Active:
+		// deactivating a webhook ends its retries, they can be replayed once it is active again
+		delivery.Status = data.DeliveryFailed
+	case d.post(hook, &delivery):
+		delivery.Status = data.DeliverySucceeded
+	case len(delivery.Attempts) >= d.MaxAttempts:
+		delivery.Status = data.DeliveryFailed
+	default:
+		delay := d.Backoff << uint(len(delivery.Attempts)-1)
+		next := time.Now().Add(delay)
+		delivery.NextAttempt = &next
+	}
+	if err := data.UpdateWebhookDelivery(delivery); err != nil {
+		return err
+	}
+	if delivery.NextAttempt != nil {
+		d.schedule(delivery, time.Until(*delivery.NextAttempt))
+	}
+	return nil
+}
+
+// post makes one signed request of the delivery, appends it to the
+// attempts and reports whether the webhook answered with 2xx
+func (d *Dispatcher) post(hook data.Webhook, delivery *data.WebhookDelivery) bool {
+	body := []byte(delivery.Payload)
+	attempt := data.DeliveryAttempt{Time: time.Now()}
+	defer func() {
+		attempt.DurationMS = time.Since(attempt.Time).Milliseconds()
+		delivery.Attempts = append(delivery.Attempts, attempt)
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "traceability-webhook")
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return false
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return true
+	}
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	attempt.Error = fmt.Sprintf("%s: %s", resp.Status, b)
+	return false
+}
@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// AllowedNetworks are loopback and private networks webhooks may be posted
+// to anyway, e.g. 127.0.0.1/32 for the local receiver of cmd/webhookrecv,
+// main reads them from WEBHOOK_ALLOWED_NETWORKS
+var AllowedNetworks []*net.IPNet
+
+// ErrAddressNotAllowed is returned when a webhook resolves to a loopback, private or link-local address
+var ErrAddressNotAllowed = errors.New("webhook address is not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT network, it isn't public either
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// allowedAddress reports whether webhooks may be posted to the ip
+func allowedAddress(ip net.IP) bool {
+	for _, n := range AllowedNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// newClient returns the client posting webhooks. It checks the address every
+// connection is made to, after the name was resolved, so a webhook can't
+// reach the server or its network, and it doesn't follow redirects.
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowedAddress(ip) {
+				return ErrAddressNotAllowed
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// a proxy would make the connections in place of the dialer
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the body as "sha256=<hex>"
+	SignatureHeader = "X-Traceability-Signature"
+	// EventHeader carries the event type, e.g. "component.created"
+	EventHeader = "X-Traceability-Event"
+	// DeliveryHeader carries the id of the delivery, retries and replays reuse it
+	DeliveryHeader = "X-Traceability-Delivery"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign returns the signature header value of body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body, receivers use
+// it to check that a delivery comes from a webhook with their secret
+func Verify(secret string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// NewSecret returns a random secret for a new webhook
+func NewSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}